@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/model"
+)
+
+const apikeyUsage = "usage: server apikey create -name NAME [-roles r1,r2] [-ttl 720h] | list | revoke ID"
+
+// runAPIKey implements the `apikey` subcommand. The plaintext of a new key is
+// printed once and never stored.
+func runAPIKey(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(apikeyUsage)
+	}
+
+	pool, err := db.NewDB(cfg.DatabaseDSN, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
+	if err != nil {
+		return fmt.Errorf("db init: %w", err)
+	}
+	defer pool.Close()
+	keys := dao.NewAPIKeyDAO(pool, db.DriverName(cfg.DatabaseDSN))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		name := fs.String("name", "", "who or what the key is for (required)")
+		roles := fs.String("roles", "", "comma-separated roles granted to the key")
+		ttl := fs.Duration("ttl", 0, "lifetime of the key; 0 never expires")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if strings.TrimSpace(*name) == "" {
+			return fmt.Errorf(apikeyUsage)
+		}
+		plain, err := auth.GenerateAPIKey()
+		if err != nil {
+			return err
+		}
+		k := &model.APIKey{
+			Name:    strings.TrimSpace(*name),
+			Prefix:  auth.APIKeyPrefix(plain),
+			KeyHash: auth.HashAPIKey(plain),
+			Roles:   strings.Join(strings.FieldsFunc(*roles, func(r rune) bool { return r == ',' || r == ' ' }), ","),
+		}
+		if *ttl > 0 {
+			exp := time.Now().UTC().Add(*ttl)
+			k.ExpiresAt = &exp
+		}
+		if _, err := keys.Create(ctx, k); err != nil {
+			return err
+		}
+		fmt.Printf("created api key %d (%s); it will not be shown again:\n%s\n", k.ID, k.Name, plain)
+	case "list":
+		list, err := keys.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tROLES\tCREATED AT\tSTATUS")
+		for _, k := range list {
+			status := "active"
+			switch {
+			case k.RevokedAt != nil:
+				status = "revoked " + k.RevokedAt.Format(time.RFC3339)
+			case k.ExpiresAt != nil && !time.Now().Before(*k.ExpiresAt):
+				status = "expired " + k.ExpiresAt.Format(time.RFC3339)
+			case k.ExpiresAt != nil:
+				status = "expires " + k.ExpiresAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.Prefix, k.Roles, k.CreatedAt.Format(time.RFC3339), status)
+		}
+		return tw.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf(apikeyUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid key id %q", args[1])
+		}
+		if err := keys.Revoke(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("revoked api key %d\n", id)
+	default:
+		return fmt.Errorf(apikeyUsage)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/db"
+)
+
+const auditUsage = "usage: server audit verify"
+
+// runAudit implements the `audit` subcommand. `verify` recomputes the hash
+// chain of the employee audit log and fails on the first entry that was
+// changed or removed. It prints the newest hash; keeping that elsewhere lets a
+// later run show that no entries were cut off the end.
+func runAudit(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "verify" {
+		return fmt.Errorf(auditUsage)
+	}
+
+	pool, err := db.NewDB(cfg.DatabaseDSN, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
+	if err != nil {
+		return fmt.Errorf("db init: %w", err)
+	}
+	defer pool.Close()
+
+	head, err := dao.NewAuditDAO(pool, db.DriverName(cfg.DatabaseDSN)).Verify(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("audit log ok: %d entries, head %s\n", head.Entries, head.Hash)
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/dao"
+)
+
+// newAuthenticator builds the API's authenticator chain: the admin token,
+// trusted proxy headers (when configured), JWTs (when any verification key is
+// configured) and stored API keys, in that order. With AUTH_DISABLED, requests
+// without credentials fall through to an anonymous principal.
+func newAuthenticator(cfg *config.Config, keys dao.APIKeyDAO) (auth.Authenticator, error) {
+	chain := []auth.Authenticator{
+		auth.StaticToken(cfg.AdminToken, auth.Principal{
+			Subject: "admin", Method: auth.MethodAdminToken, Roles: []string{auth.RoleAdmin},
+		}),
+	}
+	if cfg.TrustedUserHeader != "" {
+		chain = append(chain, auth.TrustedHeader(cfg.TrustedUserHeader, cfg.TrustedRolesHeader, cfg.TrustedEmployeeHeader))
+	}
+	jwtKeys, err := loadJWTKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(jwtKeys) > 0 {
+		v, err := auth.NewJWTVerifier(jwtKeys, auth.WithIssuer(cfg.JWTIssuer), auth.WithAudience(cfg.JWTAudience))
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, v)
+	}
+	chain = append(chain, auth.NewAPIKeyAuthenticator(keys))
+	if cfg.AuthDisabled {
+		chain = append(chain, auth.Anonymous(cfg.AnonymousRoles...))
+	}
+	return auth.Chain(chain...), nil
+}
+
+// loadJWTKeys reads every configured verification key. Files are read once at
+// startup; rotate keys by restarting.
+func loadJWTKeys(cfg *config.Config) ([]auth.Key, error) {
+	var keys []auth.Key
+	if cfg.JWTSecret != "" {
+		k, err := auth.HMACKey("", []byte(cfg.JWTSecret))
+		if err != nil {
+			return nil, fmt.Errorf("JWT_HS256_SECRET: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	for _, path := range cfg.JWTPublicKeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("JWT public key: %w", err)
+		}
+		k, err := auth.ParsePublicKeyPEM("", data)
+		if err != nil {
+			return nil, fmt.Errorf("JWT public key %s: %w", path, err)
+		}
+		keys = append(keys, k)
+	}
+	if cfg.JWTJWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWTJWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS: %w", err)
+		}
+		set, err := auth.ParseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS %s: %w", cfg.JWTJWKSFile, err)
+		}
+		keys = append(keys, set...)
+	}
+	return keys, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/service"
+)
+
+const importUsage = "usage: server import [-dry-run] [-map column=field]... [-report errors.csv] FILE|-"
+
+// columnFlags collects repeated -map flags.
+type columnFlags []string
+
+func (c *columnFlags) String() string { return fmt.Sprint(*c) }
+
+func (c *columnFlags) Set(v string) error {
+	*c = append(*c, v)
+	return nil
+}
+
+// runImport implements the `import` subcommand: the CSV import of
+// POST /api/v1/employees/import, run directly against the database with the
+// same validation. Writes are audited as the system actor. Failed rows are
+// printed and, with -report, written to a CSV file that can be fixed and
+// imported again.
+func runImport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "validate and report without writing anything")
+	report := fs.String("report", "", "write the failed rows to this CSV file")
+	var pairs columnFlags
+	fs.Var(&pairs, "map", "map a CSV column onto an employee field (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf(importUsage)
+	}
+
+	var in io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	columns, err := service.ParseColumnMap(pairs)
+	if err != nil {
+		return err
+	}
+	ir, err := service.NewImportReader(in, columns)
+	if err != nil {
+		return err
+	}
+	var ew *service.ImportErrorWriter
+	if *report != "" {
+		f, err := os.Create(*report)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		ew = service.NewImportErrorWriter(f, ir.Header())
+		defer ew.Close()
+	}
+
+	pool, err := db.NewDB(cfg.DatabaseDSN, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
+	if err != nil {
+		return fmt.Errorf("db init: %w", err)
+	}
+	defer pool.Close()
+	driver := db.DriverName(cfg.DatabaseDSN)
+	svc := service.NewEmployeeService(dao.NewEmployeeDAO(pool, driver),
+		service.WithAllowedPositions(cfg.AllowedPositions),
+		service.WithReservedDeletedEmails(cfg.ReserveDeletedEmails),
+		service.WithDepartments(dao.NewDepartmentDAO(pool, driver)),
+	)
+
+	sum, err := svc.ImportEmployees(context.Background(), ir, *dryRun, func(row *service.ImportRow) error {
+		if row.Err == nil {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "line %d: %s\n", row.Line, service.ImportErrorMessage(row.Err))
+		if ew != nil {
+			return ew.Write(row)
+		}
+		return nil
+	})
+	if err != nil {
+		if ew != nil {
+			ew.Abort(err)
+		}
+		return err
+	}
+	verb := "imported"
+	if sum.DryRun {
+		verb = "dry run"
+	}
+	fmt.Printf("%s: %d rows, %d created, %d updated, %d unchanged, %d failed\n",
+		verb, sum.Rows, sum.Created, sum.Updated, sum.Unchanged, sum.Failed)
+	if sum.Failed > 0 {
+		return fmt.Errorf("%d rows failed", sum.Failed)
+	}
+	return nil
+}
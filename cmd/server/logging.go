@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/logging"
+)
+
+// newLogger builds the logger LOG_FORMAT and LOG_LEVEL ask for, writing to
+// stderr. The returned level is what the admin listener changes at runtime.
+func newLogger(cfg *config.Config) (*slog.Logger, *slog.LevelVar, error) {
+	l, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, nil, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	level := new(slog.LevelVar)
+	level.Set(l)
+	logger, err := logging.New(os.Stderr, cfg.LogFormat, level)
+	if err != nil {
+		return nil, nil, fmt.Errorf("LOG_FORMAT: %w", err)
+	}
+	return logger, level, nil
+}
+
+// fatal logs err under msg and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
@@ -2,7 +2,8 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,24 +12,122 @@ import (
 	"emplopyee-app-go/internal/config"
 	"emplopyee-app-go/internal/dao"
 	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/handler"
+	"emplopyee-app-go/internal/metrics"
 	"emplopyee-app-go/internal/router"
 	"emplopyee-app-go/internal/service"
 )
 
 func main() {
 	cfg := config.Load() // reads from env/defaults
+	logger, level, err := newLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// also routes the standard log package, and so net/http's own messages,
+	// through logger
+	slog.SetDefault(logger)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(cfg, os.Args[2:]); err != nil {
+				fatal("migrate", err)
+			}
+			return
+		case "apikey":
+			if err := runAPIKey(cfg, os.Args[2:]); err != nil {
+				fatal("apikey", err)
+			}
+			return
+		case "audit":
+			if err := runAudit(cfg, os.Args[2:]); err != nil {
+				fatal("audit", err)
+			}
+			return
+		case "import":
+			if err := runImport(cfg, os.Args[2:]); err != nil {
+				fatal("import", err)
+			}
+			return
+		default:
+			fatal("usage", fmt.Errorf("unknown command %q", os.Args[1]))
+		}
+	}
 
 	// Initialize DB pool
 	pool, err := db.NewDB(cfg.DatabaseDSN, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
 	if err != nil {
-		log.Fatalf("db init: %v", err)
+		fatal("db init", err)
 	}
 	defer pool.Close()
 
+	migrator, err := db.NewMigrator(pool, db.DriverName(cfg.DatabaseDSN))
+	if err != nil {
+		fatal("db migrations", err)
+	}
+	migCtx, migCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	err = migrateOnStartup(migCtx, cfg, migrator)
+	migCancel()
+	if err != nil {
+		fatal("db migrations", err)
+	}
+
+	tracer, err := newTracer(cfg)
+	if err != nil {
+		fatal("tracing", err)
+	}
+
 	// Wire dependencies (manual DI)
-	empDAO := dao.NewEmployeeDAO(pool)
-	empService := service.NewEmployeeService(empDAO)
-	r := router.NewRouter(empService)
+	empDAO := dao.NewEmployeeDAO(pool, db.DriverName(cfg.DatabaseDSN), dao.WithTracer(tracer))
+	deptDAO := dao.NewDepartmentDAO(pool, db.DriverName(cfg.DatabaseDSN))
+	empService := service.NewEmployeeService(empDAO,
+		service.WithAllowedPositions(cfg.AllowedPositions),
+		service.WithReservedDeletedEmails(cfg.ReserveDeletedEmails),
+		service.WithDepartments(deptDAO),
+	)
+	deptService := service.NewDepartmentService(deptDAO)
+	auditService := service.NewAuditService(dao.NewAuditDAO(pool, db.DriverName(cfg.DatabaseDSN)))
+	// every transport goes through the authorizing decorators
+	empService = service.NewAuthorizedEmployeeService(empService)
+	var reg *metrics.Registry
+	if cfg.AdminAddr != "" {
+		reg = newMetrics(pool, deptDAO)
+		empService = service.NewInstrumentedEmployeeService(empService, reg)
+	}
+	if tracer != nil {
+		empService = service.NewTracedEmployeeService(empService, tracer)
+	}
+	deptService = service.NewAuthorizedDepartmentService(deptService)
+	auditService = service.NewAuthorizedAuditService(auditService)
+	authn, err := newAuthenticator(cfg, dao.NewAPIKeyDAO(pool, db.DriverName(cfg.DatabaseDSN)))
+	if err != nil {
+		fatal("auth", err)
+	}
+	routerOpts := []router.Option{router.WithHandlerOptions(
+		handler.WithRequireIfMatch(cfg.RequireIfMatch),
+		handler.WithBatchLimits(cfg.BatchMaxOperations, cfg.BatchMaxBytes),
+	)}
+	if cfg.ValidateRequests {
+		v, err := newValidator(cfg)
+		if err != nil {
+			fatal("openapi", err)
+		}
+		routerOpts = append(routerOpts, router.WithRequestValidation(v, cfg.BatchMaxBytes, cfg.DevMode))
+	}
+	if reg != nil {
+		routerOpts = append(routerOpts, router.WithMetrics(reg))
+	}
+	if tracer != nil {
+		routerOpts = append(routerOpts, router.WithTracer(tracer))
+	}
+	if cfg.IdempotencyTTL > 0 {
+		routerOpts = append(routerOpts, router.WithIdempotency(
+			dao.NewIdempotencyDAO(pool, db.DriverName(cfg.DatabaseDSN)), cfg.IdempotencyTTL, cfg.IdempotencyMaxBytes))
+	}
+	routerOpts = append(routerOpts, router.WithBulkTimeout(cfg.BulkTimeout))
+	r := router.NewRouter(empService, deptService, auditService, authn, routerOpts...)
 
 	srv := &http.Server{
 		Addr:         cfg.ServerAddr,
@@ -36,25 +135,52 @@ func main() {
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
 	}
 
 	go func() {
-		log.Printf("server listening on %s\n", cfg.ServerAddr)
+		slog.Info("server listening", "addr", cfg.ServerAddr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
+			fatal("listen", err)
 		}
 	}()
 
+	// the admin listener is separate so /metrics is never exposed with the API
+	var admin *http.Server
+	if cfg.AdminAddr != "" {
+		admin = &http.Server{
+			Addr:        cfg.AdminAddr,
+			Handler:     router.NewAdminRouter(reg, level),
+			ReadTimeout: 15 * time.Second,
+			IdleTimeout: 60 * time.Second,
+			ErrorLog:    slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		}
+		go func() {
+			slog.Info("admin listening", "addr", cfg.AdminAddr)
+			if err := admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("admin listen", err)
+			}
+		}()
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
-	log.Println("shutting down server...")
+	slog.Info("shutting down server")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("server Shutdown: %v", err)
+		fatal("server shutdown", err)
+	}
+	if admin != nil {
+		if err := admin.Shutdown(ctx); err != nil {
+			fatal("admin shutdown", err)
+		}
+	}
+	if err := tracer.Shutdown(ctx); err != nil {
+		slog.Error("tracing shutdown", "err", err)
 	}
-	log.Println("server stopped")
+	slog.Info("server stopped")
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/metrics"
+)
+
+// newMetrics returns a registry with the connection pool statistics of pool
+// and the business gauges, which query depts on each scrape. Request and
+// service metrics are added by the router and service decorators.
+func newMetrics(pool *sql.DB, depts dao.DepartmentDAO) *metrics.Registry {
+	reg := metrics.NewRegistry()
+	metrics.RegisterDBStats(reg, pool)
+	reg.GaugeFunc("employees", "Live employees by department; department_id is empty for employees without one.",
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			counts, err := depts.Headcounts(ctx)
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]metrics.Sample, len(counts))
+			for i, c := range counts {
+				id := ""
+				if c.DepartmentID != nil {
+					id = strconv.FormatInt(*c.DepartmentID, 10)
+				}
+				samples[i] = metrics.Sample{
+					Labels: []metrics.Label{{Name: "department_id", Value: id}, {Name: "department", Value: c.Department}},
+					Value:  float64(c.Employees),
+				}
+			}
+			return samples, nil
+		})
+	return reg
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/db"
+)
+
+const migrateUsage = "usage: server migrate up|down|status|redo"
+
+// runMigrate implements the `migrate` subcommand.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	pool, err := db.NewDB(cfg.DatabaseDSN, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
+	if err != nil {
+		return fmt.Errorf("db init: %w", err)
+	}
+	defer pool.Close()
+
+	m, err := db.NewMigrator(pool, db.DriverName(cfg.DatabaseDSN))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		ran, err := m.Up(ctx)
+		for _, mig := range ran {
+			fmt.Printf("applied %d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Println("database is up to date")
+		}
+	case "down":
+		mig, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if mig == nil {
+			fmt.Println("nothing to roll back")
+			return nil
+		}
+		fmt.Printf("rolled back %d_%s\n", mig.Version, mig.Name)
+	case "redo":
+		mig, err := m.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		if mig == nil {
+			fmt.Println("nothing to redo")
+			return nil
+		}
+		fmt.Printf("redid %d_%s\n", mig.Version, mig.Name)
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range st {
+			state, at := "pending", ""
+			if s.Applied {
+				state, at = "applied", s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Unknown {
+				state = "unknown (database ahead)"
+			}
+			if s.Modified {
+				state = "applied (checksum mismatch)"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+	return nil
+}
+
+// migrateOnStartup applies pending migrations under the migration lock, or,
+// with auto-migration disabled, refuses to start unless the schema is current.
+// Either way a database that is ahead of the binary is rejected.
+func migrateOnStartup(ctx context.Context, cfg *config.Config, m *db.Migrator) error {
+	if !cfg.AutoMigrate {
+		return m.Check(ctx)
+	}
+	_, err := m.Up(ctx)
+	return err
+}
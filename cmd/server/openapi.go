@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/openapi"
+)
+
+// newValidator builds the request validator from OPENAPI_FILE, or from the
+// embedded document when it is unset.
+func newValidator(cfg *config.Config) (*openapi.Validator, error) {
+	doc := openapi.Document()
+	if cfg.OpenAPIFile != "" {
+		b, err := os.ReadFile(cfg.OpenAPIFile)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", cfg.OpenAPIFile, err)
+		}
+		doc = b
+	}
+	return openapi.NewValidator(doc)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"emplopyee-app-go/internal/config"
+	"emplopyee-app-go/internal/tracing"
+)
+
+// newTracer builds the tracer OTEL_TRACES_EXPORTER asks for, or returns nil
+// when tracing is off.
+func newTracer(cfg *config.Config) (*tracing.Tracer, error) {
+	var exp tracing.Exporter
+	switch cfg.TracesExporter {
+	case "none", "":
+		return nil, nil
+	case "otlp":
+		headers := map[string]string{}
+		for _, h := range cfg.OTLPHeaders {
+			k, v, ok := strings.Cut(h, "=")
+			if !ok {
+				return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: %q is not key=value", h)
+			}
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		exp = tracing.NewOTLPExporter(cfg.ServiceName, cfg.OTLPEndpoint, headers)
+	case "stdout", "console":
+		// hide Close, so shutting the exporter down leaves stdout open
+		exp = tracing.NewWriterExporter(cfg.ServiceName, struct{ io.Writer }{os.Stdout})
+	case "file":
+		f, err := os.OpenFile(cfg.TracesFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open traces file: %w", err)
+		}
+		exp = tracing.NewWriterExporter(cfg.ServiceName, f)
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q (want none, otlp, stdout or file)", cfg.TracesExporter)
+	}
+	return tracing.NewTracer(cfg.ServiceName, exp), nil
+}
@@ -0,0 +1,121 @@
+// Package apperr defines the application's error taxonomy. The DAO maps driver
+// errors onto these kinds, the service adds domain meaning, and the HTTP layer
+// renders them without ever exposing the underlying cause to clients.
+package apperr
+
+import (
+	"context"
+	"errors"
+)
+
+// Kind classifies an error by how a caller should react to it.
+type Kind int
+
+const (
+	Internal           Kind = iota // unexpected failure; details are logged, not returned
+	Invalid                        // malformed request (bad parameter, cursor, etc.)
+	Validation                     // well-formed input that breaks business rules
+	NotFound                       // the addressed record does not exist
+	Conflict                       // the change clashes with existing state (e.g. duplicate email)
+	Unavailable                    // the database is closed, busy or unreachable
+	Timeout                        // the operation ran past its deadline or was cancelled
+	PreconditionFailed             // an If-Match / expected version did not match the stored record
+	Unauthenticated                // missing, malformed, expired or unknown credentials
+	Forbidden                      // the caller is known but lacks the permission
+)
+
+var kindNames = map[Kind]string{
+	Internal:           "internal",
+	Invalid:            "invalid",
+	Validation:         "validation",
+	NotFound:           "not_found",
+	Conflict:           "conflict",
+	Unavailable:        "unavailable",
+	Timeout:            "timeout",
+	PreconditionFailed: "precondition_failed",
+	Unauthenticated:    "unauthenticated",
+	Forbidden:          "forbidden",
+}
+
+func (k Kind) String() string { return kindNames[k] }
+
+// Error is a classified error. Message is safe to show to clients; Err is the
+// underlying cause and is only surfaced through Error() for logs.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// New returns an error of the given kind with a client-safe message.
+func New(kind Kind, msg string) *Error {
+	return &Error{Kind: kind, Message: msg}
+}
+
+// Wrap classifies err, keeping it reachable through errors.Is/As.
+func Wrap(kind Kind, msg string, err error) *Error {
+	return &Error{Kind: kind, Message: msg, Err: err}
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.String()
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrorKind implements the interface KindOf looks for.
+func (e *Error) ErrorKind() Kind { return e.Kind }
+
+// KindOf returns the kind of the first classified error in err's chain.
+// Context errors are recognised even when unclassified.
+func KindOf(err error) Kind {
+	if err == nil {
+		return Internal
+	}
+	var k interface{ ErrorKind() Kind }
+	if errors.As(err, &k) {
+		return k.ErrorKind()
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return Timeout
+	}
+	return Internal
+}
+
+// Is reports whether err is classified as kind.
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// Message returns the client-safe message of err, falling back to a generic
+// description of its kind so that internal details never leak. Error types
+// outside this package can supply their own by implementing
+// ClientMessage() string.
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Message != "" {
+		return e.Message
+	}
+	var cm interface{ ClientMessage() string }
+	if errors.As(err, &cm) {
+		return cm.ClientMessage()
+	}
+	switch KindOf(err) {
+	case Validation:
+		return "validation failed"
+	case Timeout:
+		return "the request timed out"
+	case Unavailable:
+		return "the service is temporarily unavailable"
+	case NotFound:
+		return "not found"
+	}
+	return "internal server error"
+}
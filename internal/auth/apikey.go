@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+// APIKeyHeader carries an API key on a request.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix marks generated keys so they are easy to spot in config files
+// and secret scanners.
+const apiKeyPrefix = "emp_"
+
+// APIKeyStore looks stored keys up by hash; dao.APIKeyDAO implements it.
+type APIKeyStore interface {
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+}
+
+// GenerateAPIKey returns a new random key. Only HashAPIKey(key) should be
+// stored; the key itself is shown to the user once.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashAPIKey returns the hex SHA-256 of key. Keys carry 256 bits of entropy,
+// so a fast unsalted hash is enough and allows an indexed lookup.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix returns the leading part of key kept in model.APIKey.Prefix.
+func APIKeyPrefix(key string) string {
+	if len(key) > 12 {
+		return key[:12]
+	}
+	return key
+}
+
+type apiKeyAuthenticator struct {
+	store APIKeyStore
+	now   func() time.Time
+}
+
+// NewAPIKeyAuthenticator accepts keys sent in the X-API-Key header. Unknown,
+// revoked and expired keys are rejected; store failures are passed through so
+// an unreachable database surfaces as 503 rather than 401.
+func NewAPIKeyAuthenticator(store APIKeyStore) Authenticator {
+	return &apiKeyAuthenticator{store: store, now: time.Now}
+}
+
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	k, err := a.store.GetByHash(r.Context(), HashAPIKey(key))
+	if apperr.Is(err, apperr.NotFound) {
+		return nil, unauthenticated("invalid api key", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.RevokedAt != nil {
+		return nil, unauthenticated("api key has been revoked", nil)
+	}
+	if k.ExpiresAt != nil && !a.now().Before(*k.ExpiresAt) {
+		return nil, unauthenticated("api key has expired", nil)
+	}
+	return &Principal{Subject: "api_key:" + k.Name, Method: MethodAPIKey, Roles: splitRoles(k.Roles)}, nil
+}
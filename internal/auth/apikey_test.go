@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyStore map[string]*model.APIKey
+
+func (s keyStore) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	if k, ok := s[hash]; ok {
+		return k, nil
+	}
+	return nil, apperr.New(apperr.NotFound, "record not found")
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	key, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "emp_"))
+
+	past := time.Now().Add(-time.Hour)
+	store := keyStore{HashAPIKey(key): {Name: "ci", Roles: "viewer,hr_editor"}}
+	a := NewAPIKeyAuthenticator(store)
+	req := func(key string) (*Principal, error) {
+		r := httptest.NewRequest("GET", "/", nil)
+		if key != "" {
+			r.Header.Set(APIKeyHeader, key)
+		}
+		return a.Authenticate(r)
+	}
+
+	p, err := req(key)
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Subject: "api_key:ci", Method: MethodAPIKey, Roles: []string{"viewer", "hr_editor"}}, p)
+
+	_, err = req("")
+	assert.ErrorIs(t, err, ErrNoCredentials)
+	_, err = req("emp_unknown")
+	assert.True(t, apperr.Is(err, apperr.Unauthenticated))
+
+	store[HashAPIKey(key)].ExpiresAt = &past
+	_, err = req(key)
+	assert.Equal(t, "api key has expired", apperr.Message(err))
+	store[HashAPIKey(key)].RevokedAt = &past
+	_, err = req(key)
+	assert.Equal(t, "api key has been revoked", apperr.Message(err))
+}
@@ -0,0 +1,164 @@
+// Package auth authenticates API callers. An Authenticator turns the
+// credentials on a request into a Principal; the router stores it in the
+// request context, where handlers and services read it back with FromContext.
+//
+// Credentials that are present but wrong are reported as apperr.Unauthenticated.
+// An authenticator that finds no credentials it understands returns
+// ErrNoCredentials, which lets Chain fall through to the next one.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"emplopyee-app-go/internal/apperr"
+)
+
+// Roles a principal can hold. What each one permits is decided by the
+// service layer; see service.NewAuthorizedEmployeeService.
+const (
+	RoleViewer = "viewer"    // read any employee and department
+	RoleEditor = "hr_editor" // change employees and departments
+	RoleAdmin  = "hr_admin"  // everything, including purge
+	RoleSelf   = "self"      // read and partly edit one's own record, read one's reports
+)
+
+// Principal methods, recorded so logs and audits can tell how a caller
+// authenticated.
+const (
+	MethodJWT           = "jwt"
+	MethodAPIKey        = "api_key"
+	MethodAdminToken    = "admin_token"
+	MethodTrustedHeader = "trusted_header"
+	MethodAnonymous     = "anonymous"
+)
+
+// ErrNoCredentials means the request carries no credentials the authenticator
+// understands. It is not an authentication failure on its own.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Principal is an authenticated caller. EmployeeID links the caller to their
+// own employee record (0 when unknown); row-level rules for RoleSelf use it.
+type Principal struct {
+	Subject    string
+	Method     string
+	Roles      []string
+	EmployeeID int64
+}
+
+// HasRole reports whether p was granted role.
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator identifies the caller of a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) { return f(r) }
+
+// Chain tries each authenticator in order and returns the first principal or
+// the first real failure. It returns ErrNoCredentials only if every
+// authenticator did.
+func Chain(as ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		for _, a := range as {
+			p, err := a.Authenticate(r)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			return p, err
+		}
+		return nil, ErrNoCredentials
+	})
+}
+
+// StaticToken accepts a single shared bearer token and authenticates it as p.
+// Any other bearer token is passed on, so it can sit in front of a JWT
+// verifier. An empty token disables it.
+func StaticToken(token string, p Principal) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		got, ok := bearerToken(r)
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return nil, ErrNoCredentials
+		}
+		out := p
+		return &out, nil
+	})
+}
+
+// Anonymous authenticates every request as an anonymous caller holding
+// roles. It is meant to end a Chain when authentication is switched off.
+func Anonymous(roles ...string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		return &Principal{Subject: "anonymous", Method: MethodAnonymous, Roles: roles}, nil
+	})
+}
+
+// TrustedHeader takes the caller's identity from headers set by an
+// authenticating proxy: the subject from userHeader, a comma-separated role
+// list from rolesHeader and the employee id from employeeHeader. Only use it
+// when the proxy strips these headers from client requests.
+func TrustedHeader(userHeader, rolesHeader, employeeHeader string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		sub := strings.TrimSpace(r.Header.Get(userHeader))
+		if sub == "" {
+			return nil, ErrNoCredentials
+		}
+		p := &Principal{Subject: sub, Method: MethodTrustedHeader, Roles: splitRoles(r.Header.Get(rolesHeader))}
+		if s := strings.TrimSpace(r.Header.Get(employeeHeader)); s != "" {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || id < 1 {
+				return nil, unauthenticated("invalid "+employeeHeader+" header", err)
+			}
+			p.EmployeeID = id
+		}
+		return p, nil
+	})
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the principal stored by NewContext.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthenticated classifies a credential failure; msg is shown to the client.
+func unauthenticated(msg string, err error) error {
+	return apperr.Wrap(apperr.Unauthenticated, msg, err)
+}
+
+// splitRoles parses a comma- or space-separated role list.
+func splitRoles(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	admin := StaticToken("s3cret", Principal{Subject: "admin", Method: MethodAdminToken, Roles: []string{RoleAdmin}})
+	a := Chain(admin, NewAPIKeyAuthenticator(keyStore{}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer s3cret")
+	p, err := a.Authenticate(r)
+	require.NoError(t, err)
+	assert.True(t, p.HasRole(RoleAdmin))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer wrong")
+	_, err = a.Authenticate(r)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	r.Header.Set(APIKeyHeader, "emp_unknown")
+	_, err = a.Authenticate(r)
+	assert.True(t, apperr.Is(err, apperr.Unauthenticated), "a bad credential stops the chain")
+
+	p, err = Chain(a, Anonymous()).Authenticate(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, MethodAnonymous, p.Method)
+	assert.Empty(t, p.Roles)
+}
+
+func TestTrustedHeader(t *testing.T) {
+	a := TrustedHeader("X-Remote-User", "X-Remote-Roles", "X-Remote-Employee-Id")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	_, err := a.Authenticate(r)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	r.Header.Set("X-Remote-User", "bob")
+	r.Header.Set("X-Remote-Roles", "self, viewer")
+	r.Header.Set("X-Remote-Employee-Id", "42")
+	p, err := a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Subject: "bob", Method: MethodTrustedHeader, Roles: []string{RoleSelf, RoleViewer}, EmployeeID: 42}, p)
+
+	r.Header.Set("X-Remote-Employee-Id", "x")
+	_, err = a.Authenticate(r)
+	assert.True(t, apperr.Is(err, apperr.Unauthenticated))
+}
@@ -0,0 +1,332 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported JWS algorithms. Each key is bound to exactly one of them, so a
+// token cannot choose how its own signature is checked (no "none", no
+// RS256/HS256 confusion).
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// minHMACKeyLen is the shortest HS256 secret accepted (RFC 7518 section 3.2).
+const minHMACKeyLen = 32
+
+// Key is a verification key. ID is matched against the token's "kid"; a key
+// without an ID matches any kid.
+type Key struct {
+	ID        string
+	Algorithm string
+	key       interface{}
+}
+
+// HMACKey returns an HS256 key for a shared secret.
+func HMACKey(id string, secret []byte) (Key, error) {
+	if len(secret) < minHMACKeyLen {
+		return Key{}, fmt.Errorf("HS256 secret must be at least %d bytes", minHMACKeyLen)
+	}
+	return Key{ID: id, Algorithm: HS256, key: secret}, nil
+}
+
+// PublicKey returns an RS256 key for an RSA public key (2048 bits or more)
+// or an EdDSA key for an Ed25519 public key.
+func PublicKey(id string, pub crypto.PublicKey) (Key, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return Key{}, fmt.Errorf("RSA key is %d bits, need at least 2048", k.N.BitLen())
+		}
+		return Key{ID: id, Algorithm: RS256, key: k}, nil
+	case ed25519.PublicKey:
+		return Key{ID: id, Algorithm: EdDSA, key: k}, nil
+	}
+	return Key{}, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// ParsePublicKeyPEM reads a PKIX ("PUBLIC KEY") or PKCS #1 ("RSA PUBLIC KEY")
+// PEM block.
+func ParsePublicKeyPEM(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM block found")
+	}
+	var pub crypto.PublicKey
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	return PublicKey(id, pub)
+}
+
+// jwk is the subset of RFC 7517 members needed for RSA, OKP (Ed25519) and
+// oct keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS reads a JSON Web Key Set. Keys marked for encryption ("use":
+// "enc") are skipped; any other key that cannot be used is an error, so a
+// typo in the file does not silently lock everyone out.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+	var keys []Key
+	for i, j := range set.Keys {
+		if j.Use == "enc" {
+			continue
+		}
+		k, err := j.key()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (%q): %w", i, j.Kid, err)
+		}
+		if j.Alg != "" && j.Alg != k.Algorithm {
+			return nil, fmt.Errorf("JWKS key %d (%q): alg %q does not match key type %s", i, j.Kid, j.Alg, j.Kty)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (j jwk) key() (Key, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+	switch j.Kty {
+	case "RSA":
+		n, err := b64(j.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("bad n: %w", err)
+		}
+		e, err := b64(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return Key{}, errors.New("bad e")
+		}
+		exp := int(new(big.Int).SetBytes(e).Int64())
+		return PublicKey(j.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp})
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return Key{}, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := b64(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return Key{}, errors.New("bad x")
+		}
+		return PublicKey(j.Kid, ed25519.PublicKey(x))
+	case "oct":
+		k, err := b64(j.K)
+		if err != nil {
+			return Key{}, fmt.Errorf("bad k: %w", err)
+		}
+		return HMACKey(j.Kid, k)
+	}
+	return Key{}, fmt.Errorf("unsupported kty %q", j.Kty)
+}
+
+// Claims are the registered claims the verifier checks plus the roles the
+// principal is granted and, for employees, their own employee id.
+type Claims struct {
+	Subject    string   `json:"sub"`
+	Issuer     string   `json:"iss"`
+	Audience   audience `json:"aud"`
+	ExpiresAt  *float64 `json:"exp"`
+	NotBefore  *float64 `json:"nbf"`
+	Roles      []string `json:"roles"`
+	EmployeeID int64    `json:"employee_id"`
+}
+
+// audience accepts both forms RFC 7519 allows: a string or an array.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return errors.New("aud must be a string or an array of strings")
+	}
+	*a = list
+	return nil
+}
+
+// JWTVerifier checks compact JWS tokens sent as "Authorization: Bearer".
+type JWTVerifier struct {
+	keys     []Key
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// JWTOption configures a JWTVerifier.
+type JWTOption func(*JWTVerifier)
+
+// WithIssuer requires the "iss" claim to equal iss.
+func WithIssuer(iss string) JWTOption {
+	return func(v *JWTVerifier) { v.issuer = iss }
+}
+
+// WithAudience requires aud to be among the token's audiences.
+func WithAudience(aud string) JWTOption {
+	return func(v *JWTVerifier) { v.audience = aud }
+}
+
+// WithLeeway tolerates clock skew when checking exp and nbf. The default is
+// one minute.
+func WithLeeway(d time.Duration) JWTOption {
+	return func(v *JWTVerifier) { v.leeway = d }
+}
+
+// NewJWTVerifier returns a verifier for tokens signed by any of keys.
+func NewJWTVerifier(keys []Key, opts ...JWTOption) (*JWTVerifier, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no JWT verification keys configured")
+	}
+	v := &JWTVerifier{keys: keys, leeway: time.Minute, now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
+
+// Authenticate implements Authenticator. Bearer values that are not shaped
+// like a JWT are left to the next authenticator in the chain.
+func (v *JWTVerifier) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+	c, err := v.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: c.Subject, Method: MethodJWT, Roles: c.Roles, EmployeeID: c.EmployeeID}, nil
+}
+
+// Verify checks the signature and the time, issuer and audience claims of
+// token. Errors are apperr.Unauthenticated with a client-safe message.
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, unauthenticated("malformed token", nil)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, unauthenticated("malformed token header", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, unauthenticated("malformed token signature", err)
+	}
+	if !v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], sig) {
+		return nil, unauthenticated("token signature is invalid", nil)
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, unauthenticated("malformed token claims", err)
+	}
+	now := v.now()
+	switch {
+	case c.Subject == "":
+		return nil, unauthenticated("token has no subject", nil)
+	case c.ExpiresAt == nil:
+		return nil, unauthenticated("token has no expiry", nil)
+	case now.After(unixTime(*c.ExpiresAt).Add(v.leeway)):
+		return nil, unauthenticated("token has expired", nil)
+	case c.NotBefore != nil && now.Add(v.leeway).Before(unixTime(*c.NotBefore)):
+		return nil, unauthenticated("token is not valid yet", nil)
+	case v.issuer != "" && c.Issuer != v.issuer:
+		return nil, unauthenticated("token issuer is not accepted", nil)
+	case v.audience != "" && !c.Audience.contains(v.audience):
+		return nil, unauthenticated("token audience is not accepted", nil)
+	}
+	return &c, nil
+}
+
+// verifySignature tries every key bound to alg whose ID matches kid.
+func (v *JWTVerifier) verifySignature(alg, kid, input string, sig []byte) bool {
+	for _, k := range v.keys {
+		if k.Algorithm != alg || (kid != "" && k.ID != "" && k.ID != kid) {
+			continue
+		}
+		var ok bool
+		switch alg {
+		case HS256:
+			mac := hmac.New(sha256.New, k.key.([]byte))
+			mac.Write([]byte(input))
+			ok = hmac.Equal(mac.Sum(nil), sig)
+		case RS256:
+			sum := sha256.Sum256([]byte(input))
+			ok = rsa.VerifyPKCS1v15(k.key.(*rsa.PublicKey), crypto.SHA256, sum[:], sig) == nil
+		case EdDSA:
+			ok = ed25519.Verify(k.key.(ed25519.PublicKey), []byte(input), sig)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (a audience) contains(s string) bool {
+	for _, x := range a {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func unixTime(sec float64) time.Time {
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
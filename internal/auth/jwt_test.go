@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// sign builds a compact JWS; sig receives the signing input.
+func sign(t *testing.T, header, claims map[string]interface{}, sig func(input []byte) []byte) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	input := b64(h) + "." + b64(c)
+	return input + "." + b64(sig([]byte(input)))
+}
+
+func hs256(input []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(input)
+	return mac.Sum(nil)
+}
+
+func claims(extra map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix(), "roles": []string{"viewer"}}
+	for k, v := range extra {
+		c[k] = v
+	}
+	return c
+}
+
+func TestJWTVerifier_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	hk, err := HMACKey("hs", secret)
+	require.NoError(t, err)
+	rk, err := PublicKey("rs", &rsaKey.PublicKey)
+	require.NoError(t, err)
+	ek, err := PublicKey("ed", edPub)
+	require.NoError(t, err)
+	v, err := NewJWTVerifier([]Key{hk, rk, ek})
+	require.NoError(t, err)
+
+	tokens := map[string]string{
+		HS256: sign(t, map[string]interface{}{"alg": HS256, "kid": "hs"}, claims(nil), hs256),
+		RS256: sign(t, map[string]interface{}{"alg": RS256, "kid": "rs"}, claims(nil), func(in []byte) []byte {
+			sum := sha256.Sum256(in)
+			s, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+			require.NoError(t, err)
+			return s
+		}),
+		EdDSA: sign(t, map[string]interface{}{"alg": EdDSA}, claims(nil), func(in []byte) []byte {
+			return ed25519.Sign(edPriv, in)
+		}),
+	}
+	for alg, tok := range tokens {
+		t.Run(alg, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Authorization", "Bearer "+tok)
+			p, err := v.Authenticate(r)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", p.Subject)
+			assert.Equal(t, MethodJWT, p.Method)
+			assert.True(t, p.HasRole("viewer"))
+		})
+	}
+
+	t.Run("AlgorithmIsBoundToKey", func(t *testing.T) {
+		// an HS256 token "signed" with the RSA public key must not verify
+		pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+		tok := sign(t, map[string]interface{}{"alg": HS256, "kid": "rs"}, claims(nil), func(in []byte) []byte {
+			mac := hmac.New(sha256.New, pemBytes)
+			mac.Write(in)
+			return mac.Sum(nil)
+		})
+		_, err := v.Verify(tok)
+		assert.True(t, apperr.Is(err, apperr.Unauthenticated))
+	})
+
+	t.Run("AlgNone", func(t *testing.T) {
+		tok := sign(t, map[string]interface{}{"alg": "none"}, claims(nil), func([]byte) []byte { return nil })
+		_, err := v.Verify(tok)
+		assert.True(t, apperr.Is(err, apperr.Unauthenticated))
+	})
+}
+
+func TestJWTVerifier_Claims(t *testing.T) {
+	hk, err := HMACKey("", secret)
+	require.NoError(t, err)
+	v, err := NewJWTVerifier([]Key{hk}, WithIssuer("https://idp.example.com"), WithAudience("employees"))
+	require.NoError(t, err)
+	good := map[string]interface{}{"iss": "https://idp.example.com", "aud": []string{"other", "employees"}}
+	merge := func(m map[string]interface{}) map[string]interface{} {
+		out := map[string]interface{}{}
+		for k, v := range good {
+			out[k] = v
+		}
+		for k, v := range m {
+			out[k] = v
+		}
+		return claims(out)
+	}
+
+	_, err = v.Verify(sign(t, map[string]interface{}{"alg": HS256}, merge(nil), hs256))
+	assert.NoError(t, err)
+
+	cases := map[string]map[string]interface{}{
+		"token has expired":              {"exp": time.Now().Add(-2 * time.Minute).Unix()},
+		"token is not valid yet":         {"nbf": time.Now().Add(2 * time.Minute).Unix()},
+		"token issuer is not accepted":   {"iss": "https://evil.example.com"},
+		"token audience is not accepted": {"aud": "other"},
+		"token has no subject":           {"sub": ""},
+	}
+	for want, c := range cases {
+		t.Run(want, func(t *testing.T) {
+			_, err := v.Verify(sign(t, map[string]interface{}{"alg": HS256}, merge(c), hs256))
+			assert.True(t, apperr.Is(err, apperr.Unauthenticated))
+			assert.Equal(t, want, apperr.Message(err))
+		})
+	}
+
+	t.Run("WithinLeeway", func(t *testing.T) {
+		_, err := v.Verify(sign(t, map[string]interface{}{"alg": HS256}, merge(map[string]interface{}{"exp": time.Now().Add(-30 * time.Second).Unix()}), hs256))
+		assert.NoError(t, err)
+	})
+
+	t.Run("NotAJWT", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer opaque-token")
+		_, err := v.Authenticate(r)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	e := big.NewInt(int64(rsaKey.PublicKey.E)).Bytes()
+
+	doc := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"r1","alg":"RS256","n":%q,"e":%q},
+		{"kty":"OKP","kid":"e1","crv":"Ed25519","x":%q},
+		{"kty":"oct","kid":"h1","k":%q},
+		{"kty":"RSA","kid":"enc","use":"enc","n":"","e":""}
+	]}`, b64(rsaKey.PublicKey.N.Bytes()), b64(e), b64(edPub), b64(secret))
+	keys, err := ParseJWKS([]byte(doc))
+	require.NoError(t, err)
+	if assert.Len(t, keys, 3) {
+		assert.Equal(t, []string{RS256, EdDSA, HS256}, []string{keys[0].Algorithm, keys[1].Algorithm, keys[2].Algorithm})
+		assert.Equal(t, "r1", keys[0].ID)
+	}
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"OKP","crv":"Ed25519","alg":"RS256","x":"` + b64(edPub) + `"}]}`))
+	assert.Error(t, err, "alg must match the key type")
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"oct","k":"c2hvcnQ"}]}`))
+	assert.Error(t, err, "short HMAC secrets are rejected")
+}
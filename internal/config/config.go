@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,79 @@ type Config struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	// AutoMigrate applies pending migrations at startup. When false the server
+	// refuses to start until `server migrate up` has been run.
+	AutoMigrate bool
+	// AllowedPositions restricts employee positions; empty allows any.
+	AllowedPositions []string
+	// RequireIfMatch rejects PUT/PATCH/DELETE without If-Match (428). On by
+	// default; REQUIRE_IF_MATCH=false allows unconditional writes.
+	RequireIfMatch bool
+	// ReserveDeletedEmails keeps a soft-deleted employee's email unavailable
+	// to others until the record is purged.
+	ReserveDeletedEmails bool
+	// AdminToken is a static bearer token that authenticates as an hr_admin.
+	// Empty disables it.
+	AdminToken string
+	// AuthDisabled serves the API without authentication. Callers without
+	// credentials are anonymous and hold AnonymousRoles; the admin token and
+	// other credentials still work.
+	AuthDisabled   bool
+	AnonymousRoles []string
+	// TrustedUserHeader, when set, takes the caller's identity from headers
+	// added by an authenticating proxy in front of the API: the subject from
+	// this header, roles from TrustedRolesHeader and the employee id from
+	// TrustedEmployeeHeader.
+	TrustedUserHeader     string
+	TrustedRolesHeader    string
+	TrustedEmployeeHeader string
+	// JWTSecret is an HS256 shared secret (at least 32 bytes).
+	JWTSecret string
+	// JWTPublicKeyFiles are PEM files with RS256 or EdDSA public keys.
+	JWTPublicKeyFiles []string
+	// JWTJWKSFile is a local JSON Web Key Set file.
+	JWTJWKSFile string
+	// JWTIssuer and JWTAudience, when set, must match the iss and aud claims.
+	JWTIssuer   string
+	JWTAudience string
+	// BatchMaxOperations and BatchMaxBytes bound a batch request; larger ones
+	// are rejected with 413.
+	BatchMaxOperations int
+	BatchMaxBytes      int64
+	// AdminAddr is where /metrics is served, apart from the API; empty turns
+	// the admin listener and metrics off.
+	AdminAddr string
+	// TracesExporter is where spans go: "none", "otlp" (OTLPEndpoint),
+	// "stdout" or "file" (TracesFile).
+	TracesExporter string
+	// OTLPEndpoint is an OTLP/HTTP traces URL; OTLPHeaders are "key=value"
+	// pairs sent with every export, such as an API key of the collector.
+	OTLPEndpoint string
+	OTLPHeaders  []string
+	TracesFile   string
+	// ServiceName is the service.name of exported spans.
+	ServiceName string
+	// ValidateRequests rejects API requests that do not match the OpenAPI
+	// document with 400 before they reach a handler.
+	ValidateRequests bool
+	// DevMode also checks responses against the document and logs every
+	// mismatch; it implies ValidateRequests.
+	DevMode bool
+	// OpenAPIFile replaces the embedded document used for validation.
+	OpenAPIFile string
+	// LogFormat is "json" or "text"; LogLevel is the initial level (debug,
+	// info, warn or error), which PUT /log/level on the admin listener
+	// changes at runtime.
+	LogFormat string
+	LogLevel  string
+	// IdempotencyTTL is how long the response to an Idempotency-Key is kept
+	// for replay; zero ignores the header. Request bodies sent with a key are
+	// limited to IdempotencyMaxBytes, and larger responses are not kept.
+	IdempotencyTTL      time.Duration
+	IdempotencyMaxBytes int64
+	// BulkTimeout bounds imports and exports, which move whole files and
+	// are exempt from the 30s limit of other requests.
+	BulkTimeout time.Duration
 }
 
 func Load() *Config {
@@ -23,13 +97,61 @@ func Load() *Config {
 	maxOpen := mustAtoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
 	maxIdle := mustAtoi(getEnv("DB_MAX_IDLE_CONNS", "25"))
 	connLifeS := mustAtoi(getEnv("DB_CONN_MAX_LIFETIME_SECONDS", "300"))
+	autoMigrate := mustParseBool(getEnv("DB_AUTO_MIGRATE", "true"))
+	positions := splitList(getEnv("ALLOWED_POSITIONS", ""))
+	requireIfMatch := mustParseBool(getEnv("REQUIRE_IF_MATCH", "true"))
+	reserveEmails := mustParseBool(getEnv("RESERVE_DELETED_EMAILS", "false"))
+	authDisabled := mustParseBool(getEnv("AUTH_DISABLED", "false"))
+	batchMaxOps := mustAtoi(getEnv("BATCH_MAX_OPERATIONS", "500"))
+	batchMaxBytes := mustAtoi(getEnv("BATCH_MAX_BYTES", "1048576"))
+	validateRequests := mustParseBool(getEnv("VALIDATE_REQUESTS", "false"))
+	devMode := mustParseBool(getEnv("DEV_MODE", "false"))
+	idempotencyTTLS := mustAtoi(getEnv("IDEMPOTENCY_TTL_SECONDS", "86400"))
+	idempotencyMaxBytes := mustAtoi(getEnv("IDEMPOTENCY_MAX_BYTES", "10485760"))
+	bulkTimeoutS := mustAtoi(getEnv("BULK_TIMEOUT_SECONDS", "600"))
+	// unlike other settings, an empty ADMIN_ADDR means something: no listener
+	adminAddr, ok := os.LookupEnv("ADMIN_ADDR")
+	if !ok {
+		adminAddr = "127.0.0.1:9090"
+	}
 
 	return &Config{
-		ServerAddr:      serverAddr,
-		DatabaseDSN:     dsn,
-		MaxOpenConns:    maxOpen,
-		MaxIdleConns:    maxIdle,
-		ConnMaxLifetime: time.Duration(connLifeS) * time.Second,
+		ServerAddr:            serverAddr,
+		DatabaseDSN:           dsn,
+		MaxOpenConns:          maxOpen,
+		MaxIdleConns:          maxIdle,
+		ConnMaxLifetime:       time.Duration(connLifeS) * time.Second,
+		AutoMigrate:           autoMigrate,
+		AllowedPositions:      positions,
+		RequireIfMatch:        requireIfMatch,
+		ReserveDeletedEmails:  reserveEmails,
+		AdminToken:            os.Getenv("ADMIN_TOKEN"),
+		AuthDisabled:          authDisabled,
+		AnonymousRoles:        splitList(getEnv("ANONYMOUS_ROLES", "hr_editor")),
+		TrustedUserHeader:     os.Getenv("TRUSTED_USER_HEADER"),
+		TrustedRolesHeader:    getEnv("TRUSTED_ROLES_HEADER", "X-Remote-Roles"),
+		TrustedEmployeeHeader: getEnv("TRUSTED_EMPLOYEE_HEADER", "X-Remote-Employee-Id"),
+		JWTSecret:             os.Getenv("JWT_HS256_SECRET"),
+		JWTPublicKeyFiles:     splitList(getEnv("JWT_PUBLIC_KEY_FILES", "")),
+		JWTJWKSFile:           os.Getenv("JWT_JWKS_FILE"),
+		JWTIssuer:             os.Getenv("JWT_ISSUER"),
+		JWTAudience:           os.Getenv("JWT_AUDIENCE"),
+		BatchMaxOperations:    batchMaxOps,
+		BatchMaxBytes:         int64(batchMaxBytes),
+		AdminAddr:             adminAddr,
+		TracesExporter:        getEnv("OTEL_TRACES_EXPORTER", "none"),
+		OTLPEndpoint:          getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4318/v1/traces"),
+		OTLPHeaders:           splitList(getEnv("OTEL_EXPORTER_OTLP_HEADERS", "")),
+		TracesFile:            getEnv("TRACES_FILE", "traces.jsonl"),
+		ServiceName:           getEnv("OTEL_SERVICE_NAME", "employee-app"),
+		ValidateRequests:      validateRequests || devMode,
+		DevMode:               devMode,
+		OpenAPIFile:           os.Getenv("OPENAPI_FILE"),
+		LogFormat:             getEnv("LOG_FORMAT", "json"),
+		LogLevel:              getEnv("LOG_LEVEL", "info"),
+		IdempotencyTTL:        time.Duration(idempotencyTTLS) * time.Second,
+		IdempotencyMaxBytes:   int64(idempotencyMaxBytes),
+		BulkTimeout:           time.Duration(bulkTimeoutS) * time.Second,
 	}
 }
 
@@ -47,3 +169,22 @@ func mustAtoi(s string) int {
 	}
 	return i
 }
+
+func mustParseBool(s string) bool {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		panic(fmt.Sprintf("invalid bool %s", s))
+	}
+	return b
+}
+
+// splitList parses a comma-separated env value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
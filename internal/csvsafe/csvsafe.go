@@ -0,0 +1,14 @@
+// Package csvsafe keeps text written to CSV files from being read as a
+// formula by the spreadsheet programs that open them (CSV injection).
+package csvsafe
+
+import "strings"
+
+// Text returns s with a leading ' if it starts with =, +, -, @, a tab or a
+// carriage return; spreadsheet programs show such a cell as plain text.
+func Text(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
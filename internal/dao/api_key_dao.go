@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"emplopyee-app-go/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type APIKeyDAO interface {
+	Create(ctx context.Context, k *model.APIKey) (*model.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	GetAll(ctx context.Context) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+}
+
+type apiKeyDAO struct {
+	db sqlxExecer
+}
+
+// NewAPIKeyDAO constructs an APIKeyDAO; see NewEmployeeDAO for the meaning of
+// driverName.
+//
+// Methods:
+//   - Create: Stores a new key. The caller supplies the hash; plaintext keys never reach the database.
+//   - GetByHash: Looks a key up by the SHA-256 hash of its plaintext, including revoked and expired keys.
+//   - GetAll: Lists every key, newest first.
+//   - Revoke: Marks a key revoked; revoking twice keeps the first timestamp.
+func NewAPIKeyDAO(conn *sql.DB, driverName string) APIKeyDAO {
+	return &apiKeyDAO{db: sqlx.NewDb(conn, driverName)}
+}
+
+func (d *apiKeyDAO) Create(ctx context.Context, k *model.APIKey) (*model.APIKey, error) {
+	query := `INSERT INTO api_keys (name, prefix, key_hash, roles, created_at, expires_at)
+              VALUES (:name, :prefix, :key_hash, :roles, :created_at, :expires_at)`
+	k.CreatedAt = time.Now().UTC()
+	id, err := insert(ctx, d.db, query, k)
+	if err != nil {
+		return nil, mapError(fmt.Errorf("insert api key: %w", err))
+	}
+	k.ID = id
+	return k, nil
+}
+
+func (d *apiKeyDAO) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var k model.APIKey
+	err := d.db.GetContext(ctx, &k, d.db.Rebind("SELECT * FROM api_keys WHERE key_hash = ?"), hash)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &k, nil
+}
+
+func (d *apiKeyDAO) GetAll(ctx context.Context) ([]*model.APIKey, error) {
+	list := []*model.APIKey{}
+	if err := d.db.SelectContext(ctx, &list, "SELECT * FROM api_keys ORDER BY id DESC"); err != nil {
+		return nil, mapError(fmt.Errorf("list api keys: %w", err))
+	}
+	return list, nil
+}
+
+func (d *apiKeyDAO) Revoke(ctx context.Context, id int64) error {
+	query := "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?"
+	res, err := d.db.ExecContext(ctx, d.db.Rebind(query), time.Now().UTC(), id)
+	if err != nil {
+		return mapError(fmt.Errorf("revoke api key: %w", err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n == 0 {
+		return mapError(sql.ErrNoRows)
+	}
+	return nil
+}
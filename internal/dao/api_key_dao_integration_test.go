@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+func TestAPIKeyDAO_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		kd := NewAPIKeyDAO(conn, driver)
+
+		exp := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		k, err := kd.Create(ctx, &model.APIKey{Name: "ci", Prefix: "emp_abc", KeyHash: "h1", Roles: "viewer", ExpiresAt: &exp})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := kd.Create(ctx, &model.APIKey{Name: "dup", Prefix: "emp_abc", KeyHash: "h1"}); !apperr.Is(err, apperr.Conflict) {
+			t.Errorf("expected Conflict for a duplicate hash, got %v", err)
+		}
+
+		got, err := kd.GetByHash(ctx, "h1")
+		if err != nil {
+			t.Fatalf("GetByHash: %v", err)
+		}
+		if got.ID != k.ID || got.Roles != "viewer" || got.ExpiresAt == nil || !got.ExpiresAt.Equal(exp) || got.RevokedAt != nil {
+			t.Errorf("unexpected key: %+v", got)
+		}
+		if _, err := kd.GetByHash(ctx, "nope"); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound, got %v", err)
+		}
+
+		if err := kd.Revoke(ctx, k.ID); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		got, err = kd.GetByHash(ctx, "h1")
+		if err != nil || got.RevokedAt == nil {
+			t.Errorf("expected revoked key, got %+v, %v", got, err)
+		}
+		if err := kd.Revoke(ctx, 999); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound revoking an unknown key, got %v", err)
+		}
+
+		list, err := kd.GetAll(ctx)
+		if err != nil || len(list) != 1 {
+			t.Errorf("GetAll: %d keys, %v", len(list), err)
+		}
+	})
+}
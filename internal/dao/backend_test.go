@@ -0,0 +1,163 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dbpkg "emplopyee-app-go/internal/db"
+)
+
+// forEachBackend runs fn against a fresh database on every available backend.
+// SQLite always runs (in memory). PostgreSQL runs when TEST_POSTGRES_DSN points
+// at a server the tests may create databases on, or when initdb/pg_ctl can be
+// found on PATH or in PG_BIN, in which case a throwaway cluster is started.
+// Otherwise it is skipped, unless REQUIRE_POSTGRES is set, as in CI, where a
+// missing server fails the tests instead of passing them on SQLite alone.
+func forEachBackend(t *testing.T, fn func(t *testing.T, conn *sql.DB, driver string)) {
+	t.Run(dbpkg.SQLite, func(t *testing.T) {
+		dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_foreign_keys=1", strings.ReplaceAll(t.Name(), "/", "_"))
+		conn, err := dbpkg.NewDB(dsn, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("NewDB: %v", err)
+		}
+		defer conn.Close()
+		migrate(t, conn, dbpkg.SQLite)
+		fn(t, conn, dbpkg.SQLite)
+	})
+
+	t.Run(dbpkg.Postgres, func(t *testing.T) {
+		base := postgresDSN(t)
+		admin, err := sql.Open(dbpkg.Postgres, base)
+		if err != nil {
+			t.Fatalf("open postgres: %v", err)
+		}
+		defer admin.Close()
+
+		name := fmt.Sprintf("emp_test_%d", time.Now().UnixNano())
+		if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+			t.Fatalf("create database: %v", err)
+		}
+		defer admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)")
+
+		conn, err := dbpkg.NewDB(withDatabase(base, name), 4, 4, 0)
+		if err != nil {
+			t.Fatalf("NewDB: %v", err)
+		}
+		defer conn.Close()
+		migrate(t, conn, dbpkg.Postgres)
+		fn(t, conn, dbpkg.Postgres)
+	})
+}
+
+func migrate(t *testing.T, conn *sql.DB, driver string) {
+	m, err := dbpkg.NewMigrator(conn, driver)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+}
+
+var (
+	pgOnce sync.Once
+	pgDSN  string
+	pgErr  error
+	pgStop func()
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if pgStop != nil {
+		pgStop()
+	}
+	os.Exit(code)
+}
+
+func postgresDSN(t *testing.T) string {
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		return dsn
+	}
+	pgOnce.Do(func() { pgDSN, pgErr = startLocalPostgres() })
+	if pgErr != nil {
+		if os.Getenv("REQUIRE_POSTGRES") != "" {
+			t.Fatalf("postgres unavailable and REQUIRE_POSTGRES is set: %v", pgErr)
+		}
+		t.Skipf("postgres unavailable: %v", pgErr)
+	}
+	return pgDSN
+}
+
+// startLocalPostgres initialises and starts a cluster in a temp dir using the
+// locally installed server binaries. It is shared by all tests in the package
+// and stopped by TestMain.
+func startLocalPostgres() (string, error) {
+	bin := func(name string) (string, error) {
+		if dir := os.Getenv("PG_BIN"); dir != "" {
+			return filepath.Join(dir, name), nil
+		}
+		return exec.LookPath(name)
+	}
+	initdb, err := bin("initdb")
+	if err != nil {
+		return "", err
+	}
+	pgctl, err := bin("pg_ctl")
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "emp-pg-")
+	if err != nil {
+		return "", err
+	}
+	data := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust").CombinedOutput(); err != nil {
+		return "", fmt.Errorf("initdb: %v: %s", err, out)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1", port, dir)
+	cmd := exec.Command(pgctl, "-D", data, "-o", opts, "-l", filepath.Join(dir, "log"), "-w", "start")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("pg_ctl start: %v: %s", err, out)
+	}
+	pgStop = func() {
+		exec.Command(pgctl, "-D", data, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port), nil
+}
+
+// withDatabase swaps the database path of a postgres:// URL.
+func withDatabase(dsn, name string) string {
+	i := strings.Index(dsn, "://")
+	rest := dsn[i+3:]
+	slash := strings.Index(rest, "/")
+	query := ""
+	if q := strings.Index(rest, "?"); q >= 0 {
+		query = rest[q:]
+		if slash < 0 || slash > q {
+			slash = q
+		}
+	}
+	if slash < 0 {
+		slash = len(rest)
+	}
+	return dsn[:i+3] + rest[:slash] + "/" + name + query
+}
@@ -0,0 +1,130 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type DepartmentDAO interface {
+	Create(ctx context.Context, d *model.Department) (*model.Department, error)
+	Update(ctx context.Context, d *model.Department) (*model.Department, error)
+	GetByID(ctx context.Context, id int64) (*model.Department, error)
+	GetAll(ctx context.Context) ([]*model.Department, error)
+	Delete(ctx context.Context, id int64) error
+	Headcounts(ctx context.Context) ([]*model.Headcount, error)
+}
+
+type departmentDAO struct {
+	db sqlxExecer
+}
+
+// NewDepartmentDAO constructs a DepartmentDAO; see NewEmployeeDAO for the
+// meaning of driverName.
+//
+// Methods:
+//   - Create: Inserts a new department.
+//   - Update: Renames or re-describes an existing department.
+//   - GetByID: Retrieves a department by ID.
+//   - GetAll: Lists every department ordered by name.
+//   - Delete: Removes a department that no employee belongs to.
+//   - Headcounts: Counts the live employees of every department, and of none.
+func NewDepartmentDAO(conn *sql.DB, driverName string) DepartmentDAO {
+	return &departmentDAO{db: sqlx.NewDb(conn, driverName)}
+}
+
+func (d *departmentDAO) Create(ctx context.Context, dep *model.Department) (*model.Department, error) {
+	query := `INSERT INTO departments (name, description, created_at, updated_at)
+              VALUES (:name, :description, :created_at, :updated_at)`
+	now := time.Now().UTC()
+	dep.CreatedAt = now
+	dep.UpdatedAt = now
+	id, err := insert(ctx, d.db, query, dep)
+	if err != nil {
+		return nil, mapError(fmt.Errorf("insert department: %w", err))
+	}
+	dep.ID = id
+	return dep, nil
+}
+
+func (d *departmentDAO) Update(ctx context.Context, dep *model.Department) (*model.Department, error) {
+	query := "UPDATE departments SET name = ?, description = ?, updated_at = ? WHERE id = ?"
+	res, err := d.db.ExecContext(ctx, d.db.Rebind(query), dep.Name, dep.Description, time.Now().UTC(), dep.ID)
+	if err != nil {
+		return nil, mapError(fmt.Errorf("update department: %w", err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if n == 0 {
+		return nil, mapError(sql.ErrNoRows)
+	}
+	return d.GetByID(ctx, dep.ID)
+}
+
+func (d *departmentDAO) GetByID(ctx context.Context, id int64) (*model.Department, error) {
+	var dep model.Department
+	err := d.db.GetContext(ctx, &dep, d.db.Rebind("SELECT * FROM departments WHERE id = ?"), id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &dep, nil
+}
+
+func (d *departmentDAO) GetAll(ctx context.Context) ([]*model.Department, error) {
+	list := []*model.Department{}
+	if err := d.db.SelectContext(ctx, &list, "SELECT * FROM departments ORDER BY name, id"); err != nil {
+		return nil, mapError(fmt.Errorf("list departments: %w", err))
+	}
+	return list, nil
+}
+
+// Delete refuses, with apperr.Conflict, to remove a department that any
+// employee still references, including soft-deleted ones awaiting purge. The
+// check is part of the DELETE so it cannot race with a new assignment.
+func (d *departmentDAO) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM departments WHERE id = ?
+              AND NOT EXISTS (SELECT 1 FROM employees WHERE department_id = ?)`
+	res, err := d.db.ExecContext(ctx, d.db.Rebind(query), id, id)
+	if err != nil {
+		return mapError(fmt.Errorf("delete department: %w", err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := d.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return apperr.New(apperr.Conflict, "department still has employees")
+}
+
+// Headcounts lists every department, empty ones included, ordered by name,
+// followed by the employees that have no department.
+func (d *departmentDAO) Headcounts(ctx context.Context) ([]*model.Headcount, error) {
+	query := `SELECT * FROM (
+                SELECT d.id AS department_id, d.name AS department, COUNT(e.id) AS employees
+                FROM departments d
+                LEFT JOIN employees e ON e.department_id = d.id AND e.deleted_at IS NULL
+                GROUP BY d.id, d.name
+                UNION ALL
+                SELECT NULL, '', COUNT(*) FROM employees
+                WHERE department_id IS NULL AND deleted_at IS NULL
+              ) counts
+              ORDER BY department_id IS NULL, department, department_id`
+	list := []*model.Headcount{}
+	if err := d.db.SelectContext(ctx, &list, query); err != nil {
+		return nil, mapError(fmt.Errorf("count employees by department: %w", err))
+	}
+	return list, nil
+}
@@ -0,0 +1,157 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+func TestDepartmentDAO_CRUD_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		dd := NewDepartmentDAO(conn, driver)
+
+		dep, err := dd.Create(ctx, &model.Department{Name: "Platform Engineering"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := dd.Create(ctx, &model.Department{Name: "Platform Engineering"}); !apperr.Is(err, apperr.Conflict) {
+			t.Errorf("expected Conflict for a duplicate name, got %v", err)
+		} else if msg := apperr.Message(err); msg != "a department with this name already exists" {
+			t.Errorf("unexpected conflict message %q", msg)
+		}
+		if _, err := dd.Create(ctx, &model.Department{Name: "Finance"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		dep.Description = "Runs the platform"
+		got, err := dd.Update(ctx, dep)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got.Description != "Runs the platform" {
+			t.Errorf("unexpected department: %+v", got)
+		}
+		if _, err := dd.Update(ctx, &model.Department{ID: 999, Name: "x"}); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound, got %v", err)
+		}
+
+		all, err := dd.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		if len(all) != 2 || all[0].Name != "Finance" {
+			t.Errorf("expected departments ordered by name, got %+v", all)
+		}
+	})
+}
+
+func TestDepartmentDAO_Membership_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		dd := NewDepartmentDAO(conn, driver)
+		ed := NewEmployeeDAO(conn, driver)
+
+		dep, err := dd.Create(ctx, &model.Department{Name: "Platform Engineering"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		e, err := ed.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", DepartmentID: &dep.ID})
+		if err != nil {
+			t.Fatalf("Create employee: %v", err)
+		}
+		if _, err := ed.Create(ctx, &model.Employee{FirstName: "Bo", LastName: "Ng", Email: "bo@example.com"}); err != nil {
+			t.Fatalf("Create employee: %v", err)
+		}
+
+		page, err := ed.GetAll(ctx, &model.EmployeeQuery{DepartmentID: dep.ID})
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		if page.Total != 1 || page.Items[0].ID != e.ID {
+			t.Errorf("unexpected members: %+v", page)
+		}
+
+		missing := int64(999)
+		if _, err := ed.Create(ctx, &model.Employee{FirstName: "X", LastName: "Y", Email: "x@example.com", DepartmentID: &missing}); !apperr.Is(err, apperr.Conflict) {
+			t.Errorf("expected the foreign key to reject an unknown department, got %v", err)
+		}
+
+		// a soft-deleted member still holds the department
+		if err := dd.Delete(ctx, dep.ID); !apperr.Is(err, apperr.Conflict) {
+			t.Errorf("expected Conflict deleting a non-empty department, got %v", err)
+		}
+		if err := ed.Delete(ctx, e.ID, 0); err != nil {
+			t.Fatalf("Delete employee: %v", err)
+		}
+		if err := dd.Delete(ctx, dep.ID); !apperr.Is(err, apperr.Conflict) {
+			t.Errorf("expected Conflict while a trashed member remains, got %v", err)
+		}
+
+		if _, err := ed.UpdateFields(ctx, e.ID, 0, map[string]interface{}{"department_id": nil}); !apperr.Is(err, apperr.NotFound) {
+			t.Fatalf("expected trashed employee to be hidden from updates, got %v", err)
+		}
+		if err := ed.Purge(ctx, e.ID); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		if err := dd.Delete(ctx, dep.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := dd.Delete(ctx, dep.ID); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound, got %v", err)
+		}
+	})
+}
+
+func TestDepartmentDAO_Headcounts_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		dd := NewDepartmentDAO(conn, driver)
+		ed := NewEmployeeDAO(conn, driver)
+
+		eng, err := dd.Create(ctx, &model.Department{Name: "Engineering"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		audit, err := dd.Create(ctx, &model.Department{Name: "Audit"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		for _, e := range []*model.Employee{
+			{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", DepartmentID: &eng.ID},
+			{FirstName: "Bo", LastName: "Ng", Email: "bo@example.com", DepartmentID: &eng.ID},
+			{FirstName: "Cy", LastName: "Oh", Email: "cy@example.com", DepartmentID: &eng.ID},
+			{FirstName: "Di", LastName: "Po", Email: "di@example.com"},
+		} {
+			if _, err := ed.Create(ctx, e); err != nil {
+				t.Fatalf("Create employee: %v", err)
+			}
+		}
+		// the trash does not count
+		if err := ed.Delete(ctx, 3, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		got, err := dd.Headcounts(ctx)
+		if err != nil {
+			t.Fatalf("Headcounts: %v", err)
+		}
+		var lines []string
+		for _, h := range got {
+			id := "-"
+			if h.DepartmentID != nil {
+				id = fmt.Sprint(*h.DepartmentID)
+			}
+			lines = append(lines, fmt.Sprintf("%s %q %d", id, h.Department, h.Employees))
+		}
+		want := []string{fmt.Sprintf(`%d "Audit" 0`, audit.ID), fmt.Sprintf(`%d "Engineering" 2`, eng.ID), `- "" 1`}
+		if strings.Join(lines, "; ") != strings.Join(want, "; ") {
+			t.Errorf("Headcounts = %q, want %q", lines, want)
+		}
+	})
+}
@@ -0,0 +1,293 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jmoiron/sqlx"
+)
+
+// SystemActor is recorded as the actor of writes made without an
+// authenticated principal in the context, e.g. by command-line tools.
+const SystemActor = "system"
+
+type AuditDAO interface {
+	List(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error)
+	Verify(ctx context.Context) (*ChainHead, error)
+}
+
+type auditDAO struct {
+	db sqlxExecer
+}
+
+// NewAuditDAO constructs an AuditDAO over the employee_audit table, which
+// employeeDAO appends to on every write; see NewEmployeeDAO for the meaning of
+// driverName. The log is read-only through this interface.
+//
+// Methods:
+//   - List: Returns one page of entries, oldest first, filtered by employee, actor and time.
+//   - Verify: Walks the whole log and checks every entry's hash and link to the one before.
+func NewAuditDAO(conn *sql.DB, driverName string) AuditDAO {
+	return &auditDAO{db: sqlx.NewDb(conn, driverName)}
+}
+
+// ChainHead describes a log that passed verification: how many entries it has
+// and the hash of the newest one. Keeping the head hash somewhere else makes
+// truncation of the newest entries detectable too.
+type ChainHead struct {
+	Entries int64
+	Hash    string
+}
+
+// ChainError is returned by Verify for the first entry that does not match
+// its hash or does not link to the entry before it.
+type ChainError struct {
+	EntryID int64
+	Reason  string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit entry %d: %s", e.EntryID, e.Reason)
+}
+
+func (d *auditDAO) List(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	var conds []string
+	var args []interface{}
+	if q.EmployeeID != 0 {
+		conds = append(conds, "employee_id = ?")
+		args = append(args, q.EmployeeID)
+	}
+	if q.Actor != "" {
+		conds = append(conds, "actor = ?")
+		args = append(args, q.Actor)
+	}
+	if !q.Since.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, q.Until.UTC())
+	}
+	if q.Cursor != "" {
+		after, err := decodeAuditCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, "id > ?")
+		args = append(args, after)
+	}
+
+	query := "SELECT * FROM employee_audit" + whereClause(conds) + " ORDER BY id LIMIT ?"
+	args = append(args, limit+1)
+	list := []*model.AuditEntry{}
+	if err := d.db.SelectContext(ctx, &list, d.db.Rebind(query), args...); err != nil {
+		return nil, mapError(fmt.Errorf("list audit entries: %w", err))
+	}
+	page := &model.AuditPage{Items: list}
+	if len(list) > limit {
+		page.Items = list[:limit]
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(list[limit-1].ID, 10)))
+	}
+	return page, nil
+}
+
+func decodeAuditCursor(s string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, invalidQuery("malformed cursor")
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, invalidQuery("malformed cursor")
+	}
+	return id, nil
+}
+
+func (d *auditDAO) Verify(ctx context.Context) (*ChainHead, error) {
+	rows, err := d.db.QueryxContext(ctx, "SELECT * FROM employee_audit ORDER BY id")
+	if err != nil {
+		return nil, mapError(fmt.Errorf("read audit log: %w", err))
+	}
+	defer rows.Close()
+
+	head := &ChainHead{}
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.StructScan(&e); err != nil {
+			return nil, mapError(fmt.Errorf("read audit log: %w", err))
+		}
+		if e.PrevHash != head.Hash {
+			return nil, &ChainError{EntryID: e.ID, Reason: "does not link to the previous entry; an entry was removed or changed"}
+		}
+		if auditHash(&e) != e.Hash {
+			return nil, &ChainError{EntryID: e.ID, Reason: "content does not match its hash"}
+		}
+		head.Entries++
+		head.Hash = e.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(fmt.Errorf("read audit log: %w", err))
+	}
+	return head, nil
+}
+
+// auditLockKey serialises appends to the audit chain on PostgreSQL. It must
+// differ from the key db.Migrator locks.
+const auditLockKey = 7243089187310373
+
+// recordAudit appends an entry for a write from before to after (either may be
+// nil) to the log. It must run in the transaction of the write.
+//
+// Appending reads the newest hash, so concurrent appends must not interleave:
+// SQLite already serialises write transactions, on PostgreSQL appenders take
+// auditLockKey until commit. Readers of the log are not blocked; to keep
+// appenders' wait short, record the entry as the last step of a transaction.
+func recordAudit(ctx context.Context, q sqlxExecer, op string, before, after *model.Employee) error {
+	if !inTx(q) {
+		return errors.New("record audit: not in a transaction")
+	}
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		return fmt.Errorf("record audit: %w", err)
+	}
+	e := &model.AuditEntry{
+		Operation: op,
+		Actor:     auditActor(ctx),
+		RequestID: middleware.GetReqID(ctx),
+		Changes:   changes,
+		// PostgreSQL keeps microseconds; the hash must survive the round trip
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if after != nil {
+		e.EmployeeID = after.ID
+	} else if before != nil {
+		e.EmployeeID = before.ID
+	}
+
+	if q.DriverName() == db.Postgres {
+		if _, err := q.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+			return mapError(fmt.Errorf("lock audit log: %w", err))
+		}
+	}
+	err = q.GetContext(ctx, &e.PrevHash, "SELECT hash FROM employee_audit ORDER BY id DESC LIMIT 1")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return mapError(fmt.Errorf("read audit head: %w", err))
+	}
+	e.Hash = auditHash(e)
+
+	query := `INSERT INTO employee_audit (employee_id, operation, actor, request_id, changes, created_at, prev_hash, hash)
+              VALUES (:employee_id, :operation, :actor, :request_id, :changes, :created_at, :prev_hash, :hash)`
+	if _, err := insert(ctx, q, query, e); err != nil {
+		return mapError(fmt.Errorf("insert audit entry: %w", err))
+	}
+	return nil
+}
+
+// auditActor names the caller recorded in an audit entry.
+func auditActor(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+	return SystemActor
+}
+
+// auditHash is the hex SHA-256 of the entry's content and PrevHash. The
+// fields are encoded as a JSON array so no two entries share an encoding.
+func auditHash(e *model.AuditEntry) string {
+	b, _ := json.Marshal([]interface{}{
+		e.PrevHash,
+		e.EmployeeID,
+		e.Operation,
+		e.Actor,
+		e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		string(e.Changes),
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// unaudited are employee members every write changes; recording them would
+// only add noise.
+var unaudited = map[string]bool{"id": true, "version": true, "created_at": true, "updated_at": true}
+
+type auditChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// auditDiff compares the JSON forms of before and after member by member.
+func auditDiff(before, after *model.Employee) (model.JSONText, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return "", err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(from)+len(to))
+	for k := range from {
+		names = append(names, k)
+	}
+	for k := range to {
+		if _, ok := from[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	diff := map[string]auditChange{}
+	for _, k := range names {
+		f, t := nullIfMissing(from[k]), nullIfMissing(to[k])
+		if unaudited[k] || bytes.Equal(f, t) {
+			continue
+		}
+		diff[k] = auditChange{From: f, To: t}
+	}
+	b, err := json.Marshal(diff)
+	return model.JSONText(b), err
+}
+
+func auditFields(e *model.Employee) (map[string]json.RawMessage, error) {
+	out := map[string]json.RawMessage{}
+	if e == nil {
+		return out, nil
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return out, json.Unmarshal(b, &out)
+}
+
+func nullIfMissing(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
@@ -0,0 +1,156 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/auth"
+	dbpkg "emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestAuditDAO_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "alice"})
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, "req-1")
+		ed := NewEmployeeDAO(conn, driver)
+		ad := NewAuditDAO(conn, driver)
+		start := time.Now().UTC().Add(-time.Second)
+
+		e, err := ed.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := ed.UpdateFields(ctx, e.ID, 0, map[string]interface{}{"position": "CTO"}); err != nil {
+			t.Fatalf("UpdateFields: %v", err)
+		}
+		if err := ed.Delete(context.Background(), e.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := ed.Restore(ctx, e.ID, 0); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		if err := ed.Delete(ctx, e.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := ed.Purge(ctx, e.ID); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		// failed writes leave no entry
+		if err := ed.Delete(ctx, e.ID, 0); err == nil {
+			t.Fatal("expected deleting a purged employee to fail")
+		}
+
+		t.Run("History", func(t *testing.T) {
+			page, err := ad.List(ctx, &model.AuditQuery{EmployeeID: e.ID})
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			var ops []string
+			for _, a := range page.Items {
+				ops = append(ops, a.Operation)
+			}
+			want := []string{model.AuditCreate, model.AuditUpdate, model.AuditDelete, model.AuditRestore, model.AuditDelete, model.AuditPurge}
+			if len(ops) != len(want) {
+				t.Fatalf("expected %v, got %v", want, ops)
+			}
+			for i := range want {
+				if ops[i] != want[i] {
+					t.Fatalf("expected %v, got %v", want, ops)
+				}
+			}
+
+			upd := page.Items[1]
+			if upd.Actor != "alice" || upd.RequestID != "req-1" {
+				t.Errorf("unexpected actor/request id: %q %q", upd.Actor, upd.RequestID)
+			}
+			var changes map[string]struct{ From, To interface{} }
+			if err := json.Unmarshal([]byte(upd.Changes), &changes); err != nil {
+				t.Fatalf("changes %q: %v", upd.Changes, err)
+			}
+			if len(changes) != 1 || changes["position"].From != "" || changes["position"].To != "CTO" {
+				t.Errorf("unexpected update diff: %s", upd.Changes)
+			}
+			if page.Items[2].Actor != SystemActor || page.Items[2].RequestID != "" {
+				t.Errorf("expected a system entry without request id, got %+v", page.Items[2])
+			}
+		})
+
+		t.Run("Filters", func(t *testing.T) {
+			page, err := ad.List(ctx, &model.AuditQuery{Actor: "alice", Since: start, Limit: 2})
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(page.Items) != 2 || page.NextCursor == "" {
+				t.Fatalf("expected a full first page, got %d items, cursor %q", len(page.Items), page.NextCursor)
+			}
+			rest, err := ad.List(ctx, &model.AuditQuery{Actor: "alice", Since: start, Cursor: page.NextCursor})
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(rest.Items) != 3 || rest.NextCursor != "" || rest.Items[0].ID <= page.Items[1].ID {
+				t.Errorf("unexpected second page: %d items, cursor %q", len(rest.Items), rest.NextCursor)
+			}
+			none, err := ad.List(ctx, &model.AuditQuery{Since: time.Now().UTC().Add(time.Hour)})
+			if err != nil || len(none.Items) != 0 {
+				t.Errorf("expected nothing in the future, got %d, %v", len(none.Items), err)
+			}
+		})
+
+		t.Run("Verify", func(t *testing.T) {
+			head, err := ad.Verify(ctx)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if head.Entries != 6 || head.Hash == "" {
+				t.Errorf("unexpected head: %+v", head)
+			}
+		})
+
+		t.Run("AppendOnly", func(t *testing.T) {
+			if _, err := conn.Exec("UPDATE employee_audit SET actor = 'mallory'"); err == nil {
+				t.Error("expected updating the audit log to fail")
+			}
+			if _, err := conn.Exec("DELETE FROM employee_audit"); err == nil {
+				t.Error("expected deleting from the audit log to fail")
+			}
+		})
+
+		t.Run("Tampering", func(t *testing.T) {
+			// someone with enough rights to get past the triggers
+			if driver == dbpkg.Postgres {
+				mustExec(t, conn, "ALTER TABLE employee_audit DISABLE TRIGGER employee_audit_append_only")
+			} else {
+				mustExec(t, conn, "DROP TRIGGER employee_audit_no_update")
+				mustExec(t, conn, "DROP TRIGGER employee_audit_no_delete")
+			}
+
+			mustExec(t, conn, "UPDATE employee_audit SET actor = 'mallory' WHERE id = 2")
+			_, err := ad.Verify(ctx)
+			var cerr *ChainError
+			if !errors.As(err, &cerr) || cerr.EntryID != 2 {
+				t.Errorf("expected entry 2 to fail verification, got %v", err)
+			}
+
+			mustExec(t, conn, "UPDATE employee_audit SET actor = 'alice' WHERE id = 2")
+			mustExec(t, conn, "DELETE FROM employee_audit WHERE id = 3")
+			_, err = ad.Verify(ctx)
+			if !errors.As(err, &cerr) || cerr.EntryID != 4 {
+				t.Errorf("expected entry 4 to fail verification, got %v", err)
+			}
+		})
+	})
+}
+
+func mustExec(t *testing.T, conn *sql.DB, query string) {
+	t.Helper()
+	if _, err := conn.Exec(query); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
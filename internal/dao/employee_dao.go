@@ -3,9 +3,14 @@ package dao
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/db"
 	"emplopyee-app-go/internal/model"
 
 	"github.com/jmoiron/sqlx"
@@ -15,16 +20,39 @@ type EmployeeDAO interface {
 	Create(ctx context.Context, e *model.Employee) (*model.Employee, error)
 	Update(ctx context.Context, e *model.Employee) (*model.Employee, error)
 	GetByID(ctx context.Context, id int64) (*model.Employee, error)
-	GetAll(ctx context.Context) ([]*model.Employee, error)
-	Delete(ctx context.Context, id int64) error
+	GetByEmail(ctx context.Context, email string) (*model.Employee, error)
+	GetAsOf(ctx context.Context, id int64, at time.Time) (*model.Employee, error)
+	GetAll(ctx context.Context, q *model.EmployeeQuery) (*model.EmployeePage, error)
+	Each(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) error
+	Search(ctx context.Context, q *model.SearchQuery) ([]*model.SearchHit, error)
+	Delete(ctx context.Context, id, version int64) error
+	Restore(ctx context.Context, id, version int64) (*model.Employee, error)
+	Purge(ctx context.Context, id int64) error
+	EmailReserved(ctx context.Context, email string) (bool, error)
+	Chain(ctx context.Context, id int64) ([]*model.Employee, error)
+	Reports(ctx context.Context, id int64, depth int) ([]*model.OrgNode, error)
+	OrgChart(ctx context.Context) ([]*model.OrgNode, error)
+	ReassignReports(ctx context.Context, fromID int64, toID *int64) (int64, error)
+	UpdateFields(ctx context.Context, id, version int64, fields map[string]interface{}) (*model.Employee, error)
+	WithTx(ctx context.Context, fn func(tx EmployeeDAO) error) error
 }
 
 type employeeDAO struct {
-	db *sqlx.DB
+	db   sqlxExecer
+	root *sqlx.DB
 }
 
-func NewEmployeeDAO(db *sql.DB) EmployeeDAO {
-	return &employeeDAO{db: sqlx.NewDb(db, "sqlite3")}
+func NewEmployeeDAO(conn *sql.DB, driverName string, opts ...Option) EmployeeDAO {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root := sqlx.NewDb(conn, driverName)
+	d := &employeeDAO{db: withTracing(root, o.tracer), root: root}
+	if o.tracer == nil {
+		return d
+	}
+	return &tracedEmployeeDAO{next: d, tracer: o.tracer}
 }
 
 // EmployeeDAO provides methods for CRUD operations on Employee model.
@@ -32,85 +60,399 @@ func NewEmployeeDAO(db *sql.DB) EmployeeDAO {
 // Methods:
 //   - Create: Inserts a new employee record into the database.
 //   - Update: Updates an existing employee record.
-//   - GetByID: Retrieves an employee by their unique ID.
-//   - GetAll: Retrieves all employees from the database.
-//   - Delete: Removes an employee record by ID.
+//   - GetByID: Retrieves a live (not soft-deleted) employee by their unique ID.
+//   - GetByEmail: Retrieves the live employee holding an email.
+//   - GetAsOf: Retrieves an employee as they were at a past instant.
+//   - GetAll: Retrieves one keyset-paginated, filtered page of employees, optionally as of a past instant.
+//   - Each: Streams every employee matching GetAll's filters, one row at a time.
+//   - Search: Ranks live employees by a typo-tolerant full-text match on names, email and position.
+//   - Delete: Soft-deletes an employee by setting deleted_at.
+//   - Restore: Clears deleted_at on a soft-deleted employee.
+//   - Purge: Permanently removes a soft-deleted employee.
+//   - EmailReserved: Reports whether a soft-deleted employee holds an email.
+//   - Chain: Lists an employee's managers, nearest first, up to the top.
+//   - Reports: Returns the reporting tree below an employee, to a given depth.
+//   - OrgChart: Returns the whole reporting tree of live employees.
+//   - ReassignReports: Moves every live direct report of one manager to another.
+//   - UpdateFields: Updates only the given columns of one employee.
+//   - WithTx: Runs a function with a DAO bound to a single transaction.
+//
+// Every write appends an entry to the employee_audit log and a row to the
+// employee_versions history in the same transaction; see NewAuditDAO. Reads of
+// the current state never touch either table.
 //
-// NewEmployeeDAO constructs a new EmployeeDAO backed by a sql.DB.
+// Writes take the version the caller last saw (e.Version for Update) and only
+// apply if the row still has it, returning apperr.PreconditionFailed otherwise.
+// Version 0 skips the check. Every write increments the version.
+//
+// NewEmployeeDAO constructs a new EmployeeDAO backed by a sql.DB. driverName
+// (db.SQLite or db.Postgres) selects the placeholder style and insert strategy;
+// queries are written with ? placeholders and rebound per dialect. WithTracer
+// traces its calls and statements.
 //
 /*
 Example usage:
 
-	db, err := sql.Open("sqlite3", dsn)
+	pool, err := db.NewDB(dsn, 25, 25, 5*time.Minute)
 	if err != nil { // handle error }
-	dao := dao.NewEmployeeDAO(db)
+	dao := dao.NewEmployeeDAO(pool, db.DriverName(dsn))
 	emp, err := dao.GetByID(ctx, 1)
 */
 
 func (d *employeeDAO) Create(ctx context.Context, e *model.Employee) (*model.Employee, error) {
-	query := `INSERT INTO employees (first_name, last_name, email, position, created_at, updated_at)
-              VALUES (:first_name, :last_name, :email, :position, :created_at, :updated_at)`
+	query := `INSERT INTO employees (first_name, last_name, email, position, department_id, manager_id, version, created_at, updated_at)
+              VALUES (:first_name, :last_name, :email, :position, :department_id, :manager_id, :version, :created_at, :updated_at)`
 	now := time.Now().UTC()
 	e.CreatedAt = now
 	e.UpdatedAt = now
-	res, err := d.db.NamedExecContext(ctx, query, e)
+	e.Version = 1
+	err := d.WithTx(ctx, func(txDAO EmployeeDAO) error {
+		tx := txDAO.(*employeeDAO)
+		id, err := insert(ctx, tx.db, query, e)
+		if err != nil {
+			return mapError(fmt.Errorf("insert employee: %w", err))
+		}
+		e.ID = id
+		return tx.recordWrite(ctx, model.AuditCreate, nil, e)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("insert employee: %w", err)
-	}
-	id, err := res.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("last insert id: %w", err)
+		return nil, err
 	}
-	e.ID = id
 	return e, nil
 }
 
-func (d *employeeDAO) Update(ctx context.Context, e *model.Employee) (*model.Employee, error) {
-	e.UpdatedAt = time.Now().UTC()
-	query := `UPDATE employees SET first_name=:first_name, last_name=:last_name, email=:email, position=:position, updated_at=:updated_at WHERE id=:id`
-	res, err := d.db.NamedExecContext(ctx, query, e)
-	if err != nil {
-		return nil, fmt.Errorf("update employee: %w", err)
+// insert runs a named INSERT and returns the generated id, using RETURNING on
+// PostgreSQL where LastInsertId is not supported.
+func insert(ctx context.Context, q sqlxExecer, query string, arg interface{}) (int64, error) {
+	if q.DriverName() == db.Postgres {
+		named, args, err := sqlx.Named(query+" RETURNING id", arg)
+		if err != nil {
+			return 0, err
+		}
+		var id int64
+		err = q.QueryRowxContext(ctx, q.Rebind(named), args...).Scan(&id)
+		return id, err
 	}
-	n, err := res.RowsAffected()
+	res, err := q.NamedExecContext(ctx, query, arg)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	if n == 0 {
-		return nil, sql.ErrNoRows
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("last insert id: %w", err)
 	}
-	return e, nil
+	return id, nil
+}
+
+func (d *employeeDAO) Update(ctx context.Context, e *model.Employee) (*model.Employee, error) {
+	return d.UpdateFields(ctx, e.ID, e.Version, map[string]interface{}{
+		"first_name":    e.FirstName,
+		"last_name":     e.LastName,
+		"email":         e.Email,
+		"position":      e.Position,
+		"department_id": e.DepartmentID,
+		"manager_id":    e.ManagerID,
+	})
 }
 
 func (d *employeeDAO) GetByID(ctx context.Context, id int64) (*model.Employee, error) {
 	var e model.Employee
-	err := d.db.GetContext(ctx, &e, "SELECT * FROM employees WHERE id = ?", id)
+	query := "SELECT * FROM employees WHERE id = ? AND deleted_at IS NULL"
+	if inTx(d.db) && d.db.DriverName() == db.Postgres {
+		// lock the row for the read-modify-write done inside WithTx; SQLite
+		// already serialises writers for the whole database
+		query += " FOR UPDATE"
+	}
+	err := d.db.GetContext(ctx, &e, d.db.Rebind(query), id)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
+	}
+	return &e, nil
+}
+
+func (d *employeeDAO) GetByEmail(ctx context.Context, email string) (*model.Employee, error) {
+	var e model.Employee
+	query := "SELECT * FROM employees WHERE email = ? AND deleted_at IS NULL"
+	if inTx(d.db) && d.db.DriverName() == db.Postgres {
+		query += " FOR UPDATE"
+	}
+	if err := d.db.GetContext(ctx, &e, d.db.Rebind(query), email); err != nil {
+		return nil, mapError(err)
 	}
 	return &e, nil
 }
 
-func (d *employeeDAO) GetAll(ctx context.Context) ([]*model.Employee, error) {
-	var list []*model.Employee
-	err := d.db.SelectContext(ctx, &list, "SELECT * FROM employees ORDER BY id DESC")
+func (d *employeeDAO) GetAll(ctx context.Context, q *model.EmployeeQuery) (*model.EmployeePage, error) {
+	sort, err := normalizeSort(q.Sort)
 	if err != nil {
 		return nil, err
 	}
-	return list, nil
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	from, args := employeeSource(q)
+	conds, filterArgs := filterClause(q)
+	args = append(args, filterArgs...)
+
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM " + from + whereClause(conds)
+	if err := d.db.GetContext(ctx, &total, d.db.Rebind(countQuery), args...); err != nil {
+		return nil, mapError(fmt.Errorf("count employees: %w", err))
+	}
+
+	if q.Cursor != "" {
+		vals, err := decodeCursor(sort, q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		ks, ksArgs := keysetClause(sort, vals)
+		conds = append(conds, ks)
+		args = append(args, ksArgs...)
+	}
+
+	query := "SELECT * FROM " + from + whereClause(conds) +
+		" ORDER BY " + orderClause(sort) + " LIMIT ?"
+	// fetch one extra row to learn whether another page follows
+	args = append(args, limit+1)
+
+	list := []*model.Employee{}
+	if err := d.db.SelectContext(ctx, &list, d.db.Rebind(query), args...); err != nil {
+		return nil, mapError(fmt.Errorf("list employees: %w", err))
+	}
+
+	page := &model.EmployeePage{Items: list, Total: total}
+	if len(list) > limit {
+		page.Items = list[:limit]
+		page.NextCursor = encodeCursor(sort, list[limit-1])
+	}
+	return page, nil
 }
 
-func (d *employeeDAO) Delete(ctx context.Context, id int64) error {
-	res, err := d.db.ExecContext(ctx, "DELETE FROM employees WHERE id = ?", id)
+// Each calls fn with every employee matching q, in q's sort order. Rows are
+// scanned one at a time from the open result set, so memory stays flat however
+// many match; Limit and Cursor are ignored. An error from fn stops the
+// iteration and is returned as is.
+func (d *employeeDAO) Each(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) error {
+	sort, err := normalizeSort(q.Sort)
 	if err != nil {
 		return err
 	}
+	from, args := employeeSource(q)
+	conds, filterArgs := filterClause(q)
+	args = append(args, filterArgs...)
+	query := "SELECT * FROM " + from + whereClause(conds) + " ORDER BY " + orderClause(sort)
+
+	rows, err := d.db.QueryxContext(ctx, d.db.Rebind(query), args...)
+	if err != nil {
+		return mapError(fmt.Errorf("export employees: %w", err))
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e model.Employee
+		if err := rows.StructScan(&e); err != nil {
+			return mapError(fmt.Errorf("scan employee: %w", err))
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	return mapError(rows.Err())
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+func (d *employeeDAO) Delete(ctx context.Context, id, version int64) error {
+	now := time.Now().UTC()
+	query := "UPDATE employees SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{now, now, id}
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	return d.WithTx(ctx, func(txDAO EmployeeDAO) error {
+		tx := txDAO.(*employeeDAO)
+		before, err := tx.snapshot(ctx, id)
+		if err != nil {
+			return err
+		}
+		res, err := tx.db.ExecContext(ctx, tx.db.Rebind(query), args...)
+		if err != nil {
+			return mapError(fmt.Errorf("delete employee: %w", err))
+		}
+		if err := tx.checkAffected(ctx, res, id, false); err != nil {
+			return err
+		}
+		after, err := tx.snapshot(ctx, id)
+		if err != nil {
+			return err
+		}
+		return tx.recordWrite(ctx, model.AuditDelete, before, after)
+	})
+}
+
+func (d *employeeDAO) Restore(ctx context.Context, id, version int64) (*model.Employee, error) {
+	query := "UPDATE employees SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL"
+	args := []interface{}{time.Now().UTC(), id}
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	return d.updateAndGet(ctx, model.AuditRestore, query, args, id, true)
+}
+
+func (d *employeeDAO) Purge(ctx context.Context, id int64) error {
+	return d.WithTx(ctx, func(txDAO EmployeeDAO) error {
+		tx := txDAO.(*employeeDAO)
+		before, err := tx.snapshot(ctx, id)
+		if err != nil {
+			return err
+		}
+		res, err := tx.db.ExecContext(ctx, tx.db.Rebind("DELETE FROM employees WHERE id = ? AND deleted_at IS NOT NULL"), id)
+		if err != nil {
+			return mapError(fmt.Errorf("purge employee: %w", err))
+		}
+		err = tx.checkAffected(ctx, res, id, true)
+		if apperr.Is(err, apperr.NotFound) && before != nil && before.DeletedAt == nil {
+			// purge only takes rows from the trash; a live row must be deleted first
+			return apperr.New(apperr.Conflict, "employee must be deleted before it can be purged")
+		}
+		if err != nil {
+			return err
+		}
+		return tx.recordWrite(ctx, model.AuditPurge, before, nil)
+	})
+}
+
+func (d *employeeDAO) EmailReserved(ctx context.Context, email string) (bool, error) {
+	var n int
+	err := d.db.GetContext(ctx, &n, d.db.Rebind("SELECT COUNT(*) FROM employees WHERE email = ? AND deleted_at IS NOT NULL"), email)
+	if err != nil {
+		return false, mapError(fmt.Errorf("check reserved email: %w", err))
+	}
+	return n > 0, nil
+}
+
+// checkAffected turns a write that matched no row into NotFound or, when the
+// row exists, PreconditionFailed (its version moved on). trashed says whether
+// the write targeted a soft-deleted row or a live one.
+func (d *employeeDAO) checkAffected(ctx context.Context, res sql.Result, id int64, trashed bool) error {
 	n, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return mapError(err)
+	}
+	if n > 0 {
+		return nil
+	}
+	query := "SELECT COUNT(*) FROM employees WHERE id = ? AND deleted_at IS NULL"
+	if trashed {
+		query = "SELECT COUNT(*) FROM employees WHERE id = ? AND deleted_at IS NOT NULL"
+	}
+	var exists int
+	if err := d.db.GetContext(ctx, &exists, d.db.Rebind(query), id); err != nil {
+		return mapError(err)
+	}
+	if exists == 0 {
+		return mapError(sql.ErrNoRows)
+	}
+	return apperr.New(apperr.PreconditionFailed, "employee was modified by another request")
+}
+
+// updatableColumns whitelists the columns UpdateFields may set.
+var updatableColumns = map[string]bool{
+	"first_name":    true,
+	"last_name":     true,
+	"email":         true,
+	"position":      true,
+	"department_id": true,
+	"manager_id":    true,
+}
+
+func (d *employeeDAO) UpdateFields(ctx context.Context, id, version int64, fields map[string]interface{}) (*model.Employee, error) {
+	cols := make([]string, 0, len(fields))
+	for c := range fields {
+		if !updatableColumns[c] {
+			return nil, fmt.Errorf("update employee: column %q is not updatable", c)
+		}
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+
+	sets := make([]string, 0, len(cols)+2)
+	args := make([]interface{}, 0, len(cols)+3)
+	for _, c := range cols {
+		sets = append(sets, c+" = ?")
+		args = append(args, fields[c])
+	}
+	sets = append(sets, "updated_at = ?", "version = version + 1")
+	args = append(args, time.Now().UTC(), id)
+
+	query := "UPDATE employees SET " + strings.Join(sets, ", ") + " WHERE id = ? AND deleted_at IS NULL"
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	return d.updateAndGet(ctx, model.AuditUpdate, query, args, id, false)
+}
+
+// updateAndGet runs a single-row UPDATE and re-reads the row in the same
+// transaction, so the returned employee is exactly the one written. op is
+// recorded in the audit log.
+func (d *employeeDAO) updateAndGet(ctx context.Context, op, query string, args []interface{}, id int64, trashed bool) (*model.Employee, error) {
+	var out *model.Employee
+	err := d.WithTx(ctx, func(txDAO EmployeeDAO) error {
+		tx := txDAO.(*employeeDAO)
+		before, err := tx.snapshot(ctx, id)
+		if err != nil {
+			return err
+		}
+		res, err := tx.db.ExecContext(ctx, tx.db.Rebind(query), args...)
+		if err != nil {
+			return mapError(fmt.Errorf("%s employee: %w", op, err))
+		}
+		if err := tx.checkAffected(ctx, res, id, trashed); err != nil {
+			return err
+		}
+		if out, err = tx.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return tx.recordWrite(ctx, op, before, out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// snapshot reads employee id whether or not it is soft-deleted, or returns
+// nil if there is no such row. Writes use it to capture the audited state.
+func (d *employeeDAO) snapshot(ctx context.Context, id int64) (*model.Employee, error) {
+	query := "SELECT * FROM employees WHERE id = ?"
+	if inTx(d.db) && d.db.DriverName() == db.Postgres {
+		query += " FOR UPDATE"
 	}
-	if n == 0 {
-		return sql.ErrNoRows
+	var e model.Employee
+	err := d.db.GetContext(ctx, &e, d.db.Rebind(query), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
 	}
-	return nil
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &e, nil
+}
+
+func (d *employeeDAO) WithTx(ctx context.Context, fn func(tx EmployeeDAO) error) error {
+	return runInTx(ctx, d.root, d.db, func(q sqlxExecer) error {
+		return fn(&employeeDAO{db: q, root: d.root})
+	})
 }
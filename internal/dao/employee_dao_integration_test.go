@@ -0,0 +1,413 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+func TestEmployeeDAO_CRUD_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		da := NewEmployeeDAO(conn, driver)
+
+		created, err := da.Create(ctx, &model.Employee{FirstName: "Alice", LastName: "Smith", Email: "alice@example.com", Position: "Engineer"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if created.ID == 0 {
+			t.Fatalf("expected generated id")
+		}
+
+		created.Position = "Lead"
+		updated, err := da.Update(ctx, created)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Errorf("expected version 2 after update, got %d", updated.Version)
+		}
+		got, err := da.GetByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Position != "Lead" || got.Email != "alice@example.com" {
+			t.Errorf("unexpected employee: %+v", got)
+		}
+
+		if err := da.Delete(ctx, created.ID, updated.Version); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := da.GetByID(ctx, created.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected sql.ErrNoRows after delete, got %v", err)
+		}
+	})
+}
+
+func TestEmployeeDAO_GetAll_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		da := NewEmployeeDAO(conn, driver)
+
+		for i := 0; i < 7; i++ {
+			_, err := da.Create(ctx, &model.Employee{
+				FirstName: "Emp",
+				LastName:  fmt.Sprintf("L%d", i%3),
+				Email:     fmt.Sprintf("e%d@example.com", i),
+				Position:  "Engineer",
+			})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		q := &model.EmployeeQuery{Limit: 3, Sort: []model.SortField{{Field: "last_name"}, {Field: "created_at", Desc: true}}}
+		seen := map[int64]bool{}
+		prev := ""
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatalf("pagination did not terminate")
+			}
+			page, err := da.GetAll(ctx, q)
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if page.Total != 7 {
+				t.Errorf("expected total 7, got %d", page.Total)
+			}
+			for _, e := range page.Items {
+				if seen[e.ID] {
+					t.Errorf("employee %d returned twice", e.ID)
+				}
+				if e.LastName < prev {
+					t.Errorf("out of order: %s after %s", e.LastName, prev)
+				}
+				seen[e.ID] = true
+				prev = e.LastName
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		if len(seen) != 7 {
+			t.Errorf("expected 7 employees across pages, got %d", len(seen))
+		}
+
+		page, err := da.GetAll(ctx, &model.EmployeeQuery{NamePrefix: "L1", Email: "E1@EXAMPLE.COM"})
+		if err != nil {
+			t.Fatalf("GetAll filtered: %v", err)
+		}
+		if page.Total != 1 || len(page.Items) != 1 || page.Items[0].Email != "e1@example.com" {
+			t.Errorf("unexpected filtered page: %+v", page)
+		}
+		// name filters ignore case on every backend
+		for _, q := range []*model.EmployeeQuery{{Name: "mP"}, {NamePrefix: "eMP"}, {NamePrefix: "l", Position: "Engineer"}} {
+			page, err := da.GetAll(ctx, q)
+			if err != nil {
+				t.Fatalf("GetAll %+v: %v", q, err)
+			}
+			if page.Total != 7 {
+				t.Errorf("GetAll %+v: expected 7 employees, got %d", q, page.Total)
+			}
+		}
+
+		t.Run("Each", func(t *testing.T) {
+			// the same filters and order as GetAll, without pages
+			var names []string
+			err := da.Each(ctx, &model.EmployeeQuery{Limit: 1, Position: "Engineer", Sort: []model.SortField{{Field: "last_name", Desc: true}}},
+				func(e *model.Employee) error {
+					names = append(names, e.LastName)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("Each: %v", err)
+			}
+			if len(names) != 7 || names[0] != "L2" || names[6] != "L0" {
+				t.Errorf("unexpected rows: %v", names)
+			}
+
+			stop := errors.New("stop")
+			n := 0
+			err = da.Each(ctx, &model.EmployeeQuery{}, func(*model.Employee) error {
+				n++
+				return stop
+			})
+			if err != stop || n != 1 {
+				t.Errorf("expected the callback error after one row, got %v after %d", err, n)
+			}
+		})
+	})
+}
+
+func TestEmployeeDAO_DuplicateEmail_Conflict(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		da := NewEmployeeDAO(conn, driver)
+
+		if _, err := da.Create(ctx, &model.Employee{FirstName: "A", LastName: "B", Email: "dup@example.com"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		_, err := da.Create(ctx, &model.Employee{FirstName: "C", LastName: "D", Email: "dup@example.com"})
+		if !apperr.Is(err, apperr.Conflict) {
+			t.Fatalf("expected Conflict, got %v", err)
+		}
+		if msg := apperr.Message(err); msg != "an employee with this email already exists" {
+			t.Errorf("unexpected client message %q", msg)
+		}
+	})
+}
+
+func TestEmployeeDAO_ClosedDB_Unavailable(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		da := NewEmployeeDAO(conn, driver)
+		conn.Close()
+		_, err := da.GetByID(context.Background(), 1)
+		if !apperr.Is(err, apperr.Unavailable) {
+			t.Errorf("expected Unavailable, got %v", err)
+		}
+	})
+}
+
+func TestEmployeeDAO_UpdateFieldsInTx_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		da := NewEmployeeDAO(conn, driver)
+
+		e, err := da.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", Position: "Engineer"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := da.UpdateFields(ctx, e.ID, 0, map[string]interface{}{"position": "Lead"})
+		if err != nil {
+			t.Fatalf("UpdateFields: %v", err)
+		}
+		if got.Position != "Lead" || got.FirstName != "Ann" || got.Email != "ann@example.com" {
+			t.Errorf("untouched columns changed: %+v", got)
+		}
+
+		// an error inside WithTx rolls the whole transaction back
+		boom := errors.New("boom")
+		err = da.WithTx(ctx, func(tx EmployeeDAO) error {
+			if _, err := tx.UpdateFields(ctx, e.ID, 0, map[string]interface{}{"first_name": "Changed"}); err != nil {
+				return err
+			}
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("expected boom, got %v", err)
+		}
+		got, err = da.GetByID(ctx, e.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.FirstName != "Ann" {
+			t.Errorf("expected rollback, first_name is %q", got.FirstName)
+		}
+
+		if _, err := da.UpdateFields(ctx, e.ID, 0, map[string]interface{}{"id": 5}); err == nil {
+			t.Errorf("expected non-updatable column to be rejected")
+		}
+	})
+}
+
+func TestEmployeeDAO_OptimisticLock_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		da := NewEmployeeDAO(conn, driver)
+
+		e, err := da.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", Position: "Engineer"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if e.Version != 1 {
+			t.Fatalf("expected version 1, got %d", e.Version)
+		}
+
+		// two writers start from version 1; only the first one wins
+		first := *e
+		first.Position = "Lead"
+		if _, err := da.Update(ctx, &first); err != nil {
+			t.Fatalf("first Update: %v", err)
+		}
+		second := *e
+		second.Position = "Manager"
+		if _, err := da.Update(ctx, &second); !apperr.Is(err, apperr.PreconditionFailed) {
+			t.Errorf("expected PreconditionFailed, got %v", err)
+		}
+		if _, err := da.UpdateFields(ctx, e.ID, 1, map[string]interface{}{"position": "Manager"}); !apperr.Is(err, apperr.PreconditionFailed) {
+			t.Errorf("expected PreconditionFailed from UpdateFields, got %v", err)
+		}
+		if err := da.Delete(ctx, e.ID, 1); !apperr.Is(err, apperr.PreconditionFailed) {
+			t.Errorf("expected PreconditionFailed from Delete, got %v", err)
+		}
+
+		got, err := da.GetByID(ctx, e.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Position != "Lead" || got.Version != 2 {
+			t.Errorf("unexpected employee: %+v", got)
+		}
+		if err := da.Delete(ctx, e.ID+1000, 1); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound for a missing row, got %v", err)
+		}
+	})
+}
+
+func TestEmployeeDAO_SoftDelete_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		da := NewEmployeeDAO(conn, driver)
+
+		e, err := da.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", Position: "Engineer"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := da.Purge(ctx, e.ID); !apperr.Is(err, apperr.Conflict) {
+			t.Errorf("expected Conflict purging a live row, got %v", err)
+		}
+		if err := da.Delete(ctx, e.ID, e.Version); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := da.GetByID(ctx, e.ID); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected deleted row to be hidden, got %v", err)
+		}
+		if err := da.Delete(ctx, e.ID, 0); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound deleting twice, got %v", err)
+		}
+
+		count := func(f model.DeletedFilter) int64 {
+			page, err := da.GetAll(ctx, &model.EmployeeQuery{Deleted: f})
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			return page.Total
+		}
+		if live, all, trash := count(model.ExcludeDeleted), count(model.IncludeDeleted), count(model.OnlyDeleted); live != 0 || all != 1 || trash != 1 {
+			t.Errorf("unexpected counts live=%d all=%d trash=%d", live, all, trash)
+		}
+
+		reserved, err := da.EmailReserved(ctx, "ann@example.com")
+		if err != nil || !reserved {
+			t.Errorf("expected email reserved by the trashed row, got %v, %v", reserved, err)
+		}
+		if _, err := da.GetByEmail(ctx, "ann@example.com"); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected GetByEmail to skip the trashed row, got %v", err)
+		}
+		// the unique index only covers live rows, so the email can be reused
+		other, err := da.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Other", Email: "ann@example.com"})
+		if err != nil {
+			t.Fatalf("Create with a trashed email: %v", err)
+		}
+		if got, err := da.GetByEmail(ctx, "ann@example.com"); err != nil || got.ID != other.ID {
+			t.Errorf("expected GetByEmail to find the live row, got %+v, %v", got, err)
+		}
+		if _, err := da.Restore(ctx, e.ID, 0); !apperr.Is(err, apperr.Conflict) {
+			t.Errorf("expected Conflict restoring onto a taken email, got %v", err)
+		}
+		if err := da.Delete(ctx, other.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		restored, err := da.Restore(ctx, e.ID, 2)
+		if err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		if restored.DeletedAt != nil || restored.Version != 3 {
+			t.Errorf("unexpected restored employee: %+v", restored)
+		}
+
+		if err := da.Purge(ctx, other.ID); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+		if err := da.Purge(ctx, other.ID); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound after purge, got %v", err)
+		}
+	})
+}
+
+func TestEmployeeDAO_Hierarchy_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		da := NewEmployeeDAO(conn, driver)
+
+		add := func(first string, manager *model.Employee) *model.Employee {
+			e := &model.Employee{FirstName: first, LastName: "Org", Email: strings.ToLower(first) + "@example.com"}
+			if manager != nil {
+				e.ManagerID = &manager.ID
+			}
+			out, err := da.Create(ctx, e)
+			if err != nil {
+				t.Fatalf("Create %s: %v", first, err)
+			}
+			return out
+		}
+		ceo := add("Ceo", nil)
+		vp := add("Vp", ceo)
+		eng := add("Eng", vp)
+		ops := add("Ops", vp)
+
+		chain, err := da.Chain(ctx, eng.ID)
+		if err != nil {
+			t.Fatalf("Chain: %v", err)
+		}
+		if len(chain) != 2 || chain[0].ID != vp.ID || chain[1].ID != ceo.ID {
+			t.Errorf("unexpected chain: %+v", chain)
+		}
+
+		direct, err := da.Reports(ctx, ceo.ID, 1)
+		if err != nil {
+			t.Fatalf("Reports: %v", err)
+		}
+		if len(direct) != 1 || direct[0].ID != vp.ID || len(direct[0].Reports) != 0 {
+			t.Errorf("unexpected direct reports: %+v", direct)
+		}
+		all, err := da.Reports(ctx, ceo.ID, 0)
+		if err != nil {
+			t.Fatalf("Reports: %v", err)
+		}
+		if len(all) != 1 || len(all[0].Reports) != 2 || all[0].Reports[0].ID != eng.ID {
+			t.Errorf("unexpected report tree: %+v", all)
+		}
+
+		// deleting the VP leaves its reports at the top of the chart until
+		// they are moved
+		if err := da.Delete(ctx, vp.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		chart, err := da.OrgChart(ctx)
+		if err != nil {
+			t.Fatalf("OrgChart: %v", err)
+		}
+		if len(chart) != 3 {
+			t.Errorf("expected 3 roots after deleting the manager, got %d", len(chart))
+		}
+
+		n, err := da.ReassignReports(ctx, vp.ID, &ceo.ID)
+		if err != nil || n != 2 {
+			t.Fatalf("ReassignReports: %d, %v", n, err)
+		}
+		moved, err := da.GetByID(ctx, ops.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if moved.ManagerID == nil || *moved.ManagerID != ceo.ID || moved.Version != 2 {
+			t.Errorf("unexpected reassigned employee: %+v", moved)
+		}
+		chart, err = da.OrgChart(ctx)
+		if err != nil {
+			t.Fatalf("OrgChart: %v", err)
+		}
+		if len(chart) != 1 || len(chart[0].Reports) != 2 {
+			t.Errorf("unexpected org chart after reassign: %+v", chart)
+		}
+	})
+}
@@ -3,10 +3,13 @@ package dao
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
 
+	"emplopyee-app-go/internal/apperr"
+	dbpkg "emplopyee-app-go/internal/db"
 	"emplopyee-app-go/internal/model"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -21,7 +24,7 @@ func TestEmployeeDAO_GetByID_Success(t *testing.T) {
 	}
 	defer db.Close() // Ensure DB is closed after test completes
 
-	da := NewEmployeeDAO(db) // Instantiate EmployeeDAO with the mock DB
+	da := NewEmployeeDAO(db, dbpkg.SQLite) // Instantiate EmployeeDAO with the mock DB
 
 	cols := []string{"id", "first_name", "last_name", "email", "position", "created_at", "updated_at"} // Define the employee table columns
 	now := time.Now().UTC()                                                                            // Fix a timestamp for created_at/updated_at fields
@@ -69,7 +72,7 @@ func TestEmployeeDAO_GetByID_Success(t *testing.T) {
 // 2. defer db.Close()
 // Schedules the closing of the mock database connection after test ends.
 
-// 3. da := NewEmployeeDAO(db)
+// 3. da := NewEmployeeDAO(db, dbpkg.SQLite)
 // Constructs a new EmployeeDAO using the mocked database.
 
 // 4. cols := []string{"id", "first_name", "last_name", "email", "position", "created_at", "updated_at"}
@@ -112,7 +115,7 @@ func TestEmployeeDAO_GetByID_NotFound(t *testing.T) {
 	}
 	defer db.Close()
 
-	da := NewEmployeeDAO(db)
+	da := NewEmployeeDAO(db, dbpkg.SQLite)
 
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = ?")).
 		WithArgs(int64(42)).
@@ -125,10 +128,260 @@ func TestEmployeeDAO_GetByID_NotFound(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected error, got nil (employee: %+v)", got)
 	}
-	if err != sql.ErrNoRows {
-		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows in chain, got %v", err)
+	}
+	if !apperr.Is(err, apperr.NotFound) {
+		t.Errorf("expected apperr.NotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestEmployeeDAO_GetAll_FilterSortAndCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	da := NewEmployeeDAO(db, dbpkg.SQLite)
+	cols := []string{"id", "first_name", "last_name", "email", "position", "created_at", "updated_at"}
+	now := time.Now().UTC()
+
+	q := &model.EmployeeQuery{
+		Limit:      2,
+		Sort:       []model.SortField{{Field: "last_name"}},
+		Position:   "Engineer",
+		NamePrefix: "Sm",
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM employees WHERE deleted_at IS NULL AND position = ? AND (LOWER(first_name) LIKE LOWER(?) ESCAPE '\' OR LOWER(last_name) LIKE LOWER(?) ESCAPE '\')`)).
+		WithArgs("Engineer", "Sm%", "Sm%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(3)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM employees WHERE deleted_at IS NULL AND position = ? AND (LOWER(first_name) LIKE LOWER(?) ESCAPE '\' OR LOWER(last_name) LIKE LOWER(?) ESCAPE '\') ORDER BY last_name ASC, id ASC LIMIT ?`)).
+		WithArgs("Engineer", "Sm%", "Sm%", 3).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(int64(4), "Ann", "Smith", "ann@example.com", "Engineer", now, now).
+			AddRow(int64(9), "Bob", "Smith", "bob@example.com", "Engineer", now, now).
+			AddRow(int64(2), "Cy", "Smythe", "cy@example.com", "Engineer", now, now))
+
+	page, err := da.GetAll(context.Background(), q)
+	if err != nil {
+		t.Fatalf("GetAll error: %v", err)
+	}
+	if len(page.Items) != 2 || page.Total != 3 || page.NextCursor == "" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	// second page resumes after (Smith, 9)
+	q.Cursor = page.NextCursor
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM employees WHERE deleted_at IS NULL AND position = ?`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(3)))
+	mock.ExpectQuery(regexp.QuoteMeta(`((last_name > ?) OR (last_name = ? AND id > ?)) ORDER BY last_name ASC, id ASC LIMIT ?`)).
+		WithArgs("Engineer", "Sm%", "Sm%", "Smith", "Smith", int64(9), 3).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(int64(2), "Cy", "Smythe", "cy@example.com", "Engineer", now, now))
+
+	page, err = da.GetAll(context.Background(), q)
+	if err != nil {
+		t.Fatalf("GetAll page 2 error: %v", err)
+	}
+	if len(page.Items) != 1 || page.NextCursor != "" {
+		t.Errorf("unexpected last page: %+v", page)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestEmployeeDAO_GetAll_InvalidSort(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	da := NewEmployeeDAO(db, dbpkg.SQLite)
+	_, err = da.GetAll(context.Background(), &model.EmployeeQuery{Sort: []model.SortField{{Field: "salary; DROP TABLE employees"}}})
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestEmployeeDAO_Postgres_RebindsPlaceholders(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	da := NewEmployeeDAO(db, dbpkg.Postgres)
+	cols := []string{"id", "first_name", "last_name", "email", "position", "created_at", "updated_at"}
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = $1")).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(int64(1), "Alice", "Smith", "alice@example.com", "Engineer", now, now))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(int64(1), "Alice", "Smith", "alice@example.com", "Engineer", now, now))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE employees SET deleted_at = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND deleted_at IS NULL")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(int64(1), "Alice", "Smith", "alice@example.com", "Engineer", now, now))
+	expectRecordWrite(mock, dbpkg.Postgres, true)
+	mock.ExpectCommit()
+
+	if _, err := da.GetByID(context.Background(), 1); err != nil {
+		t.Fatalf("GetByID error: %v", err)
+	}
+	if err := da.Delete(context.Background(), 1, 0); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestEmployeeDAO_Create_PerDialect(t *testing.T) {
+	for _, driver := range []string{dbpkg.SQLite, dbpkg.Postgres} {
+		t.Run(driver, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+
+			da := NewEmployeeDAO(db, driver)
+			mock.ExpectBegin()
+			if driver == dbpkg.Postgres {
+				// no LastInsertId on lib/pq: the id comes back via RETURNING
+				mock.ExpectQuery(regexp.QuoteMeta("VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id")).
+					WithArgs("Alice", "Smith", "alice@example.com", "Engineer", nil, nil, int64(1), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta("VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+					WithArgs("Alice", "Smith", "alice@example.com", "Engineer", nil, nil, int64(1), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(7, 1))
+			}
+			expectRecordWrite(mock, driver, false)
+			mock.ExpectCommit()
+
+			got, err := da.Create(context.Background(), &model.Employee{FirstName: "Alice", LastName: "Smith", Email: "alice@example.com", Position: "Engineer"})
+			if err != nil {
+				t.Fatalf("Create error: %v", err)
+			}
+			if got.ID != 7 {
+				t.Errorf("expected id 7, got %d", got.ID)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestEmployeeDAO_MapsDriverErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	da := NewEmployeeDAO(db, dbpkg.SQLite)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = ?")).
+		WithArgs(int64(1)).
+		WillReturnError(context.DeadlineExceeded)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = ?")).
+		WithArgs(int64(2)).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = ?")).
+		WithArgs(int64(3)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE employees SET deleted_at = ?")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM employees WHERE id = ? AND deleted_at IS NULL")).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	_, err = da.GetByID(context.Background(), 1)
+	if !apperr.Is(err, apperr.Timeout) {
+		t.Errorf("expected Timeout, got %v", err)
+	}
+	_, err = da.GetByID(context.Background(), 2)
+	if !apperr.Is(err, apperr.Unavailable) {
+		t.Errorf("expected Unavailable, got %v", err)
+	}
+	err = da.Delete(context.Background(), 3, 0)
+	if !apperr.Is(err, apperr.NotFound) {
+		t.Errorf("expected NotFound, got %v", err)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
+
+func TestEmployeeDAO_Delete_StaleVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	da := NewEmployeeDAO(db, dbpkg.SQLite)
+
+	// the version check is part of the soft-delete UPDATE itself; a miss on
+	// an existing row means someone else wrote it first
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM employees WHERE id = ?")).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(int64(1), int64(3)))
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id = ? AND deleted_at IS NULL AND version = ?")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM employees WHERE id = ? AND deleted_at IS NULL")).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err = da.Delete(context.Background(), 1, 2)
+	if !apperr.Is(err, apperr.PreconditionFailed) {
+		t.Errorf("expected PreconditionFailed, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// expectRecordWrite expects the new version every write records, then the
+// audit entry (on an empty log, so with no previous hash), which comes last so
+// the audit lock is held briefly. update says whether the write replaces an
+// existing version.
+func expectRecordWrite(mock sqlmock.Sqlmock, driver string, update bool) {
+	if update {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE employee_versions SET valid_to")).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO employee_versions")).WillReturnResult(sqlmock.NewResult(0, 1))
+	if driver == dbpkg.Postgres {
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+			WithArgs(int64(auditLockKey)).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT hash FROM employee_audit ORDER BY id DESC LIMIT 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+	if driver == dbpkg.Postgres {
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO employee_audit")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	} else {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO employee_audit")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+}
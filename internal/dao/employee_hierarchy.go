@@ -0,0 +1,140 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/model"
+)
+
+// MaxHierarchyDepth bounds every recursive walk of the reporting lines. It is
+// far deeper than any real org and keeps a corrupted (cyclic) table from
+// running the recursion forever.
+const MaxHierarchyDepth = 100
+
+// Chain walks manager_id upwards from id. Soft-deleted managers are included
+// (with DeletedAt set): they are still part of the recorded reporting line,
+// and cycle checks must see them.
+func (d *employeeDAO) Chain(ctx context.Context, id int64) ([]*model.Employee, error) {
+	query := `WITH RECURSIVE chain (id, manager_id, depth) AS (
+                  SELECT id, manager_id, 0 FROM employees WHERE id = ?
+                  UNION ALL
+                  SELECT e.id, e.manager_id, c.depth + 1
+                  FROM employees e JOIN chain c ON e.id = c.manager_id
+                  WHERE c.depth < ?
+              )
+              SELECT e.* FROM chain c JOIN employees e ON e.id = c.id
+              WHERE c.depth > 0 ORDER BY c.depth`
+	list := []*model.Employee{}
+	if err := d.db.SelectContext(ctx, &list, d.db.Rebind(query), id, MaxHierarchyDepth); err != nil {
+		return nil, mapError(fmt.Errorf("manager chain: %w", err))
+	}
+	return list, nil
+}
+
+// Reports returns the live employees below id, at most depth levels down,
+// nested under their managers. A soft-deleted employee hides their subtree.
+func (d *employeeDAO) Reports(ctx context.Context, id int64, depth int) ([]*model.OrgNode, error) {
+	if depth <= 0 || depth > MaxHierarchyDepth {
+		depth = MaxHierarchyDepth
+	}
+	roots := "SELECT id, 1 FROM employees WHERE manager_id = ? AND deleted_at IS NULL"
+	rows, err := d.tree(ctx, roots, []interface{}{id}, depth)
+	if err != nil {
+		return nil, mapError(fmt.Errorf("reports: %w", err))
+	}
+	return buildTree(rows), nil
+}
+
+// OrgChart returns every live employee nested under their manager. Employees
+// without a manager, or whose manager has been soft-deleted, are roots.
+func (d *employeeDAO) OrgChart(ctx context.Context) ([]*model.OrgNode, error) {
+	roots := `SELECT id, 1 FROM employees e WHERE deleted_at IS NULL AND (manager_id IS NULL OR NOT EXISTS
+                  (SELECT 1 FROM employees m WHERE m.id = e.manager_id AND m.deleted_at IS NULL))`
+	rows, err := d.tree(ctx, roots, nil, MaxHierarchyDepth)
+	if err != nil {
+		return nil, mapError(fmt.Errorf("org chart: %w", err))
+	}
+	return buildTree(rows), nil
+}
+
+// tree expands the (id, depth) rows selected by roots downwards through live
+// employees, returning them ordered by depth and then name so that buildTree
+// sees every manager before their reports and siblings come out sorted.
+func (d *employeeDAO) tree(ctx context.Context, roots string, args []interface{}, depth int) ([]*model.Employee, error) {
+	query := `WITH RECURSIVE tree (id, depth) AS (
+                  ` + roots + `
+                  UNION ALL
+                  SELECT e.id, t.depth + 1
+                  FROM employees e JOIN tree t ON e.manager_id = t.id
+                  WHERE e.deleted_at IS NULL AND t.depth < ?
+              )
+              SELECT e.* FROM tree t JOIN employees e ON e.id = t.id
+              ORDER BY t.depth, e.last_name, e.first_name, e.id`
+	list := []*model.Employee{}
+	err := d.db.SelectContext(ctx, &list, d.db.Rebind(query), append(args, depth)...)
+	return list, err
+}
+
+// buildTree nests rows under their managers. Rows whose manager is not in the
+// set become roots.
+func buildTree(rows []*model.Employee) []*model.OrgNode {
+	nodes := make(map[int64]*model.OrgNode, len(rows))
+	roots := []*model.OrgNode{}
+	for _, e := range rows {
+		n := &model.OrgNode{Employee: e, Reports: []*model.OrgNode{}}
+		nodes[e.ID] = n
+		if e.ManagerID != nil {
+			if parent, ok := nodes[*e.ManagerID]; ok {
+				parent.Reports = append(parent.Reports, n)
+				continue
+			}
+		}
+		roots = append(roots, n)
+	}
+	return roots
+}
+
+// ReassignReports moves the live direct reports of fromID to toID (nil makes
+// them top-level) and returns how many moved. Soft-deleted reports keep the
+// manager they had when they left. Each moved employee gets its own audit
+// entry.
+func (d *employeeDAO) ReassignReports(ctx context.Context, fromID int64, toID *int64) (int64, error) {
+	var moved int64
+	err := d.WithTx(ctx, func(txDAO EmployeeDAO) error {
+		tx := txDAO.(*employeeDAO)
+		sel := "SELECT * FROM employees WHERE manager_id = ? AND deleted_at IS NULL ORDER BY id"
+		if tx.db.DriverName() == db.Postgres {
+			sel += " FOR UPDATE"
+		}
+		before := []*model.Employee{}
+		if err := tx.db.SelectContext(ctx, &before, tx.db.Rebind(sel), fromID); err != nil {
+			return mapError(fmt.Errorf("reassign reports: %w", err))
+		}
+		query := `UPDATE employees SET manager_id = ?, updated_at = ?, version = version + 1
+              WHERE manager_id = ? AND deleted_at IS NULL`
+		res, err := tx.db.ExecContext(ctx, tx.db.Rebind(query), toID, time.Now().UTC(), fromID)
+		if err != nil {
+			return mapError(fmt.Errorf("reassign reports: %w", err))
+		}
+		if moved, err = res.RowsAffected(); err != nil {
+			return mapError(err)
+		}
+		for _, b := range before {
+			after, err := tx.GetByID(ctx, b.ID)
+			if err != nil {
+				return err
+			}
+			if err := tx.recordWrite(ctx, model.AuditUpdate, b, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
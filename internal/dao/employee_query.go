@@ -0,0 +1,222 @@
+package dao
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"emplopyee-app-go/internal/model"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// sortColumns whitelists the columns a listing may be ordered by; the values
+// are interpolated into SQL so nothing outside this map is ever accepted.
+var sortColumns = map[string]string{
+	"id":         "id",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"email":      "email",
+	"position":   "position",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// IsSortable reports whether field can be used in EmployeeQuery.Sort.
+func IsSortable(field string) bool {
+	_, ok := sortColumns[field]
+	return ok
+}
+
+// normalizeSort validates the requested ordering and appends id as the final
+// tie-breaker so that keyset pagination is stable.
+func normalizeSort(in []model.SortField) ([]model.SortField, error) {
+	out := make([]model.SortField, 0, len(in)+1)
+	seen := map[string]bool{}
+	for _, s := range in {
+		if !IsSortable(s.Field) {
+			return nil, invalidQuery("unknown sort field %q", s.Field)
+		}
+		if seen[s.Field] {
+			continue
+		}
+		seen[s.Field] = true
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		// historical default: newest first
+		return []model.SortField{{Field: "id", Desc: true}}, nil
+	}
+	if !seen["id"] {
+		out = append(out, model.SortField{Field: "id", Desc: out[len(out)-1].Desc})
+	}
+	return out, nil
+}
+
+// filterClause builds the WHERE conditions shared by the page and count queries.
+func filterClause(q *model.EmployeeQuery) ([]string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	switch q.Deleted {
+	case model.ExcludeDeleted:
+		conds = append(conds, "deleted_at IS NULL")
+	case model.OnlyDeleted:
+		conds = append(conds, "deleted_at IS NOT NULL")
+	}
+	if q.Position != "" {
+		conds = append(conds, "position = ?")
+		args = append(args, q.Position)
+	}
+	if q.DepartmentID != 0 {
+		conds = append(conds, "department_id = ?")
+		args = append(args, q.DepartmentID)
+	}
+	if q.Email != "" {
+		conds = append(conds, "LOWER(email) = LOWER(?)")
+		args = append(args, q.Email)
+	}
+	// LIKE folds case on SQLite but not on PostgreSQL; LOWER makes both fold
+	if q.Name != "" {
+		p := "%" + escapeLike(q.Name) + "%"
+		conds = append(conds, `(LOWER(first_name) LIKE LOWER(?) ESCAPE '\' OR LOWER(last_name) LIKE LOWER(?) ESCAPE '\')`)
+		args = append(args, p, p)
+	}
+	if q.NamePrefix != "" {
+		p := escapeLike(q.NamePrefix) + "%"
+		conds = append(conds, `(LOWER(first_name) LIKE LOWER(?) ESCAPE '\' OR LOWER(last_name) LIKE LOWER(?) ESCAPE '\')`)
+		args = append(args, p, p)
+	}
+	return conds, args
+}
+
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// keysetClause expands a cursor into the row-value comparison
+//
+//	(a > x) OR (a = x AND b < y) OR (a = x AND b = y AND id > z)
+//
+// which works with mixed sort directions on both SQLite and PostgreSQL.
+func keysetClause(sort []model.SortField, vals []interface{}) (string, []interface{}) {
+	var ors []string
+	var args []interface{}
+	for i, s := range sort {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, sortColumns[sort[j].Field]+" = ?")
+			args = append(args, vals[j])
+		}
+		op := ">"
+		if s.Desc {
+			op = "<"
+		}
+		ands = append(ands, sortColumns[s.Field]+" "+op+" ?")
+		args = append(args, vals[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+func orderClause(sort []model.SortField) string {
+	parts := make([]string, len(sort))
+	for i, s := range sort {
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		parts[i] = sortColumns[s.Field] + " " + dir
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortValue returns the value of field on e, as stored in a cursor.
+func sortValue(e *model.Employee, field string) interface{} {
+	switch field {
+	case "id":
+		return e.ID
+	case "first_name":
+		return e.FirstName
+	case "last_name":
+		return e.LastName
+	case "email":
+		return e.Email
+	case "position":
+		return e.Position
+	case "created_at":
+		return e.CreatedAt
+	case "updated_at":
+		return e.UpdatedAt
+	}
+	return nil
+}
+
+// encodeCursor serialises the sort key of e. The sort spec is included so a
+// cursor cannot be replayed against a different ordering.
+func encodeCursor(sort []model.SortField, e *model.Employee) string {
+	c := struct {
+		S string        `json:"s"`
+		V []interface{} `json:"v"`
+	}{S: sortSpec(sort)}
+	for _, s := range sort {
+		c.V = append(c.V, sortValue(e, s.Field))
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(sort []model.SortField, cursor string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalidQuery("malformed cursor")
+	}
+	var c struct {
+		S string            `json:"s"`
+		V []json.RawMessage `json:"v"`
+	}
+	if err := json.Unmarshal(b, &c); err != nil || len(c.V) != len(sort) {
+		return nil, invalidQuery("malformed cursor")
+	}
+	if c.S != sortSpec(sort) {
+		return nil, invalidQuery("cursor does not match sort order")
+	}
+	vals := make([]interface{}, len(sort))
+	for i, s := range sort {
+		var err error
+		switch s.Field {
+		case "id":
+			var v int64
+			err = json.Unmarshal(c.V[i], &v)
+			vals[i] = v
+		case "created_at", "updated_at":
+			var v time.Time
+			err = json.Unmarshal(c.V[i], &v)
+			vals[i] = v
+		default:
+			var v string
+			err = json.Unmarshal(c.V[i], &v)
+			vals[i] = v
+		}
+		if err != nil {
+			return nil, invalidQuery("malformed cursor")
+		}
+	}
+	return vals, nil
+}
+
+func sortSpec(sort []model.SortField) string {
+	parts := make([]string, len(sort))
+	for i, s := range sort {
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		} else {
+			parts[i] = s.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,402 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// searchColumns are the indexed columns, in index order, with the weight each
+// carries in the SQLite ranking and the label that weighs it in the Postgres
+// document.
+var searchColumns = []struct {
+	name   string
+	weight float64
+	label  string
+}{
+	{"first_name", 3, "A"},
+	{"last_name", 3, "A"},
+	{"email", 2, "B"},
+	{"position", 1, "C"},
+}
+
+// searchIn returns the indexes into searchColumns of the columns named by
+// fields, or of them all when fields is empty.
+func searchIn(fields []string) []int {
+	var cols []int
+	for i, c := range searchColumns {
+		if len(fields) == 0 || slices.Contains(fields, c.name) {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
+
+// searchRow is an employee with the rank and highlighted columns of a search.
+type searchRow struct {
+	model.Employee
+	Rank        float64        `db:"rank"`
+	HLFirstName sql.NullString `db:"hl_first_name"`
+	HLLastName  sql.NullString `db:"hl_last_name"`
+	HLEmail     sql.NullString `db:"hl_email"`
+	HLPosition  sql.NullString `db:"hl_position"`
+}
+
+func (r *searchRow) hit() *model.SearchHit {
+	h := &model.SearchHit{Employee: &r.Employee, Rank: r.Rank, Highlights: map[string]string{}}
+	for name, v := range map[string]sql.NullString{
+		"first_name": r.HLFirstName,
+		"last_name":  r.HLLastName,
+		"email":      r.HLEmail,
+		"position":   r.HLPosition,
+	} {
+		// both dialects return a column that did not match unmarked
+		if v.Valid && strings.Contains(v.String, model.HighlightStart) {
+			h.Highlights[name] = v.String
+		}
+	}
+	return h
+}
+
+// A search term of at least fuzzyMinLen letters also matches the indexed
+// words beginning within one edit of it (two from fuzzyLongLen letters) that
+// start with the same letter: "enginer" finds Engineer. Of those, the
+// fuzzyMaxWords closest are searched, and a match on one counts fuzzyWeight
+// of a match on the term as typed.
+const (
+	fuzzyMinLen   = 5
+	fuzzyLongLen  = 9
+	fuzzyMaxWords = 8
+	fuzzyWeight   = 0.5
+)
+
+// searchTerm is one word of the search text with the indexed words close to
+// it.
+type searchTerm struct {
+	prefix string
+	close  []string
+}
+
+// searchTerms splits text into lowercase words of letters and digits. Each
+// matches as a prefix, so the query needs no escaping for either dialect.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Search returns up to q.Limit live employees with a word starting with, or
+// close to, each word of q.Text in one of the q.Fields columns, best match
+// first. Text without words matches nothing. On Postgres the two name columns
+// share a weight and are searched together.
+func (d *employeeDAO) Search(ctx context.Context, q *model.SearchQuery) ([]*model.SearchHit, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	words := searchTerms(q.Text)
+	cols := searchIn(q.Fields)
+	if len(words) == 0 || len(cols) == 0 {
+		return []*model.SearchHit{}, nil
+	}
+	terms, err := d.widen(ctx, words, cols)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*searchRow
+	if d.db.DriverName() == db.Postgres {
+		rows, err = d.searchPostgres(ctx, terms, cols, limit)
+	} else {
+		rows, err = d.searchSQLite(ctx, terms, cols, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]*model.SearchHit, len(rows))
+	for i, r := range rows {
+		hits[i] = r.hit()
+	}
+	return hits, nil
+}
+
+// widen pairs each word with the indexed words of cols close to it. The
+// words starting with one letter are read once per search.
+func (d *employeeDAO) widen(ctx context.Context, words []string, cols []int) ([]searchTerm, error) {
+	terms := make([]searchTerm, len(words))
+	byLetter := map[rune][]string{}
+	for i, w := range words {
+		terms[i].prefix = w
+		r := []rune(w)
+		if len(r) < fuzzyMinLen {
+			continue
+		}
+		vocab, ok := byLetter[r[0]]
+		if !ok {
+			var err error
+			if vocab, err = d.vocabulary(ctx, r[0], cols); err != nil {
+				return nil, err
+			}
+			byLetter[r[0]] = vocab
+		}
+		terms[i].close = closeWords(w, vocab)
+	}
+	return terms, nil
+}
+
+// vocabulary lists the indexed words of cols that start with first. Words of
+// soft-deleted employees are among them; the search filters those rows out.
+func (d *employeeDAO) vocabulary(ctx context.Context, first rune, cols []int) ([]string, error) {
+	var (
+		stmt string
+		args []interface{}
+		err  error
+	)
+	if d.db.DriverName() == db.Postgres {
+		stmt, args, err = sqlx.In(`SELECT DISTINCT term FROM employee_search_terms
+            WHERE weight IN (?) AND term >= ? AND term < ?`, strings.Split(searchLabels(cols), ""), string(first), string(first+1))
+	} else {
+		stmt, args, err = sqlx.In(`SELECT DISTINCT term FROM employees_fts_terms
+            WHERE col IN (?) AND term >= ? AND term < ?`, cols, string(first), string(first+1))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("search vocabulary: %w", err)
+	}
+	vocab := []string{}
+	if err := d.db.SelectContext(ctx, &vocab, d.db.Rebind(stmt), args...); err != nil {
+		return nil, mapError(fmt.Errorf("search vocabulary: %w", err))
+	}
+	return vocab, nil
+}
+
+// closeWords returns the fuzzyMaxWords words of vocab closest to term, by
+// prefixDistance, that are within reach of it. Words term is a prefix of are
+// left out, since the term matches them as typed, and so are words the search
+// text could not have produced.
+func closeWords(term string, vocab []string) []string {
+	reach := 1
+	if utf8.RuneCountInString(term) >= fuzzyLongLen {
+		reach = 2
+	}
+	type candidate struct {
+		word string
+		dist int
+	}
+	var cs []candidate
+	for _, w := range vocab {
+		if strings.HasPrefix(w, term) || !slices.Equal(searchTerms(w), []string{w}) {
+			continue
+		}
+		if dist := prefixDistance(term, w); dist <= reach {
+			cs = append(cs, candidate{w, dist})
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].dist != cs[j].dist {
+			return cs[i].dist < cs[j].dist
+		}
+		return cs[i].word < cs[j].word
+	})
+	if len(cs) > fuzzyMaxWords {
+		cs = cs[:fuzzyMaxWords]
+	}
+	out := make([]string, len(cs))
+	for i, c := range cs {
+		out[i] = c.word
+	}
+	return out
+}
+
+// prefixDistance is the fewest letters to insert, delete, replace or swap with
+// the next one to turn term into a prefix of word.
+func prefixDistance(term, word string) int {
+	a, b := []rune(term), []rune(word)
+	// d[i][j] is the distance from a[:i] to b[:j]
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return slices.Min(d[len(a)])
+}
+
+// searchLabels returns the Postgres weight labels of cols.
+func searchLabels(cols []int) string {
+	labels := ""
+	for _, i := range cols {
+		if !strings.Contains(labels, searchColumns[i].label) {
+			labels += searchColumns[i].label
+		}
+	}
+	return labels
+}
+
+// searchPostgres ranks with ts_rank over the weighted employee_search
+// documents and highlights with ts_headline. Rows matching every term as
+// typed are ranked a second time on that alone, which puts them ahead of rows
+// that only match close words.
+func (d *employeeDAO) searchPostgres(ctx context.Context, terms []searchTerm, cols []int, limit int) ([]*searchRow, error) {
+	labels := searchLabels(cols)
+	query := make([]string, len(terms))
+	exact := make([]string, len(terms))
+	for i, t := range terms {
+		exact[i] = t.prefix + ":*" + labels
+		alts := []string{exact[i]}
+		for _, w := range t.close {
+			alts = append(alts, w+":"+labels)
+		}
+		query[i] = "(" + strings.Join(alts, " | ") + ")"
+	}
+	var hl strings.Builder
+	for _, i := range cols {
+		fmt.Fprintf(&hl, ", ts_headline('simple', hits.%[1]s, q.query, q.opts) AS hl_%[1]s", searchColumns[i].name)
+	}
+	stmt := `WITH q AS (SELECT to_tsquery('simple', ?) AS query, to_tsquery('simple', ?) AS exact, ?::text AS opts),
+hits AS (
+    SELECT employees.*, ts_rank(s.document, q.query) + ts_rank(s.document, q.exact) AS rank
+    FROM employee_search s JOIN employees ON employees.id = s.employee_id CROSS JOIN q
+    WHERE s.document @@ q.query AND employees.deleted_at IS NULL
+    ORDER BY rank DESC, employees.id LIMIT ?
+)
+SELECT hits.*` + hl.String() + ` FROM hits CROSS JOIN q ORDER BY hits.rank DESC, hits.id`
+	opts := fmt.Sprintf("StartSel=%s, StopSel=%s, HighlightAll=true", model.HighlightStart, model.HighlightStop)
+
+	rows := []*searchRow{}
+	err := d.db.SelectContext(ctx, &rows, d.db.Rebind(stmt),
+		strings.Join(query, " & "), strings.Join(exact, " & "), opts, limit)
+	if err != nil {
+		return nil, mapError(fmt.Errorf("search employees: %w", err))
+	}
+	return rows, nil
+}
+
+func init() {
+	db.RegisterSQLiteFunc("employee_search_rank", rankMatch, true)
+}
+
+// searchSQLite matches against the employees_fts index. FTS4 has no built-in
+// ranking, so matches are ranked by employee_search_rank (rankMatch) over
+// their matchinfo statistics; snippets are only made for the best limit.
+func (d *employeeDAO) searchSQLite(ctx context.Context, terms []searchTerm, cols []int, limit int) ([]*searchRow, error) {
+	match, weights := sqliteMatch(terms, cols)
+	var hl strings.Builder
+	for _, i := range cols {
+		fmt.Fprintf(&hl, ", snippet(employees_fts, char(2), char(3), '…', %d, 16) AS hl_%s", i, searchColumns[i].name)
+	}
+	rank := "employee_search_rank(matchinfo(employees_fts, 'pcnx'), ?)"
+	stmt := `SELECT employees.*, ` + rank + ` AS rank` + hl.String() + `
+        FROM employees_fts JOIN employees ON employees.id = employees_fts.docid
+        WHERE employees_fts MATCH ? AND employees.deleted_at IS NULL AND employees_fts.docid IN (
+            SELECT employees_fts.docid FROM employees_fts JOIN employees ON employees.id = employees_fts.docid
+            WHERE employees_fts MATCH ? AND employees.deleted_at IS NULL
+            ORDER BY ` + rank + ` DESC, employees_fts.docid LIMIT ?)
+        ORDER BY rank DESC, employees.id`
+
+	rows := []*searchRow{}
+	if err := d.db.SelectContext(ctx, &rows, stmt, weights, match, match, weights, limit); err != nil {
+		return nil, mapError(fmt.Errorf("search employees: %w", err))
+	}
+	return rows, nil
+}
+
+// sqliteMatch builds the MATCH expression for terms, each as a prefix or one
+// of its close words, and the weights of its phrases in the order matchinfo
+// numbers them. A term limited to some of the columns becomes a column filter
+// per column.
+func sqliteMatch(terms []searchTerm, cols []int) (string, string) {
+	var weights []string
+	groups := make([]string, len(terms))
+	for i, t := range terms {
+		var alts []string
+		add := func(word string, weight float64) {
+			w := strconv.FormatFloat(weight, 'g', -1, 64)
+			if len(cols) == len(searchColumns) {
+				alts = append(alts, word)
+				weights = append(weights, w)
+				return
+			}
+			for _, c := range cols {
+				alts = append(alts, searchColumns[c].name+":"+word)
+				weights = append(weights, w)
+			}
+		}
+		add(t.prefix+"*", 1)
+		for _, w := range t.close {
+			add(w, fuzzyWeight)
+		}
+		groups[i] = alts[0]
+		if len(alts) > 1 {
+			groups[i] = "(" + strings.Join(alts, " OR ") + ")"
+		}
+	}
+	return strings.Join(groups, " "), strings.Join(weights, " ")
+}
+
+// rankMatch scores one row from its matchinfo 'pcnx' blob with BM25: for each
+// phrase and column, the phrase's weight from the space-separated weights, the
+// column's weight, the phrase's inverse document frequency and its saturated
+// frequency in the row.
+func rankMatch(info []byte, weights string) float64 {
+	const k = 1.2
+	v := make([]uint32, len(info)/4)
+	for i := range v {
+		v[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+	if len(v) < 3 {
+		return 0
+	}
+	phrases, cols, n := int(v[0]), int(v[1]), float64(v[2])
+	if len(v) < 3+3*phrases*cols {
+		return 0
+	}
+	pw := strings.Fields(weights)
+	var rank float64
+	for p := 0; p < phrases; p++ {
+		weight := 1.0
+		if p < len(pw) {
+			if w, err := strconv.ParseFloat(pw[p], 64); err == nil {
+				weight = w
+			}
+		}
+		for c := 0; c < cols && c < len(searchColumns); c++ {
+			x := v[3+3*(c+p*cols):]
+			tf, df := float64(x[0]), float64(x[2])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+			rank += weight * searchColumns[c].weight * idf * tf * (k + 1) / (tf + k)
+		}
+	}
+	return rank
+}
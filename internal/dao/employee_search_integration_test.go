@@ -0,0 +1,173 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/model"
+)
+
+func TestEmployeeDAO_Search_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		d := NewEmployeeDAO(conn, driver)
+		create := func(first, last, email, position string) *model.Employee {
+			e, err := d.Create(ctx, &model.Employee{FirstName: first, LastName: last, Email: email, Position: position})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			return e
+		}
+		ann := create("Ann", "Lee", "ann.lee@example.com", "Engineer")
+		annie := create("Annie", "Hall", "ahall@example.com", "Sales")
+		bob := create("Bob", "Ray", "bob@example.com", "Annotator")
+		jose := create("José", "Ortiz", "jortiz@example.com", "Engineer")
+		ids := func(hits []*model.SearchHit) []int64 {
+			out := make([]int64, len(hits))
+			for i, h := range hits {
+				out[i] = h.Employee.ID
+			}
+			return out
+		}
+		search := func(t *testing.T, text string) []*model.SearchHit {
+			t.Helper()
+			hits, err := d.Search(ctx, &model.SearchQuery{Text: text})
+			if err != nil {
+				t.Fatalf("Search(%q): %v", text, err)
+			}
+			return hits
+		}
+
+		t.Run("PrefixAndRank", func(t *testing.T) {
+			// a name match outranks a position match
+			hits := search(t, "ann")
+			got := ids(hits)
+			if len(got) != 3 || got[2] != bob.ID {
+				t.Fatalf("expected ann and annie before bob, got %v", got)
+			}
+			if hits[0].Rank < hits[2].Rank {
+				t.Errorf("expected descending rank, got %v then %v", hits[0].Rank, hits[2].Rank)
+			}
+		})
+
+		t.Run("AllWords", func(t *testing.T) {
+			hits := search(t, "Ann Eng")
+			if got := ids(hits); len(got) != 1 || got[0] != ann.ID {
+				t.Fatalf("expected only ann, got %v", got)
+			}
+			h := hits[0].Highlights
+			if h["first_name"] != "\x02Ann\x03" || h["position"] != "\x02Engineer\x03" {
+				t.Errorf("unexpected highlights %q", h)
+			}
+			if _, ok := h["last_name"]; ok {
+				t.Errorf("unmatched last_name highlighted: %q", h)
+			}
+		})
+
+		t.Run("EmailParts", func(t *testing.T) {
+			if got := ids(search(t, "lee@")); len(got) != 1 || got[0] != ann.ID {
+				t.Errorf("expected ann, got %v", got)
+			}
+		})
+
+		t.Run("Fields", func(t *testing.T) {
+			q := &model.SearchQuery{Text: "ahall"}
+			if hits, err := d.Search(ctx, q); err != nil || len(hits) != 1 {
+				t.Fatalf("expected annie by email, got %d hits, %v", len(hits), err)
+			}
+			q.Fields = []string{"first_name", "last_name", "position"}
+			if hits, err := d.Search(ctx, q); err != nil || len(hits) != 0 {
+				t.Fatalf("expected no hits outside email, got %d, %v", len(hits), err)
+			}
+			q = &model.SearchQuery{Text: "ann eng", Fields: q.Fields}
+			hits, err := d.Search(ctx, q)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if got := ids(hits); len(got) != 1 || got[0] != ann.ID {
+				t.Fatalf("expected only ann, got %v", got)
+			}
+			if _, ok := hits[0].Highlights["email"]; ok {
+				t.Errorf("unsearched email highlighted: %q", hits[0].Highlights)
+			}
+		})
+
+		t.Run("Diacritics", func(t *testing.T) {
+			if got := ids(search(t, "josé")); len(got) != 1 || got[0] != jose.ID {
+				t.Errorf("expected jose, got %v", got)
+			}
+			if driver == db.SQLite {
+				// the SQLite tokenizer folds accents; Postgres matches them exactly
+				if got := ids(search(t, "jose")); len(got) != 1 || got[0] != jose.ID {
+					t.Errorf("expected jose without accent, got %v", got)
+				}
+			}
+		})
+
+		t.Run("NoWords", func(t *testing.T) {
+			if hits := search(t, " -*\" "); len(hits) != 0 {
+				t.Errorf("expected no hits, got %v", ids(hits))
+			}
+		})
+
+		t.Run("Typos", func(t *testing.T) {
+			if got := ids(search(t, "enginer")); len(got) != 2 || got[0] != ann.ID || got[1] != jose.ID {
+				t.Errorf("expected both engineers, got %v", got)
+			}
+			// a swap of neighbouring letters is one edit
+			hits := search(t, "ortzi")
+			if got := ids(hits); len(got) != 1 || got[0] != jose.ID {
+				t.Fatalf("expected jose, got %v", got)
+			}
+			if h := hits[0].Highlights["last_name"]; h != "\x02Ortiz\x03" {
+				t.Errorf("unexpected highlight %q", h)
+			}
+			// too short to be corrected
+			if got := ids(search(t, "ortz")); len(got) != 0 {
+				t.Errorf("expected no hits, got %v", got)
+			}
+			// close words come from the searched columns only
+			q := &model.SearchQuery{Text: "jortz", Fields: []string{"first_name", "last_name", "position"}}
+			if hits, err := d.Search(ctx, q); err != nil || len(hits) != 0 {
+				t.Errorf("expected no hits outside email, got %d, %v", len(hits), err)
+			}
+			if got := ids(search(t, "jortz")); len(got) != 1 || got[0] != jose.ID {
+				t.Errorf("expected jose by email, got %v", got)
+			}
+
+			marty := create("Marty", "Cole", "mcole@example.com", "Sales")
+			marta := create("Marta", "Diaz", "mdiaz@example.com", "Sales")
+			if got := ids(search(t, "marta")); len(got) != 2 || got[0] != marta.ID || got[1] != marty.ID {
+				t.Errorf("expected marta before marty, got %v", got)
+			}
+		})
+
+		t.Run("Sync", func(t *testing.T) {
+			if _, err := d.UpdateFields(ctx, annie.ID, 0, map[string]interface{}{"first_name": "Zoe"}); err != nil {
+				t.Fatalf("UpdateFields: %v", err)
+			}
+			if got := ids(search(t, "zoe")); len(got) != 1 || got[0] != annie.ID {
+				t.Errorf("expected renamed annie, got %v", got)
+			}
+			if got := ids(search(t, "annie")); len(got) != 0 {
+				t.Errorf("expected old name gone, got %v", got)
+			}
+
+			if err := d.Delete(ctx, bob.ID, 0); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if got := ids(search(t, "bob")); len(got) != 0 {
+				t.Errorf("expected deleted bob hidden, got %v", got)
+			}
+			if err := d.Purge(ctx, bob.ID); err != nil {
+				t.Fatalf("Purge: %v", err)
+			}
+			create("Bobby", "Fox", "bfox@example.com", "Lead")
+			if got := ids(search(t, "bob")); len(got) != 1 {
+				t.Errorf("expected only bobby, got %v", got)
+			}
+		})
+	})
+}
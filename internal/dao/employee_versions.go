@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"emplopyee-app-go/internal/model"
+)
+
+// versionColumns selects an employee_versions row in the shape of an
+// employees row, so model.Employee scans it and the list query can run over it
+// unchanged.
+const versionColumns = "employee_id AS id, first_name, last_name, email, position, department_id, manager_id, version, created_at, updated_at, deleted_at"
+
+// validAt is the condition matching the version of each employee that was
+// current at one instant; it takes that instant twice.
+const validAt = "valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)"
+
+// GetAsOf returns employee id as it was at the instant at. Like GetByID it
+// reports NotFound if the employee did not exist then or was in the trash.
+func (d *employeeDAO) GetAsOf(ctx context.Context, id int64, at time.Time) (*model.Employee, error) {
+	var e model.Employee
+	at = at.UTC()
+	query := "SELECT " + versionColumns + " FROM employee_versions WHERE employee_id = ? AND " + validAt + " AND deleted_at IS NULL"
+	if err := d.db.GetContext(ctx, &e, d.db.Rebind(query), id, at, at); err != nil {
+		return nil, mapError(err)
+	}
+	return &e, nil
+}
+
+// employeeSource is the relation GetAll reads: the employees table, or the
+// versions current at q.AsOf presented as one.
+func employeeSource(q *model.EmployeeQuery) (string, []interface{}) {
+	if q.AsOf.IsZero() {
+		return "employees", nil
+	}
+	at := q.AsOf.UTC()
+	return "(SELECT " + versionColumns + " FROM employee_versions WHERE " + validAt + ") AS employees", []interface{}{at, at}
+}
+
+// recordWrite records a write from before to after (either may be nil) in the
+// audit log and the version history. It must run in the transaction of the
+// write.
+func (d *employeeDAO) recordWrite(ctx context.Context, op string, before, after *model.Employee) error {
+	if err := d.recordVersion(ctx, before, after); err != nil {
+		return err
+	}
+	// last, as appending to the audit chain locks out other appenders
+	return recordAudit(ctx, d.db, op, before, after)
+}
+
+// recordVersion closes the current version of the employee and, unless it was
+// purged, starts a new one from after.
+func (d *employeeDAO) recordVersion(ctx context.Context, before, after *model.Employee) error {
+	// a purge ends the employee's history now; any other write starts a new
+	// version when the row was updated
+	var id int64
+	at := time.Now().UTC()
+	switch {
+	case after != nil:
+		id, at = after.ID, after.UpdatedAt
+	case before != nil:
+		id = before.ID
+	default:
+		return nil
+	}
+
+	if before != nil {
+		_, err := d.db.ExecContext(ctx, d.db.Rebind("UPDATE employee_versions SET valid_to = ? WHERE employee_id = ? AND valid_to IS NULL"), at, id)
+		if err != nil {
+			return mapError(fmt.Errorf("close employee version: %w", err))
+		}
+	}
+	if after == nil {
+		return nil
+	}
+	query := `INSERT INTO employee_versions (employee_id, version, first_name, last_name, email, position, department_id, manager_id, created_at, updated_at, deleted_at, valid_from)
+              VALUES (:id, :version, :first_name, :last_name, :email, :position, :department_id, :manager_id, :created_at, :updated_at, :deleted_at, :updated_at)`
+	if _, err := d.db.NamedExecContext(ctx, query, after); err != nil {
+		return mapError(fmt.Errorf("insert employee version: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+func TestEmployeeDAO_AsOf_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		d := NewEmployeeDAO(conn, driver)
+		// instants between writes; the sleeps keep them apart at the
+		// databases' microsecond resolution
+		mark := func() time.Time {
+			time.Sleep(2 * time.Millisecond)
+			at := time.Now().UTC()
+			time.Sleep(2 * time.Millisecond)
+			return at
+		}
+
+		beforeCreate := mark()
+		ann, err := d.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", Position: "Engineer"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		bob, err := d.Create(ctx, &model.Employee{FirstName: "Bob", LastName: "Ray", Email: "bob@example.com"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		asEngineer := mark()
+		if _, err := d.UpdateFields(ctx, ann.ID, 0, map[string]interface{}{"position": "CTO"}); err != nil {
+			t.Fatalf("UpdateFields: %v", err)
+		}
+		asCTO := mark()
+		if err := d.Delete(ctx, ann.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		deleted := mark()
+		if _, err := d.Restore(ctx, ann.ID, 0); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		restored := mark()
+		if err := d.Delete(ctx, bob.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := d.Purge(ctx, bob.ID); err != nil {
+			t.Fatalf("Purge: %v", err)
+		}
+
+		t.Run("GetAsOf", func(t *testing.T) {
+			for _, tc := range []struct {
+				name     string
+				at       time.Time
+				position string // "" means not found
+			}{
+				{"BeforeCreate", beforeCreate, ""},
+				{"Engineer", asEngineer, "Engineer"},
+				{"CTO", asCTO, "CTO"},
+				{"Deleted", deleted, ""},
+				{"Restored", restored, "CTO"},
+				{"Future", time.Now().Add(time.Hour), "CTO"},
+			} {
+				got, err := d.GetAsOf(ctx, ann.ID, tc.at)
+				if tc.position == "" {
+					if !apperr.Is(err, apperr.NotFound) {
+						t.Errorf("%s: expected NotFound, got %+v, %v", tc.name, got, err)
+					}
+					continue
+				}
+				if err != nil || got.Position != tc.position || got.ID != ann.ID {
+					t.Errorf("%s: expected %s, got %+v, %v", tc.name, tc.position, got, err)
+				}
+			}
+		})
+
+		t.Run("GetAll", func(t *testing.T) {
+			page, err := d.GetAll(ctx, &model.EmployeeQuery{AsOf: asEngineer, Sort: []model.SortField{{Field: "id"}}})
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if page.Total != 2 || len(page.Items) != 2 || page.Items[0].Position != "Engineer" || page.Items[1].ID != bob.ID {
+				t.Errorf("unexpected page as of %s: %+v", asEngineer, page)
+			}
+
+			page, err = d.GetAll(ctx, &model.EmployeeQuery{AsOf: asCTO, Position: "CTO"})
+			if err != nil || page.Total != 1 || page.Items[0].ID != ann.ID {
+				t.Errorf("expected only ann as CTO, got %+v, %v", page, err)
+			}
+
+			page, err = d.GetAll(ctx, &model.EmployeeQuery{AsOf: deleted, Deleted: model.OnlyDeleted})
+			if err != nil || page.Total != 1 || page.Items[0].ID != ann.ID {
+				t.Errorf("expected ann in the trash, got %+v, %v", page, err)
+			}
+
+			// bob is purged: gone from now on, still there in the past
+			page, err = d.GetAll(ctx, &model.EmployeeQuery{AsOf: time.Now().UTC(), Deleted: model.IncludeDeleted})
+			if err != nil || page.Total != 1 {
+				t.Errorf("expected only ann now, got %+v, %v", page, err)
+			}
+		})
+	})
+}
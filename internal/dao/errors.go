@@ -0,0 +1,104 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"emplopyee-app-go/internal/apperr"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrInvalidQuery is the cause of every apperr.Invalid returned for unknown
+// sort fields or malformed cursors.
+var ErrInvalidQuery = errors.New("invalid query")
+
+func invalidQuery(format string, args ...interface{}) error {
+	return apperr.Wrap(apperr.Invalid, fmt.Sprintf(format, args...), ErrInvalidQuery)
+}
+
+// mapError classifies a database/sql or driver error. The original error stays
+// in the chain (so errors.Is(err, sql.ErrNoRows) still works) but only the
+// apperr message is ever shown to clients.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var ae *apperr.Error
+	if errors.As(err, &ae) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return apperr.Wrap(apperr.NotFound, "record not found", err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return apperr.Wrap(apperr.Timeout, "the database did not respond in time", err)
+	case errors.Is(err, sql.ErrConnDone), errors.Is(err, driver.ErrBadConn),
+		strings.Contains(err.Error(), "sql: database is closed"):
+		return apperr.Wrap(apperr.Unavailable, "the database is unavailable", err)
+	}
+
+	var se sqlite3.Error
+	if errors.As(err, &se) {
+		switch se.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return apperr.Wrap(apperr.Conflict, conflictMessage(se.Error()), err)
+		case sqlite3.ErrConstraintForeignKey:
+			return apperr.Wrap(apperr.Conflict, "a referenced record is missing or still in use", err)
+		case sqlite3.ErrConstraintNotNull, sqlite3.ErrConstraintCheck:
+			return apperr.Wrap(apperr.Validation, "a required value is missing or invalid", err)
+		}
+		switch se.Code {
+		case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrCantOpen, sqlite3.ErrIoErr, sqlite3.ErrFull, sqlite3.ErrReadonly:
+			return apperr.Wrap(apperr.Unavailable, "the database is unavailable", err)
+		case sqlite3.ErrInterrupt:
+			return apperr.Wrap(apperr.Timeout, "the database did not respond in time", err)
+		}
+	}
+
+	var pe *pq.Error
+	if errors.As(err, &pe) {
+		switch {
+		case pe.Code == "23505":
+			return apperr.Wrap(apperr.Conflict, conflictMessage(pe.Constraint), err)
+		case pe.Code == "23503":
+			return apperr.Wrap(apperr.Conflict, "a referenced record is missing or still in use", err)
+		case pe.Code == "23502", pe.Code == "23514":
+			return apperr.Wrap(apperr.Validation, "a required value is missing or invalid", err)
+		case pe.Code == "57014":
+			return apperr.Wrap(apperr.Timeout, "the database did not respond in time", err)
+		case pe.Code.Class() == "08", pe.Code.Class() == "53", pe.Code.Class() == "57":
+			return apperr.Wrap(apperr.Unavailable, "the database is unavailable", err)
+		}
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		if ne.Timeout() {
+			return apperr.Wrap(apperr.Timeout, "the database did not respond in time", err)
+		}
+		return apperr.Wrap(apperr.Unavailable, "the database is unavailable", err)
+	}
+
+	return apperr.Wrap(apperr.Internal, "", err)
+}
+
+// conflictMessage turns a UNIQUE violation into a client-facing message. detail
+// is the SQLite error text ("UNIQUE constraint failed: employees.email") or the
+// PostgreSQL constraint name ("employees_email_key").
+func conflictMessage(detail string) string {
+	if strings.Contains(detail, "email") {
+		return "an employee with this email already exists"
+	}
+	if strings.Contains(detail, "departments") {
+		return "a department with this name already exists"
+	}
+	return "the record conflicts with an existing one"
+}
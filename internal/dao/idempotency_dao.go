@@ -0,0 +1,129 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyDAO interface {
+	Reserve(ctx context.Context, k *model.IdempotencyKey) (*model.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, k *model.IdempotencyKey) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+type idempotencyDAO struct {
+	db sqlxExecer
+}
+
+// NewIdempotencyDAO constructs an IdempotencyDAO; see NewEmployeeDAO for the
+// meaning of driverName.
+//
+// Methods:
+//   - Reserve: Claims k.Scope/k.Key until k.LockedUntil for a request that is about to be served and returns true. If the key is held, it returns the stored record and false instead. Expired keys are removed first, so they never block a claim, and an abandoned key is taken over by a request with the same fingerprint.
+//   - Complete: Stores the response of a reserved key.
+//   - Release: Drops a key whose request produced nothing worth replaying, so a retry runs again.
+func NewIdempotencyDAO(conn *sql.DB, driverName string) IdempotencyDAO {
+	return &idempotencyDAO{db: sqlx.NewDb(conn, driverName)}
+}
+
+func (d *idempotencyDAO) Reserve(ctx context.Context, k *model.IdempotencyKey) (*model.IdempotencyKey, bool, error) {
+	now := time.Now().UTC()
+	if _, err := d.db.ExecContext(ctx, d.db.Rebind("DELETE FROM idempotency_keys WHERE expires_at <= ?"), now); err != nil {
+		return nil, false, mapError(fmt.Errorf("delete expired idempotency keys: %w", err))
+	}
+
+	// not in a transaction: on PostgreSQL a failed INSERT would abort it and
+	// the lookup of the holder below with it
+	query := `INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, status, response_headers, created_at, expires_at, locked_until)
+              VALUES (:scope, :idempotency_key, :fingerprint, 0, '', :created_at, :expires_at, :locked_until)`
+	k.Status, k.ResponseHeaders, k.ResponseBody = 0, "", nil
+	k.CreatedAt = now
+	for attempt := 0; ; attempt++ {
+		_, err := d.db.NamedExecContext(ctx, query, k)
+		if err == nil {
+			return k, true, nil
+		}
+		if err = mapError(fmt.Errorf("insert idempotency key: %w", err)); !apperr.Is(err, apperr.Conflict) {
+			return nil, false, err
+		}
+
+		held, err := d.get(ctx, k.Scope, k.Key)
+		if err == nil {
+			if !held.Abandoned(now) || held.Fingerprint != k.Fingerprint {
+				return held, false, nil
+			}
+			return d.takeOver(ctx, k, now)
+		}
+		// the holder was released between the INSERT and the SELECT; claim
+		// the key again, once
+		if !apperr.Is(err, apperr.NotFound) || attempt > 0 {
+			return nil, false, err
+		}
+	}
+}
+
+func (d *idempotencyDAO) get(ctx context.Context, scope, key string) (*model.IdempotencyKey, error) {
+	var k model.IdempotencyKey
+	err := d.db.GetContext(ctx, &k,
+		d.db.Rebind("SELECT * FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?"), scope, key)
+	if err != nil {
+		return nil, mapError(fmt.Errorf("get idempotency key: %w", err))
+	}
+	return &k, nil
+}
+
+// takeOver claims an abandoned key for k. The lease is checked again in the
+// UPDATE, so of several retries racing for the key one wins; the others get
+// the key as that one left it.
+func (d *idempotencyDAO) takeOver(ctx context.Context, k *model.IdempotencyKey, now time.Time) (*model.IdempotencyKey, bool, error) {
+	query := `UPDATE idempotency_keys SET created_at = ?, expires_at = ?, locked_until = ?
+              WHERE scope = ? AND idempotency_key = ? AND status = 0 AND (locked_until IS NULL OR locked_until <= ?)`
+	res, err := d.db.ExecContext(ctx, d.db.Rebind(query), now, k.ExpiresAt, k.LockedUntil, k.Scope, k.Key, now)
+	if err != nil {
+		return nil, false, mapError(fmt.Errorf("take over idempotency key: %w", err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, mapError(err)
+	}
+	if n == 1 {
+		return k, true, nil
+	}
+	held, err := d.get(ctx, k.Scope, k.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	return held, false, nil
+}
+
+func (d *idempotencyDAO) Complete(ctx context.Context, k *model.IdempotencyKey) error {
+	query := `UPDATE idempotency_keys SET status = ?, response_headers = ?, response_body = ?, locked_until = NULL
+              WHERE scope = ? AND idempotency_key = ?`
+	res, err := d.db.ExecContext(ctx, d.db.Rebind(query), k.Status, k.ResponseHeaders, k.ResponseBody, k.Scope, k.Key)
+	if err != nil {
+		return mapError(fmt.Errorf("complete idempotency key: %w", err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n == 0 {
+		return mapError(sql.ErrNoRows)
+	}
+	return nil
+}
+
+func (d *idempotencyDAO) Release(ctx context.Context, scope, key string) error {
+	query := "DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?"
+	if _, err := d.db.ExecContext(ctx, d.db.Rebind(query), scope, key); err != nil {
+		return mapError(fmt.Errorf("release idempotency key: %w", err))
+	}
+	return nil
+}
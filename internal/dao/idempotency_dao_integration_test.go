@@ -0,0 +1,89 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+func TestIdempotencyDAO_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		ctx := context.Background()
+		d := NewIdempotencyDAO(conn, driver)
+		newKey := func(scope, key, fp string, ttl time.Duration) *model.IdempotencyKey {
+			lease := time.Now().UTC().Add(time.Minute)
+			return &model.IdempotencyKey{Scope: scope, Key: key, Fingerprint: fp, ExpiresAt: time.Now().UTC().Add(ttl), LockedUntil: &lease}
+		}
+
+		k := newKey("alice", "k1", "fp1", time.Hour)
+		if _, ok, err := d.Reserve(ctx, k); err != nil || !ok {
+			t.Fatalf("Reserve: %v, %v", ok, err)
+		}
+		held, ok, err := d.Reserve(ctx, newKey("alice", "k1", "fp2", time.Hour))
+		if err != nil || ok || held.Fingerprint != "fp1" || !held.Pending() {
+			t.Fatalf("second Reserve: %+v, %v, %v", held, ok, err)
+		}
+		// another caller's key of the same name is a different key
+		if _, ok, err := d.Reserve(ctx, newKey("bob", "k1", "fp1", time.Hour)); err != nil || !ok {
+			t.Errorf("Reserve for another scope: %v, %v", ok, err)
+		}
+
+		k.Status, k.ResponseHeaders, k.ResponseBody = 201, `{"ETag":"\"1\""}`, []byte(`{"id":1}`)
+		if err := d.Complete(ctx, k); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		held, ok, err = d.Reserve(ctx, newKey("alice", "k1", "fp1", time.Hour))
+		if err != nil || ok || held.Status != 201 || string(held.ResponseBody) != `{"id":1}` || held.ResponseHeaders != k.ResponseHeaders {
+			t.Errorf("Reserve of a completed key: %+v, %v, %v", held, ok, err)
+		}
+		if err := d.Complete(ctx, newKey("alice", "nope", "fp", time.Hour)); !apperr.Is(err, apperr.NotFound) {
+			t.Errorf("expected NotFound completing an unknown key, got %v", err)
+		}
+
+		if err := d.Release(ctx, "alice", "k1"); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		if _, ok, err := d.Reserve(ctx, newKey("alice", "k1", "fp2", time.Hour)); err != nil || !ok {
+			t.Errorf("Reserve after Release: %v, %v", ok, err)
+		}
+
+		// the request holding "lost" dies without Complete or Release
+		lost := newKey("alice", "lost", "fp1", time.Hour)
+		past := time.Now().UTC().Add(-time.Second)
+		lost.LockedUntil = &past
+		if _, ok, err := d.Reserve(ctx, lost); err != nil || !ok {
+			t.Fatalf("Reserve: %v, %v", ok, err)
+		}
+		held, ok, err = d.Reserve(ctx, newKey("alice", "lost", "fp2", time.Hour))
+		if err != nil || ok || held.Fingerprint != "fp1" {
+			t.Errorf("a different request took over an abandoned key: %+v, %v, %v", held, ok, err)
+		}
+		retry := newKey("alice", "lost", "fp1", time.Hour)
+		if _, ok, err := d.Reserve(ctx, retry); err != nil || !ok {
+			t.Fatalf("retry of an abandoned key: %v, %v", ok, err)
+		}
+		// the retry holds a fresh lease
+		if held, ok, err := d.Reserve(ctx, newKey("alice", "lost", "fp1", time.Hour)); err != nil || ok || !held.Pending() {
+			t.Errorf("second retry while the first runs: %+v, %v, %v", held, ok, err)
+		}
+		retry.Status = 201
+		if err := d.Complete(ctx, retry); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		if held, ok, err := d.Reserve(ctx, newKey("alice", "lost", "fp1", time.Hour)); err != nil || ok || held.Status != 201 || held.LockedUntil != nil {
+			t.Errorf("Reserve of the completed retry: %+v, %v, %v", held, ok, err)
+		}
+
+		// an expired key no longer holds its name
+		if _, ok, err := d.Reserve(ctx, newKey("alice", "old", "fp1", -time.Second)); err != nil || !ok {
+			t.Fatalf("Reserve: %v, %v", ok, err)
+		}
+		if _, ok, err := d.Reserve(ctx, newKey("alice", "old", "fp2", time.Hour)); err != nil || !ok {
+			t.Errorf("Reserve of an expired key: %v, %v", ok, err)
+		}
+	})
+}
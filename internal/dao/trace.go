@@ -0,0 +1,311 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"emplopyee-app-go/internal/db"
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/tracing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Option configures NewEmployeeDAO.
+type Option func(*options)
+
+type options struct {
+	tracer *tracing.Tracer
+}
+
+// WithTracer traces every EmployeeDAO call in a span named
+// "EmployeeDAO.<method>", and every SQL statement it runs in a child span
+// with the statement, its literals redacted, and the rows it returned or
+// changed. Bound parameters are never recorded.
+func WithTracer(t *tracing.Tracer) Option {
+	return func(o *options) { o.tracer = t }
+}
+
+type tracedEmployeeDAO struct {
+	next   EmployeeDAO
+	tracer *tracing.Tracer
+}
+
+// start begins the span of a call of method; the returned func, deferred
+// with the address of the call's error, ends it.
+func (d *tracedEmployeeDAO) start(ctx context.Context, method string) (context.Context, func(*error)) {
+	ctx, span := d.tracer.Start(ctx, "EmployeeDAO."+method, tracing.KindInternal)
+	return ctx, func(err *error) {
+		span.RecordError(*err)
+		span.End()
+	}
+}
+
+func (d *tracedEmployeeDAO) Create(ctx context.Context, e *model.Employee) (out *model.Employee, err error) {
+	ctx, end := d.start(ctx, "Create")
+	defer end(&err)
+	return d.next.Create(ctx, e)
+}
+
+func (d *tracedEmployeeDAO) Update(ctx context.Context, e *model.Employee) (out *model.Employee, err error) {
+	ctx, end := d.start(ctx, "Update")
+	defer end(&err)
+	return d.next.Update(ctx, e)
+}
+
+func (d *tracedEmployeeDAO) GetByID(ctx context.Context, id int64) (out *model.Employee, err error) {
+	ctx, end := d.start(ctx, "GetByID")
+	defer end(&err)
+	return d.next.GetByID(ctx, id)
+}
+
+func (d *tracedEmployeeDAO) GetByEmail(ctx context.Context, email string) (out *model.Employee, err error) {
+	ctx, end := d.start(ctx, "GetByEmail")
+	defer end(&err)
+	return d.next.GetByEmail(ctx, email)
+}
+
+func (d *tracedEmployeeDAO) GetAsOf(ctx context.Context, id int64, at time.Time) (out *model.Employee, err error) {
+	ctx, end := d.start(ctx, "GetAsOf")
+	defer end(&err)
+	return d.next.GetAsOf(ctx, id, at)
+}
+
+func (d *tracedEmployeeDAO) GetAll(ctx context.Context, q *model.EmployeeQuery) (page *model.EmployeePage, err error) {
+	ctx, end := d.start(ctx, "GetAll")
+	defer end(&err)
+	return d.next.GetAll(ctx, q)
+}
+
+func (d *tracedEmployeeDAO) Each(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) (err error) {
+	ctx, end := d.start(ctx, "Each")
+	defer end(&err)
+	return d.next.Each(ctx, q, fn)
+}
+
+func (d *tracedEmployeeDAO) Search(ctx context.Context, q *model.SearchQuery) (hits []*model.SearchHit, err error) {
+	ctx, end := d.start(ctx, "Search")
+	defer end(&err)
+	return d.next.Search(ctx, q)
+}
+
+func (d *tracedEmployeeDAO) Delete(ctx context.Context, id, version int64) (err error) {
+	ctx, end := d.start(ctx, "Delete")
+	defer end(&err)
+	return d.next.Delete(ctx, id, version)
+}
+
+func (d *tracedEmployeeDAO) Restore(ctx context.Context, id, version int64) (out *model.Employee, err error) {
+	ctx, end := d.start(ctx, "Restore")
+	defer end(&err)
+	return d.next.Restore(ctx, id, version)
+}
+
+func (d *tracedEmployeeDAO) Purge(ctx context.Context, id int64) (err error) {
+	ctx, end := d.start(ctx, "Purge")
+	defer end(&err)
+	return d.next.Purge(ctx, id)
+}
+
+func (d *tracedEmployeeDAO) EmailReserved(ctx context.Context, email string) (ok bool, err error) {
+	ctx, end := d.start(ctx, "EmailReserved")
+	defer end(&err)
+	return d.next.EmailReserved(ctx, email)
+}
+
+func (d *tracedEmployeeDAO) Chain(ctx context.Context, id int64) (list []*model.Employee, err error) {
+	ctx, end := d.start(ctx, "Chain")
+	defer end(&err)
+	return d.next.Chain(ctx, id)
+}
+
+func (d *tracedEmployeeDAO) Reports(ctx context.Context, id int64, depth int) (nodes []*model.OrgNode, err error) {
+	ctx, end := d.start(ctx, "Reports")
+	defer end(&err)
+	return d.next.Reports(ctx, id, depth)
+}
+
+func (d *tracedEmployeeDAO) OrgChart(ctx context.Context) (nodes []*model.OrgNode, err error) {
+	ctx, end := d.start(ctx, "OrgChart")
+	defer end(&err)
+	return d.next.OrgChart(ctx)
+}
+
+func (d *tracedEmployeeDAO) ReassignReports(ctx context.Context, fromID int64, toID *int64) (n int64, err error) {
+	ctx, end := d.start(ctx, "ReassignReports")
+	defer end(&err)
+	return d.next.ReassignReports(ctx, fromID, toID)
+}
+
+func (d *tracedEmployeeDAO) UpdateFields(ctx context.Context, id, version int64, fields map[string]interface{}) (out *model.Employee, err error) {
+	ctx, end := d.start(ctx, "UpdateFields")
+	defer end(&err)
+	return d.next.UpdateFields(ctx, id, version, fields)
+}
+
+// WithTx hands fn a traced DAO bound to the transaction.
+func (d *tracedEmployeeDAO) WithTx(ctx context.Context, fn func(tx EmployeeDAO) error) (err error) {
+	ctx, end := d.start(ctx, "WithTx")
+	defer end(&err)
+	return d.next.WithTx(ctx, func(tx EmployeeDAO) error {
+		return fn(&tracedEmployeeDAO{next: tx, tracer: d.tracer})
+	})
+}
+
+// tracedExecer runs each statement of next in a client span.
+type tracedExecer struct {
+	sqlxExecer
+	tracer *tracing.Tracer
+}
+
+// withTracing wraps q when tracer is set.
+func withTracing(q sqlxExecer, tracer *tracing.Tracer) sqlxExecer {
+	if tracer == nil {
+		return q
+	}
+	return &tracedExecer{sqlxExecer: q, tracer: tracer}
+}
+
+// start begins the span of one statement; end records its row count, when
+// known (-1 otherwise), and error.
+func (t *tracedExecer) start(ctx context.Context, query string) (context.Context, func(rows int64, err error)) {
+	system := "sqlite"
+	if t.DriverName() == db.Postgres {
+		system = "postgresql"
+	}
+	text := redactSQL(query)
+	name, _, _ := strings.Cut(text, " ")
+	ctx, span := t.tracer.Start(ctx, strings.ToUpper(name), tracing.KindClient,
+		tracing.String("db.system.name", system),
+		tracing.String("db.query.text", text),
+	)
+	return ctx, func(rows int64, err error) {
+		if rows >= 0 {
+			span.SetAttributes(tracing.Int("db.response.rows", rows))
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(err)
+		}
+		span.End()
+	}
+}
+
+func (t *tracedExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, end := t.start(ctx, query)
+	res, err := t.sqlxExecer.ExecContext(ctx, query, args...)
+	end(affected(res, err), err)
+	return res, err
+}
+
+func (t *tracedExecer) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	ctx, end := t.start(ctx, query)
+	res, err := t.sqlxExecer.NamedExecContext(ctx, query, arg)
+	end(affected(res, err), err)
+	return res, err
+}
+
+func (t *tracedExecer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, end := t.start(ctx, query)
+	rows, err := t.sqlxExecer.QueryContext(ctx, query, args...)
+	end(-1, err)
+	return rows, err
+}
+
+func (t *tracedExecer) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	ctx, end := t.start(ctx, query)
+	rows, err := t.sqlxExecer.QueryxContext(ctx, query, args...)
+	end(-1, err)
+	return rows, err
+}
+
+func (t *tracedExecer) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	ctx, end := t.start(ctx, query)
+	row := t.sqlxExecer.QueryRowxContext(ctx, query, args...)
+	end(-1, row.Err())
+	return row
+}
+
+func (t *tracedExecer) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, end := t.start(ctx, query)
+	err := t.sqlxExecer.GetContext(ctx, dest, query, args...)
+	var n int64
+	if err == nil {
+		n = 1
+	}
+	end(n, err)
+	return err
+}
+
+func (t *tracedExecer) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, end := t.start(ctx, query)
+	err := t.sqlxExecer.SelectContext(ctx, dest, query, args...)
+	n := int64(-1)
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Slice {
+		n = int64(v.Elem().Len())
+	}
+	end(n, err)
+	return err
+}
+
+func affected(res sql.Result, err error) int64 {
+	if err != nil {
+		return -1
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// redactSQL replaces the string and number literals of query with ? and
+// collapses its whitespace. Placeholders ($1, ?, :name) and identifiers are
+// kept.
+func redactSQL(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	space := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = b.Len() > 0
+			continue
+		case c == '\'':
+			// to the closing quote; '' inside a literal is an escaped quote
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			c = '?'
+		case isDigit(c) && !(i > 0 && isWordByte(query[i-1])):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			c = '?'
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// isWordByte reports whether c continues an identifier or placeholder, so
+// the digits after it are part of that name (t1, $1).
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/tracing"
+)
+
+func TestRedactSQL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"SELECT * FROM employees\n              WHERE id = ? LIMIT 51",
+			"SELECT * FROM employees WHERE id = ? LIMIT ?"},
+		{"SELECT * FROM t1 WHERE a = $1 AND b = :name", "SELECT * FROM t1 WHERE a = $1 AND b = :name"},
+		{"SELECT 'it''s', 'x' || email, 3.14 FROM e", "SELECT ?, ? || email, ? FROM e"},
+		{"SELECT NULL, '', COUNT(*)", "SELECT NULL, ?, COUNT(*)"},
+	}
+	for _, tt := range tests {
+		if got := redactSQL(tt.in); got != tt.want {
+			t.Errorf("redactSQL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (r *spanRecorder) Export(_ context.Context, spans []*tracing.Span) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+func TestTracedEmployeeDAO_Backends(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, conn *sql.DB, driver string) {
+		rec := &spanRecorder{}
+		tracer := tracing.NewTracer("test", rec)
+		ed := NewEmployeeDAO(conn, driver, WithTracer(tracer))
+		ctx, root := tracer.Start(context.Background(), "test", tracing.KindInternal)
+
+		e, err := ed.Create(ctx, &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "secret@example.com"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := ed.GetByID(ctx, e.ID); err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		root.End()
+		tracer.Shutdown(context.Background())
+
+		byID := map[tracing.SpanID]*tracing.Span{}
+		for _, s := range rec.spans {
+			byID[s.SpanContext().SpanID] = s
+		}
+		attr := func(s *tracing.Span, key string) interface{} {
+			for _, a := range s.Attributes() {
+				if a.Key == key {
+					return a.Value
+				}
+			}
+			return nil
+		}
+		var inserts, selects int
+		for _, s := range rec.spans {
+			text, _ := attr(s, "db.query.text").(string)
+			if strings.Contains(text, "secret") || strings.Contains(text, "Ann") {
+				t.Errorf("statement span leaks a parameter: %q", text)
+			}
+			parent := byID[s.Parent()]
+			switch s.Name() {
+			case "INSERT":
+				inserts++
+				if parent == nil || parent.Name() != "EmployeeDAO.Create" {
+					t.Errorf("INSERT is not a child of EmployeeDAO.Create")
+				}
+			case "SELECT":
+				if parent != nil && parent.Name() == "EmployeeDAO.GetByID" {
+					selects++
+					if rows := attr(s, "db.response.rows"); rows != int64(1) {
+						t.Errorf("GetByID returned rows = %v, want 1", rows)
+					}
+				}
+			case "EmployeeDAO.Create", "EmployeeDAO.GetByID":
+				if parent != root {
+					t.Errorf("%s is not a child of the caller's span", s.Name())
+				}
+			}
+		}
+		if inserts < 1 || selects != 1 {
+			t.Errorf("got %d INSERT and %d GetByID SELECT spans", inserts, selects)
+		}
+	})
+}
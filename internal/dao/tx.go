@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sqlxExecer is the subset of *sqlx.DB and *sqlx.Tx the DAOs use, so the same
+// query code runs inside or outside a transaction.
+type sqlxExecer interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+// inTx reports whether q is bound to a transaction.
+func inTx(q sqlxExecer) bool {
+	if t, ok := q.(*tracedExecer); ok {
+		q = t.sqlxExecer
+	}
+	_, ok := q.(*sqlx.Tx)
+	return ok
+}
+
+// runInTx runs fn inside a transaction. If q is already a transaction fn joins
+// it, so DAO methods compose without nesting. The transaction is rolled back
+// when fn returns an error or panics.
+func runInTx(ctx context.Context, root *sqlx.DB, q sqlxExecer, fn func(sqlxExecer) error) (err error) {
+	if inTx(q) {
+		return fn(q)
+	}
+	tx, err := root.BeginTxx(ctx, nil)
+	if err != nil {
+		return mapError(fmt.Errorf("begin transaction: %w", err))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rerr := tx.Rollback(); rerr != nil {
+				slog.WarnContext(ctx, "rollback failed", "err", rerr, "cause", err)
+			}
+			return
+		}
+		if cerr := tx.Commit(); cerr != nil {
+			err = mapError(fmt.Errorf("commit: %w", cerr))
+		}
+	}()
+	if t, ok := q.(*tracedExecer); ok {
+		// statements in the transaction are traced like those outside it
+		return fn(withTracing(tx, t.tracer))
+	}
+	return fn(tx)
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Driver names registered with database/sql. They double as the dialect
+// identifiers passed to sqlx so placeholders are rebound correctly.
+const (
+	SQLite   = "sqlite3"
+	Postgres = "postgres"
+)
+
+// keywordDSN matches the start of a libpq keyword/value connection string,
+// such as "host=db dbname=emp user=app".
+var keywordDSN = regexp.MustCompile(`^\s*[A-Za-z_]+\s*=`)
+
+// DriverName picks the driver from the DSN: postgres:// and postgresql://
+// URLs and keyword/value strings select PostgreSQL, anything else is treated
+// as a SQLite file or URI. NewDB refuses URLs of any other scheme.
+func DriverName(dsn string) string {
+	lower := strings.ToLower(dsn)
+	if strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") || keywordDSN.MatchString(dsn) {
+		return Postgres
+	}
+	return SQLite
+}
+
+// checkDSN rejects a DSN DriverName would take for a SQLite file although it
+// is a URL of some other database, such as mysql://.
+func checkDSN(dsn string) error {
+	if DriverName(dsn) == SQLite && strings.Contains(dsn, "://") && !strings.HasPrefix(strings.ToLower(dsn), "file:") {
+		return fmt.Errorf("unsupported database DSN scheme in %q", dsn[:strings.Index(dsn, "://")+3])
+	}
+	return nil
+}
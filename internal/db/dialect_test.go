@@ -0,0 +1,26 @@
+package db
+
+import "testing"
+
+func TestDriverName(t *testing.T) {
+	for dsn, want := range map[string]string{
+		"postgres://app@db/emp":                           Postgres,
+		"PostgreSQL://app@db/emp":                         Postgres,
+		"host=db dbname=emp user=app sslmode=disable":     Postgres,
+		" dbname = emp":                                   Postgres,
+		"file:employees.db?_foreign_keys=1":               SQLite,
+		"employees.db":                                    SQLite,
+		":memory:":                                        SQLite,
+		"file:x?mode=memory&cache=shared&_foreign_keys=1": SQLite,
+	} {
+		if got := DriverName(dsn); got != want {
+			t.Errorf("DriverName(%q) = %s, want %s", dsn, got, want)
+		}
+	}
+}
+
+func TestNewDB_UnknownScheme(t *testing.T) {
+	if _, err := NewDB("mysql://app@db/emp", 1, 1, 0); err == nil {
+		t.Error("expected an error for a mysql:// DSN")
+	}
+}
@@ -0,0 +1,406 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations
+var migrationFS embed.FS
+
+var (
+	// ErrDatabaseAhead is returned when the database has migrations applied
+	// that this binary does not know about, i.e. a newer release ran against it.
+	ErrDatabaseAhead = errors.New("database schema is ahead of this binary")
+	// ErrChecksumMismatch is returned when an applied migration's SQL no
+	// longer matches the copy embedded in the binary.
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	// ErrPendingMigrations is returned by Check when migrations still need to run.
+	ErrPendingMigrations = errors.New("database has pending migrations")
+)
+
+// advisoryLockKey serialises migrators across processes on PostgreSQL.
+const advisoryLockKey = 7243089187310372
+
+// Migration is one versioned schema change, loaded from
+// migrations/<driver>/<version>_<name>.{up,down}.sql.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes a migration known to the binary, the database, or both.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Unknown is set for versions recorded in the database but missing here.
+	Unknown bool
+	// Modified is set when the recorded checksum differs from the embedded SQL.
+	Modified bool
+}
+
+type appliedMigration struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Migrator applies the embedded migrations for one dialect and records them
+// in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations embedded for driver.
+func NewMigrator(db *sql.DB, driver string) (*Migrator, error) {
+	ms, err := loadMigrations(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: ms}, nil
+}
+
+// Migrations returns the embedded migrations in version order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+func loadMigrations(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q: %w", driver, err)
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		vs, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", name)
+		}
+		v, err := strconv.ParseInt(vs, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", name, err)
+		}
+		body, err := fs.ReadFile(migrationFS, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		mig := byVersion[v]
+		if mig == nil {
+			mig = &Migration{Version: v, Name: label}
+			byVersion[v] = mig
+		} else if mig.Name != label {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", v, mig.Name, label)
+		}
+		if direction == "up" {
+			mig.Up = string(body)
+			sum := sha256.Sum256(body)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(body)
+		}
+	}
+	out := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up applies every pending migration and returns the ones it ran.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var ran []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig, mig.Up, true); err != nil {
+				return err
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down rolls back the most recently applied migration. It returns nil if
+// nothing is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	return m.rollbackLatest(ctx, false)
+}
+
+// Redo rolls back the most recently applied migration and applies it again,
+// leaving any other pending migrations alone.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	return m.rollbackLatest(ctx, true)
+}
+
+func (m *Migrator) rollbackLatest(ctx context.Context, reapply bool) (*Migration, error) {
+	var rolled *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s is irreversible", mig.Version, mig.Name)
+			}
+			if err := m.apply(ctx, conn, mig, mig.Down, false); err != nil {
+				return err
+			}
+			if reapply {
+				if err := m.apply(ctx, conn, mig, mig.Up, true); err != nil {
+					return err
+				}
+			}
+			rolled = &mig
+			return nil
+		}
+		return nil
+	})
+	return rolled, err
+}
+
+// Status reports every migration known to the binary or recorded in the database.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	var out []MigrationStatus
+	for _, mig := range m.migrations {
+		st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if a, ok := applied[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = a.AppliedAt
+			st.Modified = a.Checksum != mig.Checksum
+			delete(applied, mig.Version)
+		}
+		out = append(out, st)
+	}
+	for _, a := range applied {
+		out = append(out, MigrationStatus{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: a.AppliedAt, Unknown: true})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Check verifies the database is exactly at the binary's schema version,
+// without changing anything. It is used at startup when auto-migration is off.
+func (m *Migrator) Check(ctx context.Context) error {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			return fmt.Errorf("%w: %d_%s", ErrPendingMigrations, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// verify refuses databases that are ahead of the binary or whose applied
+// migrations were edited after the fact.
+func (m *Migrator) verify(applied map[int64]appliedMigration) error {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for v, a := range applied {
+		mig, ok := known[v]
+		if !ok {
+			return fmt.Errorf("%w: version %d_%s is not embedded", ErrDatabaseAhead, v, a.Name)
+		}
+		if a.Checksum != mig.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, v, mig.Name)
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection while holding the migration lock:
+// a session advisory lock on PostgreSQL, or an IMMEDIATE write transaction on
+// SQLite (which then spans the whole run).
+func (m *Migrator) withLock(ctx context.Context, fn func(*sql.Conn, map[int64]appliedMigration) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.driver == Postgres {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockKey)
+	} else {
+		if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer func() {
+			if err != nil {
+				conn.ExecContext(context.Background(), "ROLLBACK")
+				return
+			}
+			if _, cerr := conn.ExecContext(ctx, "COMMIT"); cerr != nil {
+				err = cerr
+			}
+		}()
+	}
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, q execQuerier) error {
+	ts := "TIMESTAMP"
+	if m.driver == Postgres {
+		ts = "TIMESTAMPTZ"
+	}
+	_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+        version BIGINT PRIMARY KEY,
+        name TEXT NOT NULL,
+        checksum TEXT NOT NULL,
+        applied_at `+ts+` NOT NULL
+    )`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context, q execQuerier) (map[int64]appliedMigration, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	out := map[int64]appliedMigration{}
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		out[a.Version] = a
+	}
+	return out, rows.Err()
+}
+
+// apply runs one migration script and records (or removes) its version. On
+// PostgreSQL each migration gets its own transaction; on SQLite the enclosing
+// lock transaction already covers it.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration, script string, up bool) error {
+	record := func(q execQuerier) error {
+		if up {
+			_, err := q.ExecContext(ctx, m.rebind("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"),
+				mig.Version, mig.Name, mig.Checksum, time.Now().UTC())
+			return err
+		}
+		_, err := q.ExecContext(ctx, m.rebind("DELETE FROM schema_migrations WHERE version = ?"), mig.Version)
+		return err
+	}
+	wrap := func(err error) error {
+		dir := "down"
+		if up {
+			dir = "up"
+		}
+		return fmt.Errorf("migration %d_%s (%s): %w", mig.Version, mig.Name, dir, err)
+	}
+
+	if m.driver != Postgres {
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return wrap(err)
+		}
+		if err := record(conn); err != nil {
+			return wrap(err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return wrap(err)
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return wrap(err)
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return wrap(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return wrap(err)
+	}
+	return nil
+}
+
+func (m *Migrator) rebind(q string) string {
+	return sqlx.Rebind(sqlx.BindType(m.driver), q)
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLite(t *testing.T) *Migrator {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_foreign_keys=1", t.Name())
+	conn, err := NewDB(dsn, 1, 1, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	m, err := NewMigrator(conn, SQLite)
+	require.NoError(t, err)
+	return m
+}
+
+func TestLoadMigrations_Dialects(t *testing.T) {
+	for _, driver := range []string{SQLite, Postgres} {
+		ms, err := loadMigrations(driver)
+		require.NoError(t, err, driver)
+		require.NotEmpty(t, ms, driver)
+		for i, m := range ms {
+			assert.NotEmpty(t, m.Checksum)
+			assert.NotEmpty(t, m.Down, "%s %d has no down script", driver, m.Version)
+			if i > 0 {
+				assert.Greater(t, m.Version, ms[i-1].Version)
+			}
+		}
+	}
+	sqlite, _ := loadMigrations(SQLite)
+	pg, _ := loadMigrations(Postgres)
+	require.Equal(t, len(sqlite), len(pg), "dialects must ship the same migration versions")
+	for i := range sqlite {
+		assert.Equal(t, sqlite[i].Version, pg[i].Version)
+		assert.Equal(t, sqlite[i].Name, pg[i].Name)
+	}
+}
+
+func TestMigrator_UpDownRedoStatus(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSQLite(t)
+	total := len(m.Migrations())
+
+	require.ErrorIs(t, m.Check(ctx), ErrPendingMigrations)
+
+	ran, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ran, total)
+	require.NoError(t, m.Check(ctx))
+
+	ran, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, ran, "second Up must be a no-op")
+
+	last := m.Migrations()[total-1]
+	rolled, err := m.Down(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, rolled)
+	assert.Equal(t, last.Version, rolled.Version)
+
+	st, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, st, total)
+	assert.False(t, st[total-1].Applied)
+	assert.True(t, st[0].Applied)
+	assert.WithinDuration(t, time.Now(), st[0].AppliedAt, time.Minute)
+
+	redone, err := m.Redo(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, redone)
+	assert.Equal(t, m.Migrations()[total-2].Version, redone.Version)
+	require.ErrorIs(t, m.Check(ctx), ErrPendingMigrations)
+}
+
+func TestMigrator_RefusesDatabaseAhead(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSQLite(t)
+	_, err := m.Up(ctx)
+	require.NoError(t, err)
+
+	_, err = m.db.Exec("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (99999, 'from_the_future', 'x', ?)", time.Now())
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx)
+	assert.True(t, errors.Is(err, ErrDatabaseAhead), "got %v", err)
+	assert.ErrorIs(t, m.Check(ctx), ErrDatabaseAhead)
+
+	st, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, st[len(st)-1].Unknown)
+}
+
+func TestMigrator_DetectsChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSQLite(t)
+	_, err := m.Up(ctx)
+	require.NoError(t, err)
+
+	_, err = m.db.Exec("UPDATE schema_migrations SET checksum = 'tampered' WHERE version = ?", m.Migrations()[0].Version)
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
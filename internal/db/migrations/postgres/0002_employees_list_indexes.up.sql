@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_employees_last_name ON employees (last_name, id);
+CREATE INDEX IF NOT EXISTS idx_employees_position ON employees (position);
+CREATE INDEX IF NOT EXISTS idx_employees_created_at ON employees (created_at, id);
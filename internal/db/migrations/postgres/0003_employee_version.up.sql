@@ -0,0 +1 @@
+ALTER TABLE employees ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+-- Soft-deleted rows become live again; this fails if one of them shares an
+-- email with a live row.
+DROP INDEX IF EXISTS idx_employees_deleted_at;
+DROP INDEX IF EXISTS idx_employees_email_live;
+ALTER TABLE employees ADD CONSTRAINT employees_email_key UNIQUE (email);
+ALTER TABLE employees DROP COLUMN deleted_at;
@@ -0,0 +1,8 @@
+-- Soft delete: rows keep their data and get deleted_at set. Email uniqueness
+-- moves from a column constraint to a partial index over live rows so that a
+-- deleted employee's email can be released; whether it is, is decided by the
+-- application.
+ALTER TABLE employees ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_email_key;
+CREATE UNIQUE INDEX idx_employees_email_live ON employees (email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_employees_deleted_at ON employees (deleted_at);
@@ -0,0 +1,10 @@
+CREATE TABLE departments (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE employees ADD COLUMN department_id BIGINT REFERENCES departments (id);
+CREATE INDEX idx_employees_department_id ON employees (department_id);
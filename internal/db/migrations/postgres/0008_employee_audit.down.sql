@@ -0,0 +1,2 @@
+DROP TABLE employee_audit;
+DROP FUNCTION employee_audit_append_only();
@@ -0,0 +1,27 @@
+-- Append-only log of employee writes, filled by the DAO in the same
+-- transaction as the write. Each row's hash covers its content and the
+-- previous row's hash (see `server audit verify`). employee_id has no foreign
+-- key: entries outlive purged employees. changes is TEXT rather than JSONB so
+-- the stored bytes are exactly the ones that were hashed.
+CREATE TABLE employee_audit (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL,
+    operation TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    changes TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT UNIQUE NOT NULL
+);
+CREATE INDEX idx_employee_audit_employee_id ON employee_audit (employee_id, id);
+CREATE INDEX idx_employee_audit_actor ON employee_audit (actor, id);
+CREATE INDEX idx_employee_audit_created_at ON employee_audit (created_at);
+
+CREATE FUNCTION employee_audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'employee_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER employee_audit_append_only BEFORE UPDATE OR DELETE ON employee_audit
+    FOR EACH ROW EXECUTE FUNCTION employee_audit_append_only();
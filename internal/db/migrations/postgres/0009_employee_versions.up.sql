@@ -0,0 +1,27 @@
+-- Temporal history of employee rows: one row per version, valid from
+-- valid_from until valid_to (NULL for the current version). The DAO writes it
+-- in the same transaction as every employee write; current-state reads keep
+-- using employees. Nothing older than the current state is known for existing
+-- rows, so their first version is valid from their last update.
+CREATE TABLE employee_versions (
+    employee_id BIGINT NOT NULL,
+    version BIGINT NOT NULL,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    position TEXT,
+    department_id BIGINT,
+    manager_id BIGINT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ,
+    PRIMARY KEY (employee_id, version)
+);
+CREATE INDEX idx_employee_versions_valid ON employee_versions (valid_from, valid_to);
+
+INSERT INTO employee_versions (employee_id, version, first_name, last_name, email, position, department_id, manager_id, created_at, updated_at, deleted_at, valid_from)
+    SELECT id, version, first_name, last_name, email, position, department_id, manager_id, created_at, updated_at, deleted_at,
+           COALESCE(updated_at, created_at, CURRENT_TIMESTAMP)
+    FROM employees;
@@ -0,0 +1,4 @@
+DROP TRIGGER employee_search_sync ON employees;
+DROP FUNCTION employee_search_sync();
+DROP TABLE employee_search;
+DROP FUNCTION employee_search_document(TEXT, TEXT, TEXT, TEXT);
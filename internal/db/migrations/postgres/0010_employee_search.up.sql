@@ -0,0 +1,30 @@
+-- Full-text index over the searchable employee columns, kept in step with
+-- every write by a trigger. Names weigh most (A), then email (B), then
+-- position (C). The email is indexed by its parts, so "lee" finds
+-- ann.lee@example.com. Rows go with their employee on purge; deleted rows are
+-- filtered out by the query, not the index.
+CREATE TABLE employee_search (
+    employee_id BIGINT PRIMARY KEY REFERENCES employees(id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL
+);
+CREATE INDEX idx_employee_search_document ON employee_search USING GIN (document);
+
+CREATE FUNCTION employee_search_document(first_name TEXT, last_name TEXT, email TEXT, position TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '')), 'A') ||
+           setweight(to_tsvector('simple', regexp_replace(coalesce(email, ''), '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+           setweight(to_tsvector('simple', coalesce(position, '')), 'C');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE FUNCTION employee_search_sync() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO employee_search (employee_id, document)
+        VALUES (NEW.id, employee_search_document(NEW.first_name, NEW.last_name, NEW.email, NEW.position))
+        ON CONFLICT (employee_id) DO UPDATE SET document = EXCLUDED.document;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER employee_search_sync AFTER INSERT OR UPDATE OF first_name, last_name, email, position ON employees
+    FOR EACH ROW EXECUTE FUNCTION employee_search_sync();
+
+INSERT INTO employee_search (employee_id, document)
+    SELECT id, employee_search_document(first_name, last_name, email, position) FROM employees;
@@ -0,0 +1,16 @@
+-- Responses to POST and PATCH requests sent with an Idempotency-Key header,
+-- kept until expires_at so a retry gets the first answer instead of running
+-- again. scope is the caller's subject, so keys of different callers never
+-- meet. status is 0 while the first request is still being served.
+CREATE TABLE idempotency_keys (
+    scope TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status INTEGER NOT NULL DEFAULT 0,
+    response_headers TEXT NOT NULL DEFAULT '',
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
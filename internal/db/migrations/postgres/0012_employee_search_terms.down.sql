@@ -0,0 +1,10 @@
+CREATE OR REPLACE FUNCTION employee_search_sync() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO employee_search (employee_id, document)
+        VALUES (NEW.id, employee_search_document(NEW.first_name, NEW.last_name, NEW.email, NEW.position))
+        ON CONFLICT (employee_id) DO UPDATE SET document = EXCLUDED.document;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE employee_search_terms;
@@ -0,0 +1,27 @@
+-- The words in each employee_search document with their weights, which a
+-- search looks through for words close to a misspelt one. The sync trigger
+-- rewrites an employee's words with the document. "C" collation so a range
+-- of words by their first letter can use the primary key.
+CREATE TABLE employee_search_terms (
+    term TEXT COLLATE "C" NOT NULL,
+    weight TEXT NOT NULL,
+    employee_id BIGINT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    PRIMARY KEY (term, weight, employee_id)
+);
+CREATE INDEX idx_employee_search_terms_employee ON employee_search_terms (employee_id);
+
+CREATE OR REPLACE FUNCTION employee_search_sync() RETURNS trigger AS $$
+DECLARE
+    doc tsvector := employee_search_document(NEW.first_name, NEW.last_name, NEW.email, NEW.position);
+BEGIN
+    INSERT INTO employee_search (employee_id, document) VALUES (NEW.id, doc)
+        ON CONFLICT (employee_id) DO UPDATE SET document = EXCLUDED.document;
+    DELETE FROM employee_search_terms WHERE employee_id = NEW.id;
+    INSERT INTO employee_search_terms (term, weight, employee_id)
+        SELECT DISTINCT t.lexeme, w, NEW.id FROM unnest(doc) t, unnest(t.weights) w;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO employee_search_terms (term, weight, employee_id)
+    SELECT DISTINCT t.lexeme, w, s.employee_id FROM employee_search s, unnest(s.document) t, unnest(t.weights) w;
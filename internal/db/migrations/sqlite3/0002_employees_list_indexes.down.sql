@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_employees_created_at;
+DROP INDEX IF EXISTS idx_employees_position;
+DROP INDEX IF EXISTS idx_employees_last_name;
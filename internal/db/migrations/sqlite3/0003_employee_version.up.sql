@@ -0,0 +1 @@
+ALTER TABLE employees ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
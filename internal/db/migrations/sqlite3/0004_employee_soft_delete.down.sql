@@ -0,0 +1,23 @@
+-- Soft-deleted rows become live again; this fails if one of them shares an
+-- email with a live row.
+CREATE TABLE employees_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    position TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1
+);
+INSERT INTO employees_old (id, first_name, last_name, email, position, created_at, updated_at, version)
+    SELECT id, first_name, last_name, email, position, created_at, updated_at, version FROM employees;
+-- carry over the AUTOINCREMENT high-water mark so ids are never reused
+DELETE FROM sqlite_sequence WHERE name = 'employees_old';
+INSERT INTO sqlite_sequence (name, seq) SELECT 'employees_old', seq FROM sqlite_sequence WHERE name = 'employees';
+DROP TABLE employees;
+ALTER TABLE employees_old RENAME TO employees;
+
+CREATE INDEX idx_employees_last_name ON employees (last_name, id);
+CREATE INDEX idx_employees_position ON employees (position);
+CREATE INDEX idx_employees_created_at ON employees (created_at, id);
@@ -0,0 +1,28 @@
+-- Soft delete: rows keep their data and get deleted_at set. Email uniqueness
+-- moves from a column constraint to a partial index over live rows so that a
+-- deleted employee's email can be released; whether it is, is decided by the
+-- application. SQLite cannot drop a column constraint, so the table is rebuilt.
+CREATE TABLE employees_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    position TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at DATETIME
+);
+INSERT INTO employees_new (id, first_name, last_name, email, position, created_at, updated_at, version)
+    SELECT id, first_name, last_name, email, position, created_at, updated_at, version FROM employees;
+-- carry over the AUTOINCREMENT high-water mark so ids are never reused
+DELETE FROM sqlite_sequence WHERE name = 'employees_new';
+INSERT INTO sqlite_sequence (name, seq) SELECT 'employees_new', seq FROM sqlite_sequence WHERE name = 'employees';
+DROP TABLE employees;
+ALTER TABLE employees_new RENAME TO employees;
+
+CREATE UNIQUE INDEX idx_employees_email_live ON employees (email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_employees_deleted_at ON employees (deleted_at);
+CREATE INDEX idx_employees_last_name ON employees (last_name, id);
+CREATE INDEX idx_employees_position ON employees (position);
+CREATE INDEX idx_employees_created_at ON employees (created_at, id);
@@ -0,0 +1,28 @@
+-- SQLite cannot drop a column that takes part in a foreign key, so employees
+-- is rebuilt without department_id.
+CREATE TABLE employees_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    position TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at DATETIME
+);
+INSERT INTO employees_old (id, first_name, last_name, email, position, created_at, updated_at, version, deleted_at)
+    SELECT id, first_name, last_name, email, position, created_at, updated_at, version, deleted_at FROM employees;
+-- carry over the AUTOINCREMENT high-water mark so ids are never reused
+DELETE FROM sqlite_sequence WHERE name = 'employees_old';
+INSERT INTO sqlite_sequence (name, seq) SELECT 'employees_old', seq FROM sqlite_sequence WHERE name = 'employees';
+DROP TABLE employees;
+ALTER TABLE employees_old RENAME TO employees;
+
+CREATE UNIQUE INDEX idx_employees_email_live ON employees (email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_employees_deleted_at ON employees (deleted_at);
+CREATE INDEX idx_employees_last_name ON employees (last_name, id);
+CREATE INDEX idx_employees_position ON employees (position);
+CREATE INDEX idx_employees_created_at ON employees (created_at, id);
+
+DROP TABLE departments;
@@ -0,0 +1,2 @@
+ALTER TABLE employees ADD COLUMN manager_id INTEGER REFERENCES employees (id);
+CREATE INDEX idx_employees_manager_id ON employees (manager_id);
@@ -0,0 +1,27 @@
+-- Append-only log of employee writes, filled by the DAO in the same
+-- transaction as the write. Each row's hash covers its content and the
+-- previous row's hash (see `server audit verify`). employee_id has no foreign
+-- key: entries outlive purged employees.
+CREATE TABLE employee_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    changes TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT UNIQUE NOT NULL
+);
+CREATE INDEX idx_employee_audit_employee_id ON employee_audit (employee_id, id);
+CREATE INDEX idx_employee_audit_actor ON employee_audit (actor, id);
+CREATE INDEX idx_employee_audit_created_at ON employee_audit (created_at);
+
+CREATE TRIGGER employee_audit_no_update BEFORE UPDATE ON employee_audit
+BEGIN
+    SELECT RAISE(ABORT, 'employee_audit is append-only');
+END;
+CREATE TRIGGER employee_audit_no_delete BEFORE DELETE ON employee_audit
+BEGIN
+    SELECT RAISE(ABORT, 'employee_audit is append-only');
+END;
@@ -0,0 +1,5 @@
+DROP TRIGGER employees_fts_after_insert;
+DROP TRIGGER employees_fts_before_update;
+DROP TRIGGER employees_fts_after_update;
+DROP TRIGGER employees_fts_before_delete;
+DROP TABLE employees_fts;
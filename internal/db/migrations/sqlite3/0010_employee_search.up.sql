@@ -0,0 +1,31 @@
+-- Full-text index over the searchable employee columns. FTS4 rather than
+-- FTS5: go-sqlite3 only compiles FTS5 in under the sqlite_fts5 build tag.
+-- The index takes its text from employees (external content), and these
+-- triggers keep it in step with every write; deleted rows are filtered out by
+-- the query, not the index.
+CREATE VIRTUAL TABLE employees_fts USING fts4(
+    content="employees",
+    first_name, last_name, email, position,
+    tokenize=unicode61 "remove_diacritics=2"
+);
+
+CREATE TRIGGER employees_fts_after_insert AFTER INSERT ON employees
+BEGIN
+    INSERT INTO employees_fts (docid, first_name, last_name, email, position)
+        VALUES (new.id, new.first_name, new.last_name, new.email, new.position);
+END;
+CREATE TRIGGER employees_fts_before_update BEFORE UPDATE OF first_name, last_name, email, position ON employees
+BEGIN
+    DELETE FROM employees_fts WHERE docid = old.id;
+END;
+CREATE TRIGGER employees_fts_after_update AFTER UPDATE OF first_name, last_name, email, position ON employees
+BEGIN
+    INSERT INTO employees_fts (docid, first_name, last_name, email, position)
+        VALUES (new.id, new.first_name, new.last_name, new.email, new.position);
+END;
+CREATE TRIGGER employees_fts_before_delete BEFORE DELETE ON employees
+BEGIN
+    DELETE FROM employees_fts WHERE docid = old.id;
+END;
+
+INSERT INTO employees_fts (employees_fts) VALUES ('rebuild');
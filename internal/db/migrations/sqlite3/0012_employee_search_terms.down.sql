@@ -0,0 +1 @@
+DROP TABLE employees_fts_terms;
@@ -0,0 +1,4 @@
+-- The words in the full-text index, per column, which a search looks through
+-- for words close to a misspelt one. fts4aux reads them straight from
+-- employees_fts, so nothing has to keep them in step.
+CREATE VIRTUAL TABLE employees_fts_terms USING fts4aux(employees_fts);
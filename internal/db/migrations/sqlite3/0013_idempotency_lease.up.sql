@@ -0,0 +1,4 @@
+-- How long the request that holds a pending key has to answer. A pending key
+-- whose lease ran out belongs to a request that died without settling it,
+-- and a retry may take it over. NULL once the key is completed.
+ALTER TABLE idempotency_keys ADD COLUMN locked_until DATETIME;
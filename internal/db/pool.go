@@ -5,13 +5,24 @@ import (
 	"fmt"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	_ "github.com/lib/pq"
 )
 
+// NewDB opens a pool for dsn using the driver chosen by DriverName. SQLite
+// connections get the functions added with RegisterSQLiteFunc. The schema is
+// not touched; run a Migrator before serving traffic.
 func NewDB(dsn string, maxOpen, maxIdle int, connMaxLifetime time.Duration) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("open db: %w", err)
+	if err := checkDSN(dsn); err != nil {
+		return nil, err
+	}
+	var db *sql.DB
+	if DriverName(dsn) == SQLite {
+		db = sql.OpenDB(newSQLiteConnector(dsn))
+	} else {
+		var err error
+		if db, err = sql.Open(Postgres, dsn); err != nil {
+			return nil, fmt.Errorf("open db: %w", err)
+		}
 	}
 
 	// Connection pool settings (like Spring DataSource)
@@ -33,27 +44,5 @@ func NewDB(dsn string, maxOpen, maxIdle int, connMaxLifetime time.Duration) (*sq
 		return nil, fmt.Errorf("ping timeout")
 	}
 
-	// Run migrations/simple table ensure (for local)
-	if err := ensureSchema(db); err != nil {
-		db.Close()
-		return nil, err
-	}
-
 	return db, nil
 }
-
-func ensureSchema(db *sql.DB) error {
-	const schema = `
-    CREATE TABLE IF NOT EXISTS employees (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        first_name TEXT NOT NULL,
-        last_name TEXT NOT NULL,
-        email TEXT UNIQUE NOT NULL,
-        position TEXT,
-        created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-        updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    );
-    `
-	_, err := db.Exec(schema)
-	return err
-}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteFunc is an application-defined SQL function added to every SQLite
+// connection NewDB opens.
+type sqliteFunc struct {
+	name string
+	impl any
+	pure bool
+}
+
+var sqliteFuncs []sqliteFunc
+
+// RegisterSQLiteFunc makes impl callable as name from SQL on SQLite; see
+// sqlite3.SQLiteConn.RegisterFunc for the signatures it accepts. Pure
+// functions may be used in indexes and are evaluated once per distinct
+// argument list. Call it from an init function: connections only pick up the
+// functions registered before they are opened.
+func RegisterSQLiteFunc(name string, impl any, pure bool) {
+	sqliteFuncs = append(sqliteFuncs, sqliteFunc{name: name, impl: impl, pure: pure})
+}
+
+// sqliteConnector opens go-sqlite3 connections with the registered functions.
+// It is used through sql.OpenDB rather than registered under a driver name,
+// so SQLite stays the name every other package knows the dialect by.
+type sqliteConnector struct {
+	dsn string
+	drv *sqlite3.SQLiteDriver
+}
+
+func newSQLiteConnector(dsn string) *sqliteConnector {
+	return &sqliteConnector{dsn: dsn, drv: &sqlite3.SQLiteDriver{
+		ConnectHook: func(c *sqlite3.SQLiteConn) error {
+			for _, f := range sqliteFuncs {
+				if err := c.RegisterFunc(f.name, f.impl, f.pure); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}}
+}
+
+func (c *sqliteConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+
+func (c *sqliteConnector) Driver() driver.Driver { return c.drv }
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/service"
+)
+
+type AuditHandler struct {
+	svc service.AuditService
+}
+
+// NewAuditHandler serves the employee audit log at /api/v1/audit and
+// /api/v1/employees/{id}/history.
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// auditResponse is the envelope of both listings; see listResponse.
+type auditResponse struct {
+	Items      []*model.AuditEntry `json:"items"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	Next       string              `json:"next,omitempty"`
+}
+
+// List returns entries across all employees, filtered by
+// ?actor=&since=&until=&employee_id=.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAuditQuery(r)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	if s := r.URL.Query().Get("employee_id"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 1 {
+			WriteProblem(w, r, badRequest(fmt.Sprintf("invalid employee_id %q", s), err))
+			return
+		}
+		q.EmployeeID = n
+	}
+	h.list(w, r, q)
+}
+
+// History returns the entries of one employee, including a purged one.
+func (h *AuditHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	q, err := parseAuditQuery(r)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	q.EmployeeID = id
+	h.list(w, r, q)
+}
+
+func (h *AuditHandler) list(w http.ResponseWriter, r *http.Request, q *model.AuditQuery) {
+	page, err := h.svc.ListAudit(r.Context(), q)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	resp := auditResponse{Items: page.Items, NextCursor: page.NextCursor}
+	if page.NextCursor != "" {
+		u := *r.URL
+		v := u.Query()
+		v.Set("cursor", page.NextCursor)
+		u.RawQuery = v.Encode()
+		resp.Next = u.RequestURI()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseAuditQuery reads the parameters shared by both listings:
+//
+//	limit=50&cursor=...&actor=alice&since=2026-01-01T00:00:00Z&until=...
+func parseAuditQuery(r *http.Request) (*model.AuditQuery, error) {
+	v := r.URL.Query()
+	q := &model.AuditQuery{Cursor: v.Get("cursor"), Actor: v.Get("actor")}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return nil, badRequest(fmt.Sprintf("invalid limit %q", s), err)
+		}
+		q.Limit = n
+	}
+	for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if s := v.Get(name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, badRequest(fmt.Sprintf("invalid %s %q: want an RFC 3339 timestamp", name, s), err)
+			}
+			*dst = t
+		}
+	}
+	return q, nil
+}
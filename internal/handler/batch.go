@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"emplopyee-app-go/internal/service"
+)
+
+// Default batch limits; see WithBatchLimits.
+const (
+	DefaultBatchMaxOps   = 500
+	DefaultBatchMaxBytes = 1 << 20
+)
+
+// WithBatchLimits bounds POST /employees:batch by number of operations and
+// body size; larger requests get 413. Values below 1 keep the defaults.
+func WithBatchLimits(maxOps int, maxBytes int64) Option {
+	return func(h *EmployeeHandler) {
+		if maxOps > 0 {
+			h.batchMaxOps = maxOps
+		}
+		if maxBytes > 0 {
+			h.batchMaxBytes = maxBytes
+		}
+	}
+}
+
+// batchItem is the outcome of one operation. Status is the code the operation
+// would have got as a single request; Error is its problem body.
+type batchItem struct {
+	Index    int         `json:"index"`
+	Status   int         `json:"status"`
+	Employee interface{} `json:"employee,omitempty"`
+	Error    *Problem    `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchItem `json:"results"`
+}
+
+// Batch runs an array of create/update/delete operations. With ?atomic=true
+// they share one transaction: the response is 200 with every result, or the
+// problem of the first failed operation (its position in "index") and nothing
+// is written. Otherwise each operation stands alone and the response is 207
+// with one result per operation, in order.
+func (h *EmployeeHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	atomic := false
+	if s := r.URL.Query().Get("atomic"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			WriteProblem(w, r, badRequest(fmt.Sprintf("invalid atomic %q", s), err))
+			return
+		}
+		atomic = b
+	}
+
+	var ops []service.BatchOp
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.batchMaxBytes)).Decode(&ops)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		WriteStatusProblem(w, r, http.StatusRequestEntityTooLarge, "payload_too_large",
+			fmt.Sprintf("a batch body is limited to %d bytes", h.batchMaxBytes))
+		return
+	case err != nil:
+		WriteProblem(w, r, badRequest("request body is not a JSON array of operations", err))
+		return
+	case len(ops) == 0:
+		WriteProblem(w, r, badRequest("batch has no operations", nil))
+		return
+	case len(ops) > h.batchMaxOps:
+		WriteStatusProblem(w, r, http.StatusRequestEntityTooLarge, "payload_too_large",
+			fmt.Sprintf("a batch is limited to %d operations", h.batchMaxOps))
+		return
+	}
+
+	results, err := h.svc.Batch(r.Context(), ops, atomic)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	resp := batchResponse{Results: make([]batchItem, len(results))}
+	for i, res := range results {
+		item := batchItem{Index: res.Index}
+		switch {
+		case res.Err != nil:
+			item.Error = newProblem(r, res.Err)
+			item.Status = item.Error.Status
+		case ops[i].Op == service.BatchCreate:
+			item.Status = http.StatusCreated
+		case ops[i].Op == service.BatchDelete:
+			item.Status = http.StatusNoContent
+		default:
+			item.Status = http.StatusOK
+		}
+		if res.Employee != nil {
+			item.Employee = h.view(r, res.Employee)
+		}
+		resp.Results[i] = item
+	}
+	status := http.StatusMultiStatus
+	if atomic {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/redact"
+	"emplopyee-app-go/internal/service"
+)
+
+type DepartmentHandler struct {
+	svc       service.DepartmentService
+	employees service.EmployeeService
+}
+
+// NewDepartmentHandler serves /api/v1/departments. employees backs the
+// membership listing at /{id}/employees.
+func NewDepartmentHandler(svc service.DepartmentService, employees service.EmployeeService) *DepartmentHandler {
+	return &DepartmentHandler{svc: svc, employees: employees}
+}
+
+func (h *DepartmentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var in model.Department
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		WriteProblem(w, r, badRequest("request body is not valid JSON", err))
+		return
+	}
+	out, err := h.svc.CreateDepartment(r.Context(), &in)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, out)
+}
+
+func (h *DepartmentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var in model.Department
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		WriteProblem(w, r, badRequest("request body is not valid JSON", err))
+		return
+	}
+	in.ID = id
+	out, err := h.svc.UpdateDepartment(r.Context(), &in)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *DepartmentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	out, err := h.svc.GetDepartment(r.Context(), id)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *DepartmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	out, err := h.svc.ListDepartments(r.Context())
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": out})
+}
+
+func (h *DepartmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteDepartment(r.Context(), id); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Employees lists the department's members. It accepts the employee list
+// parameters; department_id is taken from the path.
+func (h *DepartmentHandler) Employees(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.svc.GetDepartment(r.Context(), id); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	q, err := parseEmployeeQuery(r)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	q.DepartmentID = id
+	out, err := h.employees.ListEmployees(r.Context(), q)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newListResponse(r, redact.Employees, out))
+}
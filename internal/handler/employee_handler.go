@@ -2,95 +2,381 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"emplopyee-app-go/internal/auth"
 	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/redact"
 	"emplopyee-app-go/internal/service"
-
-	"github.com/go-chi/chi/v5"
 )
 
 type EmployeeHandler struct {
-	svc service.EmployeeService
+	svc            service.EmployeeService
+	requireIfMatch bool
+	policy         redact.Policy
+	batchMaxOps    int
+	batchMaxBytes  int64
+}
+
+// Option configures an EmployeeHandler.
+type Option func(*EmployeeHandler)
+
+// WithRequireIfMatch makes If-Match mandatory on PUT, PATCH and DELETE; requests
+// without it get 428 Precondition Required.
+func WithRequireIfMatch(require bool) Option {
+	return func(h *EmployeeHandler) { h.requireIfMatch = require }
 }
 
-func NewEmployeeHandler(svc service.EmployeeService) *EmployeeHandler {
-	return &EmployeeHandler{svc: svc}
+// WithFieldPolicy replaces the field-visibility policy applied to every
+// employee in a response (redact.Employees by default).
+func WithFieldPolicy(p redact.Policy) Option {
+	return func(h *EmployeeHandler) { h.policy = p }
+}
+
+func NewEmployeeHandler(svc service.EmployeeService, opts ...Option) *EmployeeHandler {
+	h := &EmployeeHandler{
+		svc:           svc,
+		policy:        redact.Employees,
+		batchMaxOps:   DefaultBatchMaxOps,
+		batchMaxBytes: DefaultBatchMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func (h *EmployeeHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var in model.Employee
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		WriteProblem(w, r, badRequest("request body is not valid JSON", err))
 		return
 	}
 	out, err := h.svc.CreateEmployee(r.Context(), &in)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteProblem(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(out)
+	setETag(w, out)
+	writeJSON(w, http.StatusCreated, h.view(r, out))
 }
 
 func (h *EmployeeHandler) Update(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, _ := strconv.ParseInt(idStr, 10, 64)
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	versions, ok := h.ifMatchVersions(w, r)
+	if !ok {
+		return
+	}
 
 	var in model.Employee
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		WriteProblem(w, r, badRequest("request body is not valid JSON", err))
 		return
 	}
 	in.ID = id
-	out, err := h.svc.UpdateEmployee(r.Context(), &in)
-	if err != nil {
-		if err == service.ErrNotFound {
-			http.Error(w, "not found", http.StatusNotFound)
-			return
+	bodyVersion := in.Version
+	var out *model.Employee
+	err := eachVersion(versions, func(version int64) error {
+		// If-Match wins over a version echoed back in the body
+		in.Version = bodyVersion
+		if version != 0 {
+			in.Version = version
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		var err error
+		out, err = h.svc.UpdateEmployee(r.Context(), &in)
+		return err
+	})
+	if err != nil {
+		WriteProblem(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(out)
+	setETag(w, out)
+	writeJSON(w, http.StatusOK, h.view(r, out))
 }
 
+// maxPatchBytes bounds PATCH bodies; employee documents are tiny.
+const maxPatchBytes = 64 << 10
+
+// Patch accepts application/merge-patch+json (RFC 7396) and
+// application/json-patch+json (RFC 6902). Plain application/json is treated as
+// a merge patch, which is what most clients mean by a partial update.
+func (h *EmployeeHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var format service.PatchFormat
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mt {
+	case "application/merge-patch+json", "application/json":
+		format = service.MergePatch
+	case "application/json-patch+json":
+		format = service.JSONPatch
+	default:
+		w.Header().Set("Accept-Patch", "application/merge-patch+json, application/json-patch+json")
+		WriteStatusProblem(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"PATCH requires application/merge-patch+json or application/json-patch+json")
+		return
+	}
+
+	versions, ok := h.ifMatchVersions(w, r)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		WriteProblem(w, r, badRequest("could not read patch document", err))
+		return
+	}
+	var out *model.Employee
+	err = eachVersion(versions, func(version int64) error {
+		out, err = h.svc.PatchEmployee(r.Context(), id, version, format, body)
+		return err
+	})
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	setETag(w, out)
+	writeJSON(w, http.StatusOK, h.view(r, out))
+}
+
+// Get returns the current employee, or with ?as_of=<RFC 3339> the employee as
+// they were at that instant.
 func (h *EmployeeHandler) Get(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, _ := strconv.ParseInt(idStr, 10, 64)
-	out, err := h.svc.GetEmployee(r.Context(), id)
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	asOf, err := parseAsOf(r)
 	if err != nil {
-		if err == service.ErrNotFound {
-			http.Error(w, "not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteProblem(w, r, err)
+		return
+	}
+	var out *model.Employee
+	if asOf.IsZero() {
+		out, err = h.svc.GetEmployee(r.Context(), id)
+	} else {
+		out, err = h.svc.GetEmployeeAsOf(r.Context(), id, asOf)
+	}
+	if err != nil {
+		WriteProblem(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(out)
+	setETag(w, out)
+	if noneMatch(r.Header.Get("If-None-Match"), etag(out)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.view(r, out))
+}
+
+// listResponse is the envelope returned by List. Items are already redacted
+// for the caller. Next is a ready-to-follow link to the following page and is
+// omitted on the last page.
+type listResponse struct {
+	Items      interface{} `json:"items"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Next       string      `json:"next,omitempty"`
 }
 
 func (h *EmployeeHandler) List(w http.ResponseWriter, r *http.Request) {
-	out, err := h.svc.ListEmployees(r.Context())
+	h.list(w, r, false)
+}
+
+// Trash lists soft-deleted employees; it takes the same parameters as List.
+func (h *EmployeeHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, true)
+}
+
+func (h *EmployeeHandler) list(w http.ResponseWriter, r *http.Request, trash bool) {
+	q, err := parseEmployeeQuery(r)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	if trash {
+		q.Deleted = model.OnlyDeleted
+	}
+	out, err := h.svc.ListEmployees(r.Context(), q)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		WriteProblem(w, r, err)
 		return
 	}
-	json.NewEncoder(w).Encode(out)
+	writeJSON(w, http.StatusOK, newListResponse(r, h.policy, out))
 }
 
-func (h *EmployeeHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, _ := strconv.ParseInt(idStr, 10, 64)
-	err := h.svc.DeleteEmployee(r.Context(), id)
+func newListResponse(r *http.Request, policy redact.Policy, page *model.EmployeePage) listResponse {
+	resp := listResponse{
+		Items:      policy.EmployeeList(caller(r), page.Items),
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+	if page.NextCursor != "" {
+		u := *r.URL
+		v := u.Query()
+		v.Set("cursor", page.NextCursor)
+		u.RawQuery = v.Encode()
+		resp.Next = u.RequestURI()
+	}
+	return resp
+}
+
+// parseEmployeeQuery reads the list parameters:
+//
+//	limit=50&cursor=...&sort=last_name,-created_at
+//	position=Engineer&email=a@b.c&name=ali&name_prefix=Al
+//	department_id=3&include_deleted=true&as_of=2026-03-31T23:59:59Z
+func parseEmployeeQuery(r *http.Request) (*model.EmployeeQuery, error) {
+	asOf, err := parseAsOf(r)
 	if err != nil {
-		if err == service.ErrNotFound {
-			http.Error(w, "not found", http.StatusNotFound)
-			return
+		return nil, err
+	}
+	v := r.URL.Query()
+	q := &model.EmployeeQuery{
+		Cursor:     v.Get("cursor"),
+		Position:   v.Get("position"),
+		Email:      v.Get("email"),
+		Name:       v.Get("name"),
+		NamePrefix: v.Get("name_prefix"),
+		AsOf:       asOf,
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return nil, badRequest(fmt.Sprintf("invalid limit %q", s), err)
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		q.Limit = n
+	}
+	if s := v.Get("department_id"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 1 {
+			return nil, badRequest(fmt.Sprintf("invalid department_id %q", s), err)
+		}
+		q.DepartmentID = n
+	}
+	if s := v.Get("include_deleted"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, badRequest(fmt.Sprintf("invalid include_deleted %q", s), err)
+		}
+		if b {
+			q.Deleted = model.IncludeDeleted
+		}
+	}
+	if s := v.Get("sort"); s != "" {
+		for _, f := range strings.Split(s, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			sf := model.SortField{Field: f}
+			if strings.HasPrefix(f, "-") {
+				sf = model.SortField{Field: f[1:], Desc: true}
+			} else if strings.HasPrefix(f, "+") {
+				sf.Field = f[1:]
+			}
+			q.Sort = append(q.Sort, sf)
+		}
+	}
+	return q, nil
+}
+
+// parseAsOf reads the optional as_of parameter; the zero time means "now".
+func parseAsOf(r *http.Request) (time.Time, error) {
+	s := r.URL.Query().Get("as_of")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, badRequest(fmt.Sprintf("invalid as_of %q: want an RFC 3339 timestamp", s), err)
+	}
+	return t, nil
+}
+
+func (h *EmployeeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	versions, ok := h.ifMatchVersions(w, r)
+	if !ok {
+		return
+	}
+	err := eachVersion(versions, func(version int64) error {
+		return h.svc.DeleteEmployee(r.Context(), id, version)
+	})
+	if err != nil {
+		WriteProblem(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// Restore takes an employee out of the trash. If-Match applies to the version
+// of the deleted record, as shown in the trash listing.
+func (h *EmployeeHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	versions, ok := h.ifMatchVersions(w, r)
+	if !ok {
+		return
+	}
+	var out *model.Employee
+	err := eachVersion(versions, func(version int64) error {
+		var err error
+		out, err = h.svc.RestoreEmployee(r.Context(), id, version)
+		return err
+	})
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	setETag(w, out)
+	writeJSON(w, http.StatusOK, h.view(r, out))
+}
+
+// Purge permanently removes an employee that is already in the trash.
+func (h *EmployeeHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.PurgeEmployee(r.Context(), id); err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// view applies the field policy to a single employee for the caller of r.
+func (h *EmployeeHandler) view(r *http.Request, e *model.Employee) interface{} {
+	return h.policy.Employee(caller(r), e)
+}
+
+// caller returns the authenticated principal of r, or nil.
+func caller(r *http.Request) *auth.Principal {
+	p, _ := auth.FromContext(r.Context())
+	return p
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+// etag renders an employee version as a strong entity tag.
+func etag(e *model.Employee) string {
+	return `"` + strconv.FormatInt(e.Version, 10) + `"`
+}
+
+// setETag sets the ETag header for e.
+func setETag(w http.ResponseWriter, e *model.Employee) {
+	w.Header().Set("ETag", etag(e))
+}
+
+// maxIfMatchTags bounds the entity tags of one If-Match header; each is tried
+// against the database in turn.
+const maxIfMatchTags = 16
+
+// ifMatchVersions reads If-Match for a conditional write. It returns no
+// versions when the header is absent or "*" (any current version), or else
+// the versions named by its strong tags, in order. Weak tags never match under
+// the strong comparison If-Match uses, so a header naming nothing this API
+// could have issued is a 412 right away. A missing header is a 428 when the
+// handler requires it.
+func (h *EmployeeHandler) ifMatchVersions(w http.ResponseWriter, r *http.Request) ([]int64, bool) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" && h.requireIfMatch {
+		WriteStatusProblem(w, r, http.StatusPreconditionRequired, "precondition_required",
+			"this request must be conditional; send If-Match with the employee's ETag")
+		return nil, false
+	}
+	versions, err := parseIfMatch(v)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return nil, false
+	}
+	return versions, true
+}
+
+// parseIfMatch parses an If-Match field value, a comma-separated list of
+// entity tags or "*". It returns nil for an empty value or "*".
+func parseIfMatch(header string) ([]int64, error) {
+	var versions []int64
+	weak := false
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		switch {
+		case t == "":
+			// empty list elements are allowed and mean nothing
+			continue
+		case t == "*":
+			return nil, nil
+		case strings.HasPrefix(t, "W/"):
+			weak = true
+			continue
+		}
+		if len(t) < 2 || t[0] != '"' || t[len(t)-1] != '"' {
+			return nil, apperr.New(apperr.Invalid, "If-Match must be \"*\" or a list of quoted entity tags")
+		}
+		n, err := strconv.ParseInt(t[1:len(t)-1], 10, 64)
+		if err != nil || n < 1 || slices.Contains(versions, n) {
+			// a well-formed tag we never issued can never match
+			continue
+		}
+		versions = append(versions, n)
+	}
+	switch {
+	case len(versions) > maxIfMatchTags:
+		return nil, apperr.New(apperr.Invalid, fmt.Sprintf("If-Match lists more than %d entity tags", maxIfMatchTags))
+	case len(versions) > 0:
+		return versions, nil
+	case weak:
+		return nil, apperr.New(apperr.PreconditionFailed, "If-Match requires a strong entity tag")
+	case header == "":
+		return nil, nil
+	}
+	return nil, apperr.New(apperr.PreconditionFailed, "If-Match does not match the current ETag")
+}
+
+// eachVersion runs a conditional write against each of versions until one of
+// them is current. A write made with a version that is no longer current
+// changes nothing and fails with PreconditionFailed, so trying the next one is
+// safe. No versions means an unconditional write, version 0.
+func eachVersion(versions []int64, write func(version int64) error) error {
+	if len(versions) == 0 {
+		return write(0)
+	}
+	var err error
+	for _, v := range versions {
+		if err = write(v); !apperr.Is(err, apperr.PreconditionFailed) {
+			return err
+		}
+	}
+	return err
+}
+
+// noneMatch reports whether an If-None-Match header matches tag, using the
+// weak comparison RFC 9110 prescribes for GET.
+func noneMatch(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
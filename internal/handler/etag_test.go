@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		want   []int64
+		kind   apperr.Kind // apperr.Internal, the zero Kind, when the header parses
+	}{
+		{header: "", want: nil},
+		{header: "*", want: nil},
+		{header: `"3"`, want: []int64{3}},
+		{header: `"3", "4"`, want: []int64{3, 4}},
+		{header: `"3","4", "3"`, want: []int64{3, 4}},
+		{header: `W/"2", "5"`, want: []int64{5}},
+		{header: `"abc", "7"`, want: []int64{7}},
+		{header: `, "3",`, want: []int64{3}},
+		{header: `"3", *`, want: nil},
+		{header: `W/"3"`, kind: apperr.PreconditionFailed},
+		{header: `"0", "abc"`, kind: apperr.PreconditionFailed},
+		{header: `3`, kind: apperr.Invalid},
+		{header: `"3", 4`, kind: apperr.Invalid},
+		{header: `"1","2","3","4","5","6","7","8","9","10","11","12","13","14","15","16","17"`, kind: apperr.Invalid},
+	} {
+		got, err := parseIfMatch(tt.header)
+		if tt.kind != 0 {
+			if !apperr.Is(err, tt.kind) {
+				t.Errorf("parseIfMatch(%q) error = %v, want kind %v", tt.header, err, tt.kind)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parseIfMatch(%q) = %v, %v; want %v", tt.header, got, err, tt.want)
+		}
+	}
+}
+
+// deleteService is an EmployeeService whose stored employee has version
+// current; only DeleteEmployee is implemented.
+type deleteService struct {
+	service.EmployeeService
+	current int64
+	tried   []int64
+}
+
+func (s *deleteService) DeleteEmployee(_ context.Context, _, version int64) error {
+	s.tried = append(s.tried, version)
+	if version != 0 && version != s.current {
+		return apperr.New(apperr.PreconditionFailed, "employee was modified by another request")
+	}
+	return nil
+}
+
+// TestIfMatchList checks that a write succeeds when any tag of an If-Match
+// list names the current version, and is a 412 when none does.
+func TestIfMatchList(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		header  string
+		require bool
+		status  int
+		tried   []int64
+	}{
+		{"current listed", `"3", "4"`, true, http.StatusNoContent, []int64{3, 4}},
+		{"current first", `"4", "3"`, true, http.StatusNoContent, []int64{4}},
+		{"none current", `"1", "2"`, true, http.StatusPreconditionFailed, []int64{1, 2}},
+		{"any", `*`, true, http.StatusNoContent, []int64{0}},
+		{"missing", "", true, http.StatusPreconditionRequired, nil},
+		{"missing, not required", "", false, http.StatusNoContent, []int64{0}},
+		{"malformed", `"4", 3`, true, http.StatusBadRequest, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &deleteService{current: 4}
+			h := NewEmployeeHandler(svc, WithRequireIfMatch(tt.require))
+			r := httptest.NewRequest("DELETE", "/api/v1/employees/1/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+			h.Delete(rec, r)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if !slices.Equal(svc.tried, tt.tried) {
+				t.Errorf("tried versions %v, want %v", svc.tried, tt.tried)
+			}
+		})
+	}
+}
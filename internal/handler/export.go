@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"emplopyee-app-go/internal/csvsafe"
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/redact"
+	"emplopyee-app-go/internal/xlsx"
+)
+
+// exportColumns are the columns of CSV and XLSX exports, in order. Members
+// the caller may not see are left empty.
+var exportColumns = []string{
+	"id", "first_name", "last_name", "email", "position", "department_id",
+	"manager_id", "version", "created_at", "updated_at", "deleted_at",
+}
+
+// exportFormats maps ?format= onto the media type and file extension.
+var exportFormats = map[string]struct{ contentType, ext string }{
+	"csv":    {"text/csv; charset=utf-8", "csv"},
+	"ndjson": {"application/x-ndjson", "ndjson"},
+	"xlsx":   {xlsx.ContentType, "xlsx"},
+}
+
+// exportWriter writes employees in one export format.
+type exportWriter interface {
+	Write(e *model.Employee) error
+	Close() error
+}
+
+// Export streams every employee matching the list filters (limit and cursor
+// aside) as a file download: ?format=csv (the default), ndjson or xlsx.
+// Fields are redacted as in every other response. Rows go out as they are
+// read from the database; if the export fails part way, the connection is
+// aborted so a cut-off file cannot be taken for a complete one.
+func (h *EmployeeHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	f, ok := exportFormats[format]
+	if !ok {
+		WriteProblem(w, r, badRequest(fmt.Sprintf("invalid format %q: want csv, ndjson or xlsx", format), nil))
+		return
+	}
+	q, err := parseEmployeeQuery(r)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+
+	var ew exportWriter
+	start := func() error {
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Time{}) // the request timeout still bounds an export
+		w.Header().Set("Content-Type", f.contentType)
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf(`attachment; filename="employees-%s.%s"`, time.Now().UTC().Format("2006-01-02"), f.ext))
+		w.WriteHeader(http.StatusOK)
+		var err error
+		ew, err = newExportWriter(w, r, h.policy, format)
+		return err
+	}
+	err = h.svc.ExportEmployees(r.Context(), q, func(e *model.Employee) error {
+		if ew == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return ew.Write(e)
+	})
+	if err != nil && ew == nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	if err == nil && ew == nil {
+		err = start()
+	}
+	if err == nil {
+		err = ew.Close()
+	}
+	if err != nil {
+		// the response has started, so all that is left is to cut it short
+		slog.ErrorContext(r.Context(), "export aborted", "format", format, "err", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func newExportWriter(w http.ResponseWriter, r *http.Request, policy redact.Policy, format string) (exportWriter, error) {
+	switch format {
+	case "ndjson":
+		return &ndjsonExport{enc: json.NewEncoder(w), view: func(e *model.Employee) interface{} { return policy.Employee(caller(r), e) }}, nil
+	case "xlsx":
+		xw, err := xlsx.NewWriter(w, "Employees")
+		if err != nil {
+			return nil, err
+		}
+		header := make([]interface{}, len(exportColumns))
+		for i, c := range exportColumns {
+			header[i] = c
+		}
+		return &xlsxExport{xw: xw, cells: recordCells(r, policy)}, xw.WriteRow(header...)
+	}
+	cw := csv.NewWriter(w)
+	return &csvExport{cw: cw, cells: recordCells(r, policy)}, cw.Write(exportColumns)
+}
+
+type ndjsonExport struct {
+	enc  *json.Encoder
+	view func(*model.Employee) interface{}
+}
+
+func (x *ndjsonExport) Write(e *model.Employee) error { return x.enc.Encode(x.view(e)) }
+
+func (x *ndjsonExport) Close() error { return nil }
+
+type csvExport struct {
+	cw    *csv.Writer
+	cells func(*model.Employee) []interface{}
+}
+
+func (x *csvExport) Write(e *model.Employee) error {
+	cells := x.cells(e)
+	rec := make([]string, len(cells))
+	for i, c := range cells {
+		switch c := c.(type) {
+		case nil:
+		case string:
+			rec[i] = csvsafe.Text(c)
+		default:
+			rec[i] = fmt.Sprint(c)
+		}
+	}
+	return x.cw.Write(rec)
+}
+
+func (x *csvExport) Close() error {
+	x.cw.Flush()
+	return x.cw.Error()
+}
+
+type xlsxExport struct {
+	xw    *xlsx.Writer
+	cells func(*model.Employee) []interface{}
+}
+
+func (x *xlsxExport) Write(e *model.Employee) error { return x.xw.WriteRow(x.cells(e)...) }
+
+func (x *xlsxExport) Close() error { return x.xw.Close() }
+
+// recordCells returns a function giving the exportColumns of an employee as
+// the caller of r may see them: integers as int64, text as string and
+// hidden or null members as nil.
+func recordCells(r *http.Request, policy redact.Policy) func(*model.Employee) []interface{} {
+	p := caller(r)
+	return func(e *model.Employee) []interface{} {
+		doc := policy.EmployeeObject(p, e)
+		cells := make([]interface{}, len(exportColumns))
+		for i, col := range exportColumns {
+			raw, ok := doc.Get(col)
+			if !ok {
+				continue
+			}
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			var v interface{}
+			if dec.Decode(&v) != nil {
+				continue
+			}
+			switch v := v.(type) {
+			case json.Number:
+				if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+					cells[i] = n
+				} else {
+					cells[i] = string(v)
+				}
+			case string:
+				cells[i] = v
+			}
+		}
+		return cells
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"testing"
+
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/redact"
+)
+
+// TestCSVExportEscapesFormulas checks that text a spreadsheet would evaluate
+// is exported as plain text.
+func TestCSVExportEscapesFormulas(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/employees/export", nil)
+	r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{Subject: "admin", Roles: []string{auth.RoleAdmin}}))
+	rec := httptest.NewRecorder()
+	ew, err := newExportWriter(rec, r, redact.Employees, "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ew.Write(&model.Employee{ID: 1, FirstName: "=1+1", LastName: "-Lee", Email: "ann@example.com", Position: "@SUM(A1:A9)"})
+	if err == nil {
+		err = ew.Write(&model.Employee{ID: 2, FirstName: "Ann", LastName: "\tTab", Email: "+ann@example.com"})
+	}
+	if err == nil {
+		err = ew.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := map[string]int{}
+	for i, c := range rows[0] {
+		col[c] = i
+	}
+	for _, tt := range []struct {
+		row          int
+		column, want string
+	}{
+		{1, "id", "1"},
+		{1, "first_name", "'=1+1"},
+		{1, "last_name", "'-Lee"},
+		{1, "email", "ann@example.com"},
+		{1, "position", "'@SUM(A1:A9)"},
+		{2, "first_name", "Ann"},
+		{2, "last_name", "'\tTab"},
+		{2, "email", "'+ann@example.com"},
+	} {
+		if got := rows[tt.row][col[tt.column]]; got != tt.want {
+			t.Errorf("row %d %s = %q, want %q", tt.row, tt.column, got, tt.want)
+		}
+	}
+}
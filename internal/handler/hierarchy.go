@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"emplopyee-app-go/internal/dao"
+)
+
+// Reports returns the reporting tree below an employee. ?depth=n limits it to
+// n levels (default 1, direct reports only).
+func (h *EmployeeHandler) Reports(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	depth := 1
+	if s := r.URL.Query().Get("depth"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > dao.MaxHierarchyDepth {
+			WriteProblem(w, r, badRequest(fmt.Sprintf("invalid depth %q: must be between 1 and %d", s, dao.MaxHierarchyDepth), err))
+			return
+		}
+		depth = n
+	}
+	out, err := h.svc.GetReports(r.Context(), id, depth)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": h.policy.Tree(caller(r), out)})
+}
+
+// Chain returns the employee's managers, nearest first.
+func (h *EmployeeHandler) Chain(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	out, err := h.svc.GetChain(r.Context(), id)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": h.policy.EmployeeList(caller(r), out)})
+}
+
+// OrgChart returns every live employee as a forest rooted at the employees
+// without a (live) manager.
+func (h *EmployeeHandler) OrgChart(w http.ResponseWriter, r *http.Request) {
+	out, err := h.svc.GetOrgChart(r.Context())
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": h.policy.Tree(caller(r), out)})
+}
+
+// ReassignReports moves all direct reports of an employee to the manager in
+// the body; {"manager_id": null} makes them top-level.
+func (h *EmployeeHandler) ReassignReports(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in struct {
+		ManagerID *int64 `json:"manager_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		WriteProblem(w, r, badRequest("request body is not valid JSON", err))
+		return
+	}
+	n, err := h.svc.ReassignReports(r.Context(), id, in.ManagerID)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"reassigned": n})
+}
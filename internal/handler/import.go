@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"emplopyee-app-go/internal/service"
+)
+
+// importRow is one row of a JSON import report.
+type importRow struct {
+	Line   int      `json:"line"`
+	Email  string   `json:"email,omitempty"`
+	Action string   `json:"action,omitempty"`
+	ID     int64    `json:"id,omitempty"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+// Import upserts employees from CSV sent as text/csv or as the "file" part of
+// multipart/form-data. dry_run and map (repeatable "column=field") come from
+// the query or, for multipart, from form parts before the file.
+//
+// The report is streamed as rows are imported: JSON with every row and a
+// summary by default, or with Accept: text/csv a CSV file of the failed rows
+// that can be fixed and imported again. Problems with the request itself,
+// such as a header without an email column, are returned as a problem.
+func (h *EmployeeHandler) Import(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dryRun := q.Get("dry_run")
+	pairs := q["map"]
+
+	var src io.Reader
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mt {
+	case "text/csv":
+		src = r.Body
+	case "multipart/form-data":
+		mr, err := r.MultipartReader()
+		if err != nil {
+			WriteProblem(w, r, badRequest("invalid multipart body", err))
+			return
+		}
+		for src == nil {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				WriteProblem(w, r, badRequest(`multipart body has no "file" part`, nil))
+				return
+			}
+			if err != nil {
+				WriteProblem(w, r, badRequest("invalid multipart body", err))
+				return
+			}
+			switch part.FormName() {
+			case "file":
+				src = part
+			case "dry_run", "map":
+				b, err := io.ReadAll(io.LimitReader(part, 4<<10))
+				if err != nil {
+					WriteProblem(w, r, badRequest("invalid multipart body", err))
+					return
+				}
+				if part.FormName() == "dry_run" {
+					dryRun = string(b)
+				} else {
+					pairs = append(pairs, string(b))
+				}
+			}
+		}
+	default:
+		WriteStatusProblem(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"import requires text/csv or multipart/form-data")
+		return
+	}
+
+	dry := false
+	if dryRun != "" {
+		b, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			WriteProblem(w, r, badRequest(fmt.Sprintf("invalid dry_run %q", dryRun), err))
+			return
+		}
+		dry = b
+	}
+	columns, err := service.ParseColumnMap(pairs)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	ir, err := service.NewImportReader(src, columns)
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+
+	// the server's read and write timeouts are sized for single records; the
+	// request timeout still bounds an import
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		h.importCSV(w, r, ir, dry)
+		return
+	}
+	h.importJSON(w, r, ir, dry)
+}
+
+// importJSON streams {"rows": [...], "summary": {...}}. When the import stops
+// after rows were sent, the summary is replaced by an "error" problem.
+func (h *EmployeeHandler) importJSON(w http.ResponseWriter, r *http.Request, ir *service.ImportReader, dryRun bool) {
+	enc := json.NewEncoder(w)
+	started := false
+	sum, err := h.svc.ImportEmployees(r.Context(), ir, dryRun, func(row *service.ImportRow) error {
+		sep := ","
+		if !started {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			sep = `{"rows":[`
+			started = true
+		}
+		item := importRow{Line: row.Line, Email: row.Email, Action: row.Action, ID: row.ID}
+		if row.Err != nil {
+			item.Error = newProblem(r, row.Err)
+		}
+		io.WriteString(w, sep)
+		return enc.Encode(item)
+	})
+	switch {
+	case !started && err != nil:
+		WriteProblem(w, r, err)
+		return
+	case !started:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `{"rows":[`)
+	}
+	if err != nil {
+		io.WriteString(w, `],"error":`)
+		enc.Encode(newProblem(r, err))
+	} else {
+		io.WriteString(w, `],"summary":`)
+		enc.Encode(sum)
+	}
+	io.WriteString(w, "}\n")
+}
+
+// importCSV streams the failed rows as a CSV attachment.
+func (h *EmployeeHandler) importCSV(w http.ResponseWriter, r *http.Request, ir *service.ImportReader, dryRun bool) {
+	ew := service.NewImportErrorWriter(w, ir.Header())
+	started := false
+	start := func() {
+		if !started {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="import-errors.csv"`)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+	}
+	_, err := h.svc.ImportEmployees(r.Context(), ir, dryRun, func(row *service.ImportRow) error {
+		if row.Err == nil {
+			return nil
+		}
+		start()
+		return ew.Write(row)
+	})
+	if err != nil && !started {
+		WriteProblem(w, r, err)
+		return
+	}
+	start()
+	if err != nil {
+		ew.Abort(err)
+	}
+	ew.Close()
+}
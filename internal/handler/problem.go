@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ProblemContentType is the media type of RFC 7807 error bodies.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code and RequestID are
+// extension members; Errors is only set for validation failures,
+// Permission names what a 403 caller is missing and Index is the failed
+// operation of an atomic batch.
+type Problem struct {
+	Type       string               `json:"type"`
+	Title      string               `json:"title"`
+	Status     int                  `json:"status"`
+	Detail     string               `json:"detail,omitempty"`
+	Instance   string               `json:"instance,omitempty"`
+	Code       string               `json:"code"`
+	RequestID  string               `json:"request_id,omitempty"`
+	Errors     []service.FieldError `json:"errors,omitempty"`
+	Permission service.Permission   `json:"permission,omitempty"`
+	Index      *int                 `json:"index,omitempty"`
+}
+
+var kindStatus = map[apperr.Kind]int{
+	apperr.Internal:           http.StatusInternalServerError,
+	apperr.Invalid:            http.StatusBadRequest,
+	apperr.Validation:         http.StatusUnprocessableEntity,
+	apperr.NotFound:           http.StatusNotFound,
+	apperr.Conflict:           http.StatusConflict,
+	apperr.Unavailable:        http.StatusServiceUnavailable,
+	apperr.Timeout:            http.StatusGatewayTimeout,
+	apperr.PreconditionFailed: http.StatusPreconditionFailed,
+	apperr.Unauthenticated:    http.StatusUnauthorized,
+	apperr.Forbidden:          http.StatusForbidden,
+}
+
+// StatusFor maps an error onto its HTTP status code.
+func StatusFor(err error) int {
+	return kindStatus[apperr.KindOf(err)]
+}
+
+// WriteProblem renders err as application/problem+json. Only the apperr
+// client-safe message is exposed; the cause stays server-side and is logged,
+// at error level for server errors and debug level for the caller's.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := newProblem(r, err)
+	level := slog.LevelDebug
+	if p.Status >= 500 {
+		level = slog.LevelError
+	}
+	slog.Log(r.Context(), level, "request failed", "status", p.Status, "code", p.Code, "err", err)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// newProblem describes err for the client.
+func newProblem(r *http.Request, err error) *Problem {
+	kind := apperr.KindOf(err)
+	status := kindStatus[kind]
+	p := &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    apperr.Message(err),
+		Instance:  r.URL.Path,
+		Code:      kind.String(),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		p.Errors = verr.Errors
+	}
+	var perr *service.PermissionError
+	if errors.As(err, &perr) {
+		p.Permission = perr.Permission
+	}
+	var berr *service.BatchError
+	if errors.As(err, &berr) {
+		p.Index = &berr.Index
+	}
+	return p
+}
+
+// badRequest classifies a client input error (bad JSON, bad parameter).
+func badRequest(msg string, err error) error {
+	return apperr.Wrap(apperr.Invalid, msg, err)
+}
+
+// pathID reads the {id} parameter of r. Route patterns only admit digits, but
+// an id that overflows int64 or is 0 still gets through them; such a request
+// gets a 400 and ok is false.
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	s := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		WriteProblem(w, r, badRequest(fmt.Sprintf("invalid id %q: must be a positive integer", s), err))
+		return 0, false
+	}
+	return id, true
+}
+
+// NotFound and MethodNotAllowed render the router's own errors as problems.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	WriteProblem(w, r, apperr.New(apperr.NotFound, "no route matches "+r.URL.Path))
+}
+
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	WriteStatusProblem(w, r, http.StatusMethodNotAllowed, "method_not_allowed",
+		r.Method+" is not supported on "+r.URL.Path)
+}
+
+// WriteStatusProblem renders a protocol-level problem that has no apperr kind
+// (405, 415, ...).
+func WriteStatusProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
+
+// WriteInvalidRequest renders a 400 problem listing every field of the request
+// that is malformed, before any handler has seen it.
+func WriteInvalidRequest(w http.ResponseWriter, r *http.Request, detail string, errs []service.FieldError) {
+	p := newProblem(r, apperr.New(apperr.Invalid, detail))
+	p.Errors = errs
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
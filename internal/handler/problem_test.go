@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestPathID checks that ids the route patterns let through but no record
+// can have are a 400.
+func TestPathID(t *testing.T) {
+	for _, tt := range []struct {
+		id     string
+		want   int64
+		status int
+	}{
+		{"1", 1, http.StatusOK},
+		{"42", 42, http.StatusOK},
+		{"0", 0, http.StatusBadRequest},
+		{"99999999999999999999", 0, http.StatusBadRequest},
+		{"", 0, http.StatusBadRequest},
+	} {
+		r := httptest.NewRequest("GET", "/api/v1/employees/"+tt.id+"/", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", tt.id)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		id, ok := pathID(rec, r)
+		if ok != (tt.status == http.StatusOK) || id != tt.want || rec.Code != tt.status {
+			t.Errorf("pathID(%q) = %d, %v with status %d; want %d with status %d", tt.id, id, ok, rec.Code, tt.want, tt.status)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"emplopyee-app-go/internal/model"
+)
+
+// searchHit is one result of GET /api/v1/employees/search.
+type searchHit struct {
+	Employee   interface{}       `json:"employee"`
+	Rank       float64           `json:"rank"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// highlightMarks turns the DAO's highlight markers into HTML once the text
+// around them is escaped.
+var highlightMarks = strings.NewReplacer(model.HighlightStart, "<mark>", model.HighlightStop, "</mark>")
+
+// Search ranks live employees against q, every word of which must start, or
+// nearly start, a word of their names, email or position:
+//
+//	GET /api/v1/employees/search?q=ann%20eng&limit=10
+//
+// Highlights map each matching member to its HTML-escaped text with the
+// matched words in <mark>; members the caller may not see in full are left
+// out.
+func (h *EmployeeHandler) Search(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	text := v.Get("q")
+	if strings.TrimSpace(text) == "" {
+		WriteProblem(w, r, badRequest("query parameter q is required", nil))
+		return
+	}
+	limit := 0
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			WriteProblem(w, r, badRequest(fmt.Sprintf("invalid limit %q", s), err))
+			return
+		}
+		limit = n
+	}
+
+	hits, err := h.svc.SearchEmployees(r.Context(), &model.SearchQuery{Text: text, Limit: limit})
+	if err != nil {
+		WriteProblem(w, r, err)
+		return
+	}
+	items := make([]searchHit, len(hits))
+	for i, hit := range hits {
+		hl := make(map[string]string, len(hit.Highlights))
+		for name, s := range hit.Highlights {
+			if h.policy.Shows(caller(r), hit.Employee.ID, name) {
+				hl[name] = highlightMarks.Replace(html.EscapeString(s))
+			}
+		}
+		items[i] = searchHit{Employee: h.view(r, hit.Employee), Rank: hit.Rank, Highlights: hl}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
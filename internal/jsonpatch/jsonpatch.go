@@ -0,0 +1,403 @@
+// Package jsonpatch applies RFC 7396 JSON Merge Patch and RFC 6902 JSON Patch
+// documents to JSON values. It works on the generic representation produced by
+// encoding/json (with UseNumber) so it stays independent of any model type.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned for patch documents that are not well formed.
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// ErrTestFailed is returned when a JSON Patch "test" operation does not match.
+	ErrTestFailed = errors.New("patch test operation failed")
+	// ErrPathNotFound is returned when an operation addresses a missing location.
+	ErrPathNotFound = errors.New("patch path not found")
+)
+
+// MergePatch applies an RFC 7396 merge patch to doc and returns the result.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]interface{})
+	if !ok {
+		tm = map[string]interface{}{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergeValue(tm[k], v)
+	}
+	return tm
+}
+
+// Operation is a single RFC 6902 operation. HasValue distinguishes an explicit
+// JSON null value from a missing "value" member.
+type Operation struct {
+	Op       string
+	Path     string
+	From     string
+	Value    json.RawMessage
+	HasValue bool
+}
+
+// Apply applies an RFC 6902 JSON Patch to doc. Operations are applied in order
+// and the whole patch fails if any one of them does.
+func Apply(doc, patch []byte) ([]byte, error) {
+	ops, err := parseOperations(patch)
+	if err != nil {
+		return nil, err
+	}
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		root, err = applyOp(root, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+func parseOperations(patch []byte) ([]Operation, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	ops := make([]Operation, len(raw))
+	for i, m := range raw {
+		str := func(key string, required bool) (string, error) {
+			v, ok := m[key]
+			if !ok {
+				if required {
+					return "", fmt.Errorf("%w: operation %d is missing %q", ErrInvalidPatch, i, key)
+				}
+				return "", nil
+			}
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				return "", fmt.Errorf("%w: operation %d: %q must be a string", ErrInvalidPatch, i, key)
+			}
+			return s, nil
+		}
+		var err error
+		if ops[i].Op, err = str("op", true); err != nil {
+			return nil, err
+		}
+		if ops[i].Path, err = str("path", true); err != nil {
+			return nil, err
+		}
+		needFrom := ops[i].Op == "move" || ops[i].Op == "copy"
+		if ops[i].From, err = str("from", needFrom); err != nil {
+			return nil, err
+		}
+		ops[i].Value, ops[i].HasValue = m["value"]
+	}
+	return ops, nil
+}
+
+func applyOp(root interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	value := func() (interface{}, error) {
+		if !op.HasValue {
+			return nil, fmt.Errorf("%w: %q requires a value", ErrInvalidPatch, op.Op)
+		}
+		return decode(op.Value)
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, v)
+	case "remove":
+		root, _, err := remove(root, path)
+		return root, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		root, _, err := remove(root, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, v)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" && isPrefix(from, path) && len(from) < len(path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		v, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if root, _, err = remove(root, from); err != nil {
+				return nil, err
+			}
+		} else {
+			v = deepCopy(v)
+		}
+		return add(root, path, v)
+	case "test":
+		want, err := value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(got, want) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, p)
+	}
+	parts := strings.Split(p[1:], "/")
+	for i, s := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, tok := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[tok]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			node = v
+		case []interface{}:
+			i, err := index(tok, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return node, nil
+}
+
+// add inserts v at path, returning the (possibly new) root.
+func add(root interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[last] = v
+		return root, nil
+	case []interface{}:
+		i := len(p)
+		if last != "-" {
+			if i, err = index(last, len(p)); err != nil {
+				return nil, err
+			}
+		}
+		p = append(p, nil)
+		copy(p[i+1:], p[i:])
+		p[i] = v
+		return setAt(root, path[:len(path)-1], p)
+	}
+	return nil, ErrPathNotFound
+}
+
+// remove deletes the value at path and returns the new root and the removed value.
+func remove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, root, nil
+	}
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		v, ok := p[last]
+		if !ok {
+			return nil, nil, ErrPathNotFound
+		}
+		delete(p, last)
+		return root, v, nil
+	case []interface{}:
+		i, err := index(last, len(p)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := p[i]
+		p = append(p[:i:i], p[i+1:]...)
+		root, err = setAt(root, path[:len(path)-1], p)
+		return root, v, err
+	}
+	return nil, nil, ErrPathNotFound
+}
+
+// setAt replaces the value at path; needed because slices may be reallocated.
+func setAt(root interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[last] = v
+	case []interface{}:
+		i, err := index(last, len(p)-1)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = v
+	}
+	return root, nil
+}
+
+func index(tok string, max int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("%w: bad array index %q", ErrInvalidPatch, tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad array index %q", ErrInvalidPatch, tok)
+	}
+	if i < 0 || i > max {
+		return 0, ErrPathNotFound
+	}
+	return i, nil
+}
+
+func decode(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(x))
+		for i, e := range x {
+			s[i] = deepCopy(e)
+		}
+		return s
+	}
+	return v
+}
+
+// equal implements the RFC 6902 notion of JSON value equality; numbers are
+// compared by value, not by their textual form.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, err1 := x.Float64()
+		fy, err2 := y.Float64()
+		return err1 == nil && err2 == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
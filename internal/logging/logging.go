@@ -0,0 +1,131 @@
+// Package logging configures log/slog for the server: JSON or text output,
+// a level that can be changed while the server runs, and attributes carried
+// in a context (request ID, route, principal, trace ID) that are added to
+// every record logged with that context.
+//
+// Code logs through the slog package functions that take a context
+// (slog.InfoContext, slog.ErrorContext, ...) so the request's attributes
+// follow the call from the handler into services and the DAO.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// New returns a logger writing format ("json" or "text") to w. Records below
+// level are dropped; pass a *slog.LevelVar to change it later.
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch format {
+	case "json", "":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want json or text)", format)
+	}
+	return slog.New(NewContextHandler(h)), nil
+}
+
+// ParseLevel parses a level name (debug, info, warn, error), in any case,
+// optionally with an offset such as "info+2".
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return l, nil
+}
+
+type attrsKey struct{}
+
+// With returns a copy of ctx whose records also carry attrs, after those ctx
+// already has.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev := Attrs(ctx)
+	all := make([]slog.Attr, 0, len(prev)+len(attrs))
+	all = append(append(all, prev...), attrs...)
+	return context.WithValue(ctx, attrsKey{}, all)
+}
+
+// Attrs returns the attributes added to ctx by With.
+func Attrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the attributes of a record's context to the record
+// before passing it on.
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps h so that records logged with a context carry the
+// attributes With stored in it.
+func NewContextHandler(h slog.Handler) slog.Handler {
+	return contextHandler{h}
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := Attrs(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// levelBody is the request and response body of LevelHandler.
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler reads and changes level over HTTP:
+//
+//	GET /  - {"level":"INFO"}
+//	PUT /  - set it from {"level":"debug"}, answering with the new level
+func LevelHandler(level *slog.LevelVar) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			var in levelBody
+			if err := json.NewDecoder(io.LimitReader(r.Body, 1024)).Decode(&in); err != nil {
+				http.Error(w, "request body is not valid JSON", http.StatusBadRequest)
+				return
+			}
+			l, err := ParseLevel(in.Level)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if old := level.Level(); old != l {
+				level.Set(l)
+				slog.InfoContext(r.Context(), "log level changed", "from", old.String(), "to", l.String())
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelBody{Level: level.Level().String()})
+	})
+}
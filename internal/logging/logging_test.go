@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := With(context.Background(), slog.String("request_id", "r1"))
+	ctx = With(ctx, slog.String("principal", "alice"))
+	logger.With("component", "test").InfoContext(ctx, "hello", "n", 1)
+	logger.DebugContext(ctx, "dropped")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d records, want 1:\n%s", len(lines), buf.String())
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]interface{}{
+		"msg": "hello", "n": float64(1), "component": "test", "request_id": "r1", "principal": "alice",
+	} {
+		if rec[k] != want {
+			t.Errorf("%s = %v, want %v", k, rec[k], want)
+		}
+	}
+}
+
+func TestNewRejectsUnknownFormat(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", slog.LevelInfo); err == nil {
+		t.Error("New accepted format xml")
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	level := new(slog.LevelVar)
+	h := LevelHandler(level)
+
+	tests := []struct {
+		method, body string
+		status       int
+		want         slog.Level
+	}{
+		{"GET", "", http.StatusOK, slog.LevelInfo},
+		{"PUT", `{"level":"debug"}`, http.StatusOK, slog.LevelDebug},
+		{"PUT", `{"level":"WARN"}`, http.StatusOK, slog.LevelWarn},
+		{"PUT", `{"level":"loud"}`, http.StatusBadRequest, slog.LevelWarn},
+		{"PUT", `level=debug`, http.StatusBadRequest, slog.LevelWarn},
+		{"POST", `{"level":"debug"}`, http.StatusMethodNotAllowed, slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/log/level", strings.NewReader(tt.body)))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.body, rec.Code, tt.status)
+		}
+		if level.Level() != tt.want {
+			t.Errorf("%s %s: level %s, want %s", tt.method, tt.body, level.Level(), tt.want)
+		}
+		if rec.Code == http.StatusOK && !strings.Contains(rec.Body.String(), `"level":"`+tt.want.String()+`"`) {
+			t.Errorf("%s %s: body %s", tt.method, tt.body, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RegisterDBStats exports the connection pool statistics of db, read on each
+// scrape.
+func RegisterDBStats(r *Registry, db *sql.DB) {
+	stat := func(f func(sql.DBStats) float64) func(context.Context) ([]Sample, error) {
+		return func(context.Context) ([]Sample, error) {
+			return []Sample{{Value: f(db.Stats())}}, nil
+		}
+	}
+	r.GaugeFunc("db_max_open_connections", "Maximum number of open connections to the database.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	r.GaugeFunc("db_open_connections", "Established connections, in use or idle.",
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	r.GaugeFunc("db_in_use_connections", "Connections currently in use.",
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	r.GaugeFunc("db_idle_connections", "Idle connections.",
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	r.CounterFunc("db_wait_count_total", "Connections waited for because the pool was exhausted.",
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	r.CounterFunc("db_wait_duration_seconds_total", "Time spent waiting for a connection.",
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	r.CounterFunc("db_max_idle_closed_total", "Connections closed because the idle pool was full.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	r.CounterFunc("db_max_lifetime_closed_total", "Connections closed because they reached their maximum lifetime.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
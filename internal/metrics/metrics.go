@@ -0,0 +1,321 @@
+// Package metrics is a small, dependency-free implementation of the
+// Prometheus text exposition format (version 0.0.4): counters, gauges and
+// histograms with labels, plus families computed at scrape time.
+//
+// It covers what the service exports and nothing more; there are no
+// summaries, exemplars or OpenMetrics output.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 10s.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Label is one name="value" pair of a sample.
+type Label struct {
+	Name, Value string
+}
+
+// Sample is one value of a family computed at scrape time.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Registry holds metric families and writes them for a scrape.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+type family interface {
+	write(ctx context.Context, w *bufio.Writer) error
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]family{}}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.families[name]; dup {
+		panic("metrics: " + name + " registered twice")
+	}
+	r.families[name] = f
+}
+
+// Counter registers a counter family with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, labels)}
+	r.register(name, c)
+	return c
+}
+
+// Gauge registers a gauge family with the given label names.
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: newVec(name, help, labels)}
+	r.register(name, g)
+	return g
+}
+
+// Histogram registers a histogram family with the given upper bounds, in
+// ascending order, and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{vec: newVec(name, help, labels), buckets: buckets}
+	r.register(name, h)
+	return h
+}
+
+// GaugeFunc registers a gauge family whose samples fn computes on each scrape,
+// under the scrape's context. A family whose fn fails is left out of that
+// scrape and the error logged.
+func (r *Registry) GaugeFunc(name, help string, fn func(context.Context) ([]Sample, error)) {
+	r.register(name, &funcFamily{name: name, help: help, typ: "gauge", fn: fn})
+}
+
+// CounterFunc is GaugeFunc for values that only go up, such as totals kept
+// by another package.
+func (r *Registry) CounterFunc(name, help string, fn func(context.Context) ([]Sample, error)) {
+	r.register(name, &funcFamily{name: name, help: help, typ: "counter", fn: fn})
+}
+
+// ServeHTTP writes every family, sorted by name.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	fams := make([]family, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		fams[i] = r.families[name]
+	}
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", ContentType)
+	bw := bufio.NewWriter(w)
+	for i, f := range fams {
+		if err := f.write(req.Context(), bw); err != nil {
+			slog.ErrorContext(req.Context(), "metrics: collect failed", "family", names[i], "err", err)
+		}
+	}
+	bw.Flush()
+}
+
+// vec is the labelled state shared by counters, gauges and histograms.
+type vec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64  // counters and gauges
+	counts []uint64 // histograms: per bucket, not cumulative
+	sum    float64  // histograms
+	count  uint64   // histograms
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{name: name, help: help, labels: labels, series: map[string]*series{}}
+}
+
+// get returns the series for values, creating it; the caller holds v.mu.
+func (v *vec) get(values []string) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// snapshot copies the series, sorted by label values, for writing.
+func (v *vec) snapshot() []series {
+	v.mu.Lock()
+	out := make([]series, 0, len(v.series))
+	for _, s := range v.series {
+		c := *s
+		c.counts = append([]uint64(nil), s.counts...)
+		out = append(out, c)
+	}
+	v.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].values, "\xff") < strings.Join(out[j].values, "\xff")
+	})
+	return out
+}
+
+func (v *vec) pairs(values []string) []Label {
+	out := make([]Label, len(values))
+	for i, val := range values {
+		out[i] = Label{v.labels[i], val}
+	}
+	return out
+}
+
+// CounterVec is a family of counters.
+type CounterVec struct{ vec }
+
+// Inc adds one to the counter with the given label values.
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+// Add adds d, which must not be negative, to the counter with the given
+// label values.
+func (c *CounterVec) Add(d float64, values ...string) {
+	if d < 0 {
+		panic("metrics: counter " + c.name + " decreased")
+	}
+	c.mu.Lock()
+	c.get(values).value += d
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(_ context.Context, w *bufio.Writer) error {
+	header(w, c.name, c.help, "counter")
+	for _, s := range c.snapshot() {
+		sample(w, c.name, c.pairs(s.values), s.value)
+	}
+	return nil
+}
+
+// GaugeVec is a family of gauges.
+type GaugeVec struct{ vec }
+
+// Set sets the gauge with the given label values.
+func (g *GaugeVec) Set(x float64, values ...string) {
+	g.mu.Lock()
+	g.get(values).value = x
+	g.mu.Unlock()
+}
+
+// Add adds d, which may be negative, to the gauge with the given label values.
+func (g *GaugeVec) Add(d float64, values ...string) {
+	g.mu.Lock()
+	g.get(values).value += d
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(_ context.Context, w *bufio.Writer) error {
+	header(w, g.name, g.help, "gauge")
+	for _, s := range g.snapshot() {
+		sample(w, g.name, g.pairs(s.values), s.value)
+	}
+	return nil
+}
+
+// HistogramVec is a family of histograms.
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// Observe records x in the histogram with the given label values.
+func (h *HistogramVec) Observe(x float64, values ...string) {
+	h.mu.Lock()
+	s := h.get(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	if i := sort.SearchFloat64s(h.buckets, x); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += x
+	s.count++
+	h.mu.Unlock()
+}
+
+func (h *HistogramVec) write(_ context.Context, w *bufio.Writer) error {
+	header(w, h.name, h.help, "histogram")
+	for _, s := range h.snapshot() {
+		labels := h.pairs(s.values)
+		var cum uint64
+		for i, le := range h.buckets {
+			cum += s.counts[i]
+			sample(w, h.name+"_bucket", append(labels, Label{"le", formatFloat(le)}), float64(cum))
+		}
+		sample(w, h.name+"_bucket", append(labels, Label{"le", "+Inf"}), float64(s.count))
+		sample(w, h.name+"_sum", labels, s.sum)
+		sample(w, h.name+"_count", labels, float64(s.count))
+	}
+	return nil
+}
+
+type funcFamily struct {
+	name, help, typ string
+	fn              func(context.Context) ([]Sample, error)
+}
+
+func (f *funcFamily) write(ctx context.Context, w *bufio.Writer) error {
+	samples, err := f.fn(ctx)
+	if err != nil {
+		return err
+	}
+	header(w, f.name, f.help, f.typ)
+	for _, s := range samples {
+		sample(w, f.name, s.Labels, s.Value)
+	}
+	return nil
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func header(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, helpEscaper.Replace(help), name, typ)
+}
+
+func sample(w *bufio.Writer, name string, labels []Label, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, l.Name, labelEscaper.Replace(l.Value))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(r *Registry) string {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("requests_total", "Requests.\nSecond line.", "path")
+	c.Inc(`/a"b\c`)
+	c.Add(2, "/x")
+	g := r.Gauge("temperature", "Degrees.")
+	g.Set(21.5)
+	g.Add(-1.5)
+	h := r.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "get")
+	h.Observe(0.1, "get")
+	h.Observe(3, "get")
+	r.GaugeFunc("computed", "Computed.", func(context.Context) ([]Sample, error) {
+		return []Sample{{Labels: []Label{{"k", "v"}}, Value: 7}}, nil
+	})
+	r.GaugeFunc("broken", "Fails.", func(context.Context) ([]Sample, error) {
+		return nil, errors.New("no database")
+	})
+
+	want := `# HELP computed Computed.
+# TYPE computed gauge
+computed{k="v"} 7
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="get",le="0.1"} 2
+latency_seconds_bucket{op="get",le="1"} 2
+latency_seconds_bucket{op="get",le="+Inf"} 3
+latency_seconds_sum{op="get"} 3.15
+latency_seconds_count{op="get"} 3
+# HELP requests_total Requests.\nSecond line.
+# TYPE requests_total counter
+requests_total{path="/a\"b\\c"} 1
+requests_total{path="/x"} 2
+# HELP temperature Degrees.
+# TYPE temperature gauge
+temperature 20
+`
+	if got := scrape(r); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLabelCount(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("c_total", "C.", "a", "b")
+	defer func() {
+		if p := recover(); p == nil || !strings.Contains(p.(string), "takes 2 label values") {
+			t.Errorf("expected a panic for the wrong number of label values, got %v", p)
+		}
+	}()
+	c.Inc("only-one")
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// APIKey is a stored credential for machine clients. Only the SHA-256 hash of
+// the key is kept; Prefix is the first few characters of the plaintext so a
+// key can be recognised in listings. Roles is a comma-separated list.
+type APIKey struct {
+	ID        int64      `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	Prefix    string     `db:"prefix" json:"prefix"`
+	KeyHash   string     `db:"key_hash" json:"-"`
+	Roles     string     `db:"roles" json:"roles"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
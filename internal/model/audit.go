@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Audit operations, one per kind of employee write.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditEntry records one write to one employee. Changes maps each JSON member
+// of the employee that changed to {"from": old, "to": new}; created records
+// have null "from" values and purged ones null "to" values.
+//
+// Entries form a hash chain: Hash covers the entry's content and PrevHash,
+// the Hash of the entry before it, so editing or removing a past entry is
+// detectable.
+type AuditEntry struct {
+	ID         int64     `db:"id" json:"id"`
+	EmployeeID int64     `db:"employee_id" json:"employee_id"`
+	Operation  string    `db:"operation" json:"operation"`
+	Actor      string    `db:"actor" json:"actor"`
+	RequestID  string    `db:"request_id" json:"request_id,omitempty"`
+	Changes    JSONText  `db:"changes" json:"changes"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	PrevHash   string    `db:"prev_hash" json:"prev_hash"`
+	Hash       string    `db:"hash" json:"hash"`
+}
+
+// AuditQuery filters an audit listing. Entries are returned oldest first;
+// Cursor is the NextCursor of the previous page.
+type AuditQuery struct {
+	Limit  int
+	Cursor string
+
+	EmployeeID int64     // 0 means any employee
+	Actor      string    // exact match
+	Since      time.Time // inclusive; zero means no lower bound
+	Until      time.Time // exclusive; zero means no upper bound
+}
+
+// AuditPage is one page of an audit listing.
+type AuditPage struct {
+	Items      []*AuditEntry `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// JSONText is a JSON document stored in a TEXT column. It is a string so every
+// driver scans and binds it as text, and it encodes as the JSON it holds.
+type JSONText string
+
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Department groups employees; an employee belongs to at most one.
+type Department struct {
+	ID          int64     `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Headcount is the number of live employees in a department; DepartmentID is
+// nil for employees outside any department, with an empty Department name.
+type Headcount struct {
+	DepartmentID *int64 `db:"department_id" json:"department_id"`
+	Department   string `db:"department" json:"department"`
+	Employees    int64  `db:"employees" json:"employees"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// IdempotencyKey is the stored outcome of a request sent with an
+// Idempotency-Key header. Scope is the subject of the caller that sent it and
+// Fingerprint a hash of the request, so a key reused for a different request
+// can be told apart from a retry. Status is 0 until the first request has
+// been answered; ResponseHeaders is a JSON object of the replayed headers.
+// LockedUntil is the lease of the request serving a pending key.
+type IdempotencyKey struct {
+	Scope           string     `db:"scope"`
+	Key             string     `db:"idempotency_key"`
+	Fingerprint     string     `db:"fingerprint"`
+	Status          int        `db:"status"`
+	ResponseHeaders string     `db:"response_headers"`
+	ResponseBody    []byte     `db:"response_body"`
+	CreatedAt       time.Time  `db:"created_at"`
+	ExpiresAt       time.Time  `db:"expires_at"`
+	LockedUntil     *time.Time `db:"locked_until"`
+}
+
+// Pending reports whether the first request with the key is still being
+// served.
+func (k *IdempotencyKey) Pending() bool { return k.Status == 0 }
+
+// Abandoned reports whether the key is pending but its lease ran out at now:
+// the request serving it died without an answer.
+func (k *IdempotencyKey) Abandoned(now time.Time) bool {
+	return k.Pending() && (k.LockedUntil == nil || !k.LockedUntil.After(now))
+}
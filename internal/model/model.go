@@ -3,11 +3,25 @@ package model
 import "time"
 
 type Employee struct {
-	ID        int64     `db:"id" json:"id"`
-	FirstName string    `db:"first_name" json:"first_name"`
-	LastName  string    `db:"last_name" json:"last_name"`
-	Email     string    `db:"email" json:"email"`
-	Position  string    `db:"position" json:"position"`
+	ID        int64  `db:"id" json:"id"`
+	FirstName string `db:"first_name" json:"first_name"`
+	LastName  string `db:"last_name" json:"last_name"`
+	Email     string `db:"email" json:"email"`
+	Position  string `db:"position" json:"position"`
+	// DepartmentID is the employee's department, nil when unassigned.
+	DepartmentID *int64 `db:"department_id" json:"department_id"`
+	// ManagerID is the employee's direct manager, nil at the top of the org.
+	ManagerID *int64 `db:"manager_id" json:"manager_id"`
+	// Version is incremented on every write and served as the ETag.
+	Version   int64     `db:"version" json:"version"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// DeletedAt is set when the employee is soft-deleted (moved to the trash).
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+}
+
+// OrgNode is one employee in an org chart together with their direct reports.
+type OrgNode struct {
+	*Employee
+	Reports []*OrgNode `json:"reports"`
 }
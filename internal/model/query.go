@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// SortField is a single column in a list ordering, e.g. "-created_at" parses
+// to {Field: "created_at", Desc: true}.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// DeletedFilter selects which rows a listing returns with respect to soft
+// deletion.
+type DeletedFilter int
+
+const (
+	ExcludeDeleted DeletedFilter = iota // live employees only (the default)
+	IncludeDeleted                      // live and soft-deleted employees
+	OnlyDeleted                         // the trash
+)
+
+// EmployeeQuery carries the pagination, ordering and filter parameters of an
+// employee listing from the handler down to the DAO.
+//
+// Pagination is keyset based: Cursor is the opaque value returned as
+// NextCursor of the previous page and encodes the sort key of its last row.
+type EmployeeQuery struct {
+	Limit  int
+	Cursor string
+	Sort   []SortField
+
+	Position     string // exact match
+	Email        string // exact match, case-insensitive
+	Name         string // substring of first or last name, case-insensitive
+	NamePrefix   string // prefix of first or last name, case-insensitive
+	DepartmentID int64  // exact match; 0 means any department
+
+	Deleted DeletedFilter
+
+	// AsOf, when set, lists employees as they were at that instant instead of
+	// their current state.
+	AsOf time.Time
+}
+
+// EmployeePage is one page of an employee listing.
+type EmployeePage struct {
+	Items      []*Employee `json:"items"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// SearchQuery carries a full-text employee search from the handler down to
+// the DAO. Fields names the columns Text is matched against; empty means all
+// of SearchFields.
+type SearchQuery struct {
+	Text   string
+	Limit  int
+	Fields []string
+}
+
+// SearchFields are the columns a full-text employee search can match.
+var SearchFields = []string{"first_name", "last_name", "email", "position"}
+
+// Highlight markers delimit the matched words in SearchHit.Highlights.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// SearchHit is one result of a full-text employee search. Rank orders the
+// hits of one search, higher first, and means nothing across searches.
+// Highlights maps each column that matched to its text with the matched words
+// between HighlightStart and HighlightStop.
+type SearchHit struct {
+	Employee   *Employee
+	Rank       float64
+	Highlights map[string]string
+}
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestReferences checks that every $ref in the document points at something.
+func TestReferences(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal(Document(), &doc); err != nil {
+		t.Fatalf("parse document: %v", err)
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := x["$ref"].(string); ok {
+				if _, found := lookup(doc, ref); !found {
+					t.Errorf("unresolved $ref %q", ref)
+				}
+			}
+			for _, c := range x {
+				walk(c)
+			}
+		case []interface{}:
+			for _, c := range x {
+				walk(c)
+			}
+		}
+	}
+	walk(doc)
+
+	ops, err := Operations()
+	if err != nil || len(ops) == 0 {
+		t.Fatalf("Operations: %v, %v", ops, err)
+	}
+}
+
+func lookup(doc interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	v := doc
+	for _, k := range strings.Split(ref[2:], "/") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
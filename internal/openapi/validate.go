@@ -0,0 +1,535 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation is one way a request or response differs from the document.
+// Field locates it: "path.id", "query.limit", "header.If-Match", "body" for
+// the whole body or "body.employee.email", "body[2].op" within it.
+type Violation struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (v Violation) String() string { return v.Field + ": " + v.Message }
+
+// Validator checks requests and responses against an OpenAPI 3 document. It
+// understands the parts of JSON Schema the document uses: type, enum, const,
+// format date-time, minimum, maximum, minLength, maxLength, pattern, items,
+// minItems, maxItems, properties, required, additionalProperties, allOf and
+// local $refs.
+type Validator struct {
+	root map[string]interface{}
+	ops  map[Operation]*operation
+}
+
+type operation struct {
+	params    []map[string]interface{}
+	body      map[string]interface{} // the resolved requestBody, or nil
+	responses map[string]interface{}
+}
+
+// NewValidator parses doc, an OpenAPI 3 document in JSON.
+func NewValidator(doc []byte) (*Validator, error) {
+	v := &Validator{ops: map[Operation]*operation{}}
+	if err := json.Unmarshal(doc, &v.root); err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+	paths, _ := v.root["paths"].(map[string]interface{})
+	for path, raw := range paths {
+		item := v.resolve(raw)
+		shared, _ := item["parameters"].([]interface{})
+		for _, m := range methods {
+			op, ok := item[m].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			o := &operation{}
+			own, _ := op["parameters"].([]interface{})
+			// an operation's parameter overrides a path-level one with the
+			// same name and location
+			seen := map[string]bool{}
+			for _, p := range append(append([]interface{}{}, own...), shared...) {
+				param := v.resolve(p)
+				key := fmt.Sprint(param["in"], ":", param["name"])
+				if param == nil || seen[key] {
+					continue
+				}
+				seen[key] = true
+				o.params = append(o.params, param)
+			}
+			if rb, ok := op["requestBody"]; ok {
+				o.body = v.resolve(rb)
+			}
+			o.responses, _ = op["responses"].(map[string]interface{})
+			v.ops[Operation{Method: strings.ToUpper(m), Path: path}] = o
+		}
+	}
+	return v, nil
+}
+
+// PathTemplate turns a chi route pattern into the path of its operation:
+// /api/v1/employees/{id:[0-9]+}/ becomes /api/v1/employees/{id}/.
+func PathTemplate(route string) string {
+	return routeParam.ReplaceAllString(route, "{$1}")
+}
+
+var routeParam = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+
+// HasOperation reports whether the document describes method on path.
+func (v *Validator) HasOperation(method, path string) bool {
+	_, ok := v.ops[Operation{Method: method, Path: path}]
+	return ok
+}
+
+// ValidateRequest checks the parameters and body of r against the operation
+// for r.Method on path, whose path parameters the router matched as params.
+// A JSON body larger than maxBody is not read and reported as "too_large".
+// The body is left for the handler to read again. Requests for operations
+// the document does not describe pass.
+func (v *Validator) ValidateRequest(r *http.Request, path string, params map[string]string, maxBody int64) []Violation {
+	op := v.ops[Operation{Method: r.Method, Path: path}]
+	if op == nil {
+		return nil
+	}
+	var out []Violation
+	query := r.URL.Query()
+	known := map[string]bool{}
+	for _, p := range op.params {
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		schema, _ := p["schema"].(map[string]interface{})
+		required, _ := p["required"].(bool)
+		field := in + "." + name
+		var values []string
+		switch in {
+		case "path":
+			if s, ok := params[name]; ok {
+				values = []string{s}
+			}
+		case "query":
+			known[name] = true
+			values = query[name]
+		case "header":
+			values = r.Header.Values(name)
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if required {
+				out = append(out, Violation{field, "required", "is required"})
+			}
+			continue
+		}
+		if isType(schema, "array") {
+			items, _ := schema["items"].(map[string]interface{})
+			for _, s := range values {
+				out = append(out, v.validateParam(field, s, items)...)
+			}
+			continue
+		}
+		out = append(out, v.validateParam(field, values[0], schema)...)
+	}
+	for name := range query {
+		if !known[name] {
+			out = append(out, Violation{"query." + name, "unknown_field", "is not a parameter of this operation"})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+
+	if op.body != nil {
+		out = append(out, v.validateRequestBody(r, op.body, maxBody)...)
+	}
+	return out
+}
+
+// validateParam converts a parameter from its text form to the type its
+// schema asks for and checks the value.
+func (v *Validator) validateParam(field, s string, schema map[string]interface{}) []Violation {
+	schema = v.resolve(schema)
+	var val interface{} = s
+	switch {
+	case isType(schema, "integer"):
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return []Violation{{field, "invalid_type", "must be an integer"}}
+		}
+		val = json.Number(strconv.FormatInt(n, 10))
+	case isType(schema, "number"):
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return []Violation{{field, "invalid_type", "must be a number"}}
+		}
+		val = json.Number(s)
+	case isType(schema, "boolean"):
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return []Violation{{field, "invalid_type", "must be true or false"}}
+		}
+		val = b
+	}
+	return v.validate(field, val, schema)
+}
+
+// validateRequestBody checks a JSON body against the schema of its media
+// type, or of application/json when the Content-Type is not one the
+// operation lists. Other bodies (CSV, multipart) are left to the handler.
+func (v *Validator) validateRequestBody(r *http.Request, body map[string]interface{}, maxBody int64) []Violation {
+	content, _ := body["content"].(map[string]interface{})
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	media, ok := content[mt].(map[string]interface{})
+	if !ok {
+		if media, ok = content["application/json"].(map[string]interface{}); !ok {
+			return nil
+		}
+		mt = "application/json"
+	}
+	if !isJSON(mt) {
+		return nil
+	}
+	raw, err := readBody(r, maxBody)
+	if err != nil {
+		if err == errTooLarge {
+			return []Violation{{"body", "too_large", fmt.Sprintf("must be at most %d bytes", maxBody)}}
+		}
+		return []Violation{{"body", "unreadable", "could not be read"}}
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		if required, _ := body["required"].(bool); required {
+			return []Violation{{"body", "required", "is required"}}
+		}
+		return nil
+	}
+	val, err := decodeJSON(raw)
+	if err != nil {
+		return []Violation{{"body", "invalid_json", "is not valid JSON"}}
+	}
+	schema, _ := media["schema"].(map[string]interface{})
+	return v.validate("body", val, schema)
+}
+
+var errTooLarge = errors.New("body too large")
+
+// readBody reads r's body, up to max bytes, and puts it back for the
+// handler.
+func readBody(r *http.Request, max int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > max {
+		// hand the handler the whole body, read or not
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(raw), r.Body), r.Body}
+		return nil, errTooLarge
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw, nil
+}
+
+// ValidateResponse checks a response to method on path: that its status is
+// documented, its Content-Type is one the status lists and, for JSON, that
+// body matches the schema. Responses for operations the document does not
+// describe pass.
+func (v *Validator) ValidateResponse(method, path string, status int, contentType string, body []byte) []Violation {
+	op := v.ops[Operation{Method: method, Path: path}]
+	if op == nil {
+		return nil
+	}
+	res, ok := op.responses[strconv.Itoa(status)]
+	if !ok {
+		if res, ok = op.responses["default"]; !ok {
+			return []Violation{{"status", "undocumented", fmt.Sprintf("%d is not a documented status", status)}}
+		}
+	}
+	content, _ := v.resolve(res)["content"].(map[string]interface{})
+	if len(content) == 0 || len(body) == 0 {
+		return nil
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	media, ok := content[mt].(map[string]interface{})
+	if !ok {
+		return []Violation{{"header.Content-Type", "undocumented", fmt.Sprintf("%q is not documented for status %d", mt, status)}}
+	}
+	if !isJSON(mt) {
+		return nil
+	}
+	val, err := decodeJSON(body)
+	if err != nil {
+		return []Violation{{"body", "invalid_json", "is not valid JSON"}}
+	}
+	schema, _ := media["schema"].(map[string]interface{})
+	return v.validate("body", val, schema)
+}
+
+// validate checks val, decoded with json.Number for numbers, against schema.
+func (v *Validator) validate(field string, val interface{}, schema map[string]interface{}) []Violation {
+	if schema == nil {
+		return nil
+	}
+	schema = v.resolve(schema)
+	var out []Violation
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		// the members of every part are known to the whole, so a part's
+		// additionalProperties only rejects what no part declares
+		declared := map[string]bool{}
+		for _, part := range all {
+			for name := range v.properties(part) {
+				declared[name] = true
+			}
+		}
+		for _, part := range all {
+			out = append(out, v.validateWith(field, val, v.resolve(part), declared)...)
+		}
+	}
+	return append(out, v.validateWith(field, val, schema, nil)...)
+}
+
+func (v *Validator) properties(schema interface{}) map[string]interface{} {
+	props, _ := v.resolve(schema)["properties"].(map[string]interface{})
+	return props
+}
+
+func (v *Validator) validateWith(field string, val interface{}, schema map[string]interface{}, declared map[string]bool) []Violation {
+	if types, ok := schemaTypes(schema); ok && !typeMatches(val, types) {
+		return []Violation{{field, "invalid_type", "must be " + typeNames(types)}}
+	}
+	if c, ok := schema["const"]; ok && !sameJSON(val, c) {
+		return []Violation{{field, "not_allowed", fmt.Sprintf("must be %v", c)}}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || sameJSON(val, e)
+		}
+		if !found {
+			names := make([]string, len(enum))
+			for i, e := range enum {
+				names[i] = fmt.Sprint(e)
+			}
+			return []Violation{{field, "not_allowed", "must be one of: " + strings.Join(names, ", ")}}
+		}
+	}
+
+	var out []Violation
+	switch x := val.(type) {
+	case json.Number:
+		f, _ := x.Float64()
+		if min, ok := schema["minimum"].(float64); ok && f < min {
+			out = append(out, Violation{field, "out_of_range", fmt.Sprintf("must be at least %v", min)})
+		}
+		if max, ok := schema["maximum"].(float64); ok && f > max {
+			out = append(out, Violation{field, "out_of_range", fmt.Sprintf("must be at most %v", max)})
+		}
+	case string:
+		n := utf8.RuneCountInString(x)
+		if min, ok := schema["minLength"].(float64); ok && float64(n) < min {
+			out = append(out, Violation{field, "too_short", fmt.Sprintf("must be at least %v characters", min)})
+		}
+		if max, ok := schema["maxLength"].(float64); ok && float64(n) > max {
+			out = append(out, Violation{field, "too_long", fmt.Sprintf("must be at most %v characters", max)})
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(x) {
+				out = append(out, Violation{field, "invalid_format", "must match " + p})
+			}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, x); err != nil {
+				out = append(out, Violation{field, "invalid_format", "must be an RFC 3339 timestamp"})
+			}
+		}
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(x)) < min {
+			out = append(out, Violation{field, "too_short", fmt.Sprintf("must have at least %v items", min)})
+		}
+		if max, ok := schema["maxItems"].(float64); ok && float64(len(x)) > max {
+			out = append(out, Violation{field, "too_long", fmt.Sprintf("must have at most %v items", max)})
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range x {
+				out = append(out, v.validate(fmt.Sprintf("%s[%d]", field, i), item, items)...)
+			}
+		}
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		if req, ok := schema["required"].([]interface{}); ok {
+			for _, r := range req {
+				if name, _ := r.(string); name != "" {
+					if _, present := x[name]; !present {
+						out = append(out, Violation{field + "." + name, "required", "is required"})
+					}
+				}
+			}
+		}
+		names := make([]string, 0, len(x))
+		for name := range x {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if p, ok := props[name].(map[string]interface{}); ok {
+				out = append(out, v.validate(field+"."+name, x[name], p)...)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra && !declared[name] {
+					out = append(out, Violation{field + "." + name, "unknown_field", "is not a known field"})
+				}
+			case map[string]interface{}:
+				out = append(out, v.validate(field+"."+name, x[name], extra)...)
+			}
+		}
+	}
+	return out
+}
+
+// resolve follows local $refs ("#/components/schemas/Employee").
+func (v *Validator) resolve(node interface{}) map[string]interface{} {
+	m, _ := node.(map[string]interface{})
+	for i := 0; m != nil && i < 32; i++ {
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		var cur interface{} = v.root
+		for _, k := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			obj, _ := cur.(map[string]interface{})
+			cur = obj[k]
+		}
+		m, _ = cur.(map[string]interface{})
+	}
+	return m
+}
+
+func schemaTypes(schema map[string]interface{}) ([]string, bool) {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}, true
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, x := range t {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func isType(schema map[string]interface{}, name string) bool {
+	types, _ := schemaTypes(schema)
+	for _, t := range types {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+func typeMatches(val interface{}, types []string) bool {
+	for _, t := range types {
+		switch x := val.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case json.Number:
+			if t == "number" {
+				return true
+			}
+			// an integer is written without a fraction or exponent, which is
+			// also what the handlers' decoder accepts for int64 fields
+			if _, err := x.Int64(); err == nil && t == "integer" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var typeArticles = map[string]string{
+	"null": "null", "boolean": "a boolean", "string": "a string", "number": "a number",
+	"integer": "an integer", "array": "an array", "object": "an object",
+}
+
+func typeNames(types []string) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = typeArticles[t]
+	}
+	return strings.Join(names, " or ")
+}
+
+// sameJSON compares two decoded JSON values; numbers compare by value.
+func sameJSON(a, b interface{}) bool {
+	if n, ok := a.(json.Number); ok {
+		a, _ = n.Float64()
+	}
+	if n, ok := b.(json.Number); ok {
+		b, _ = n.Float64()
+	}
+	switch x := a.(type) {
+	case float64, string, bool, nil:
+		return a == b
+	default:
+		ja, _ := json.Marshal(x)
+		jb, _ := json.Marshal(b)
+		return bytes.Equal(ja, jb)
+	}
+}
+
+// decodeJSON decodes a single JSON value with numbers as json.Number.
+func decodeJSON(raw []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var val interface{}
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	return val, nil
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestValidator(t *testing.T) *Validator {
+	t.Helper()
+	v, err := NewValidator(Document())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func codes(vs []Violation) []string {
+	out := make([]string, len(vs))
+	for i, x := range vs {
+		out[i] = x.Field + " " + x.Code
+	}
+	return out
+}
+
+func TestValidateRequest(t *testing.T) {
+	v := newTestValidator(t)
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		path        string
+		params      map[string]string
+		contentType string
+		body        string
+		want        []string
+	}{
+		{
+			name: "valid create", method: "POST", target: "/api/v1/employees/", path: "/api/v1/employees/",
+			contentType: "application/json",
+			body:        `{"first_name":"Ann","last_name":"Lee","email":"ann@example.com","position":"Engineer","department_id":1}`,
+		},
+		{
+			name: "unknown member", method: "POST", target: "/api/v1/employees/", path: "/api/v1/employees/",
+			contentType: "application/json",
+			body:        `{"first_name":"Ann","last_name":"Lee","email":"ann@example.com","position":"Engineer","salary":10}`,
+			want:        []string{"body.salary unknown_field"},
+		},
+		{
+			name: "wrong type", method: "POST", target: "/api/v1/employees/", path: "/api/v1/employees/",
+			contentType: "application/json",
+			body:        `{"first_name":1,"last_name":"Lee","email":"ann@example.com","position":"Engineer","manager_id":"2"}`,
+			want:        []string{"body.first_name invalid_type", "body.manager_id invalid_type"},
+		},
+		{
+			name: "too long", method: "POST", target: "/api/v1/departments/", path: "/api/v1/departments/",
+			contentType: "application/json", body: `{"name":"` + strings.Repeat("x", 101) + `"}`,
+			want: []string{"body.name too_long"},
+		},
+		{
+			name: "not JSON", method: "POST", target: "/api/v1/departments/", path: "/api/v1/departments/",
+			contentType: "application/json", body: `{"name":`,
+			want: []string{"body invalid_json"},
+		},
+		{
+			name: "query parameters", method: "GET", target: "/api/v1/employees/?limit=0&include_deleted=maybe&colour=red",
+			path: "/api/v1/employees/",
+			want: []string{"query.colour unknown_field", "query.include_deleted invalid_type", "query.limit out_of_range"},
+		},
+		{
+			name: "id overflows int64", method: "GET", target: "/api/v1/employees/99999999999999999999/",
+			path: "/api/v1/employees/{id}/", params: map[string]string{"id": "99999999999999999999"},
+			want: []string{"path.id invalid_type"},
+		},
+		{
+			name: "JSON Patch item", method: "PATCH", target: "/api/v1/employees/1/", path: "/api/v1/employees/{id}/",
+			params: map[string]string{"id": "1"}, contentType: "application/json-patch+json",
+			body: `[{"op":"replace","path":"/position","value":"Lead"},{"op":"rename","path":"/x"}]`,
+			want: []string{"body[1].op not_allowed"},
+		},
+		{
+			name: "CSV is left to the handler", method: "POST", target: "/api/v1/employees/import",
+			path: "/api/v1/employees/import", contentType: "text/csv", body: "not,json\n",
+		},
+		{
+			name: "undocumented operation", method: "GET", target: "/elsewhere", path: "/elsewhere",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			got := codes(v.ValidateRequest(r, tt.path, tt.params, 1<<20))
+			if len(got) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+			// the handler still gets the whole body
+			if rest, _ := io.ReadAll(r.Body); string(rest) != tt.body {
+				t.Errorf("body left for the handler = %q, want %q", rest, tt.body)
+			}
+		})
+	}
+}
+
+func TestValidateRequestTooLarge(t *testing.T) {
+	v := newTestValidator(t)
+	body := `{"name":"` + strings.Repeat("x", 64) + `"}`
+	r := httptest.NewRequest("POST", "/api/v1/departments/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	got := codes(v.ValidateRequest(r, "/api/v1/departments/", nil, 16))
+	if want := []string{"body too_large"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+	if rest, _ := io.ReadAll(r.Body); string(rest) != body {
+		t.Errorf("body left for the handler = %q, want %q", rest, body)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	v := newTestValidator(t)
+	const node = `{"id":1,"first_name":"Ann","last_name":"Lee","position":"CEO","department_id":null,"manager_id":null,` +
+		`"version":1,"created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z","reports":[]}`
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		body        string
+		want        []string
+	}{
+		{name: "org chart", path: "/api/v1/orgchart", status: 200, contentType: "application/json",
+			body: `{"items":[` + node + `]}`},
+		{name: "missing member of an allOf part", path: "/api/v1/orgchart", status: 200, contentType: "application/json",
+			body: `{"items":[{"id":1,"first_name":"Ann","last_name":"Lee","position":"CEO","department_id":null,"manager_id":null,` +
+				`"version":1,"created_at":"2024-01-01T00:00:00Z","updated_at":"yesterday"}]}`,
+			want: []string{"body.items[0].updated_at invalid_format", "body.items[0].reports required"}},
+		{name: "problem through default", path: "/api/v1/orgchart", status: 503, contentType: "application/problem+json",
+			body: `{"type":"about:blank","title":"Service Unavailable","status":503,"code":"unavailable"}`},
+		{name: "undocumented content type", path: "/api/v1/employees/{id}/chain", status: 200, contentType: "text/plain",
+			body: "ok", want: []string{"header.Content-Type undocumented"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codes(v.ValidateResponse("GET", tt.path, tt.status, tt.contentType, []byte(tt.body)))
+			if len(got) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// Package redact decides which employee attributes a caller may see. A Policy
+// is declared once, one Field per sensitive JSON member, and applied by every
+// response that carries employees (single records, lists, trees, exports), so
+// the views cannot drift apart.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/model"
+)
+
+// Level is how much of a field a caller sees.
+type Level int
+
+const (
+	Hidden Level = iota // the member is dropped
+	Masked              // the member is replaced by Field.Mask(value)
+	Full                // the value is shown as stored
+)
+
+// Field declares the visibility of one JSON member. Roles maps a role to its
+// level; a caller with several roles gets the highest, and roles not listed
+// get Hidden. Own, if higher, applies to the caller's own record.
+type Field struct {
+	Name  string
+	Roles map[string]Level
+	Own   Level
+	Mask  func(string) string
+}
+
+// Policy is the set of restricted fields; members without a Field are always
+// shown in full.
+type Policy []Field
+
+// Employees is the policy for model.Employee. Add a Field here when a new
+// sensitive attribute is added to the model.
+var Employees = Policy{
+	{
+		Name:  "email",
+		Roles: map[string]Level{auth.RoleViewer: Masked, auth.RoleSelf: Masked, auth.RoleEditor: Full, auth.RoleAdmin: Full},
+		Own:   Full,
+		Mask:  MaskEmail,
+	},
+}
+
+// level returns the caller's level for f on the record owned by ownerID. A
+// missing principal gets Hidden.
+func (f Field) level(caller *auth.Principal, ownerID int64) Level {
+	if caller == nil {
+		return Hidden
+	}
+	lvl := Hidden
+	if caller.EmployeeID != 0 && caller.EmployeeID == ownerID {
+		lvl = f.Own
+	}
+	for _, r := range caller.Roles {
+		if l := f.Roles[r]; l > lvl {
+			lvl = l
+		}
+	}
+	return lvl
+}
+
+// Unrestricted reports whether caller sees every field in full on every
+// record, in which case values can be encoded as they are.
+func (p Policy) Unrestricted(caller *auth.Principal) bool {
+	for _, f := range p {
+		if f.level(caller, 0) < Full {
+			return false
+		}
+	}
+	return true
+}
+
+// Shows reports whether caller sees member name of the record owned by
+// ownerID in full.
+func (p Policy) Shows(caller *auth.Principal, ownerID int64, name string) bool {
+	for _, f := range p {
+		if f.Name == name {
+			return f.level(caller, ownerID) == Full
+		}
+	}
+	return true
+}
+
+// Apply redacts doc, the JSON object form of the record owned by ownerID,
+// in place.
+func (p Policy) Apply(caller *auth.Principal, ownerID int64, doc *Object) {
+	for _, f := range p {
+		raw, ok := doc.values[f.Name]
+		if !ok {
+			continue
+		}
+		switch f.level(caller, ownerID) {
+		case Hidden:
+			doc.Delete(f.Name)
+		case Masked:
+			var s string
+			if f.Mask == nil || json.Unmarshal(raw, &s) != nil {
+				doc.Delete(f.Name)
+				continue
+			}
+			doc.Set(f.Name, f.Mask(s))
+		}
+	}
+}
+
+// Employee returns e as the caller may see it: e itself when nothing is
+// restricted, otherwise a redacted JSON object.
+func (p Policy) Employee(caller *auth.Principal, e *model.Employee) interface{} {
+	if e == nil || p.Unrestricted(caller) {
+		return e
+	}
+	return p.employee(caller, e)
+}
+
+// EmployeeList redacts every element of list.
+func (p Policy) EmployeeList(caller *auth.Principal, list []*model.Employee) interface{} {
+	if p.Unrestricted(caller) {
+		return list
+	}
+	out := make([]*Object, len(list))
+	for i, e := range list {
+		out[i] = p.employee(caller, e)
+	}
+	return out
+}
+
+// Tree redacts an org-chart forest, keeping its shape.
+func (p Policy) Tree(caller *auth.Principal, nodes []*model.OrgNode) interface{} {
+	if p.Unrestricted(caller) {
+		return nodes
+	}
+	return p.tree(caller, nodes)
+}
+
+func (p Policy) tree(caller *auth.Principal, nodes []*model.OrgNode) []*Object {
+	out := make([]*Object, len(nodes))
+	for i, n := range nodes {
+		doc := p.employee(caller, n.Employee)
+		doc.Set("reports", p.tree(caller, n.Reports))
+		out[i] = doc
+	}
+	return out
+}
+
+// EmployeeObject is Employee, always as a JSON object, for writers that pick
+// out members by name (CSV and spreadsheet exports).
+func (p Policy) EmployeeObject(caller *auth.Principal, e *model.Employee) *Object {
+	return p.employee(caller, e)
+}
+
+func (p Policy) employee(caller *auth.Principal, e *model.Employee) *Object {
+	doc, err := NewObject(e)
+	if err != nil {
+		panic("redact: " + err.Error()) // model types always encode as objects
+	}
+	p.Apply(caller, e.ID, doc)
+	return doc
+}
+
+// Object is a JSON object that keeps its members in their original order, so
+// a redacted record encodes like the struct it came from, minus what the
+// caller may not see.
+type Object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// NewObject encodes v, which must encode as a JSON object.
+func NewObject(v interface{}) (*Object, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("%T does not encode as a JSON object", v)
+	}
+	o := &Object{values: map[string]json.RawMessage{}}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		o.Set(t.(string), raw)
+	}
+	return o, nil
+}
+
+// Set adds or replaces a member; new members go last.
+func (o *Object) Set(key string, v interface{}) {
+	raw, ok := v.(json.RawMessage)
+	if !ok {
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic("redact: " + err.Error())
+		}
+		raw = b
+	}
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+}
+
+// Delete removes a member.
+func (o *Object) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the member names in order.
+func (o *Object) Keys() []string { return o.keys }
+
+// Get returns the encoded value of a member.
+func (o *Object) Get(key string) (json.RawMessage, bool) {
+	raw, ok := o.values[key]
+	return raw, ok
+}
+
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(o.values[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MaskEmail keeps the first character of the local part and the domain:
+// jane@example.com becomes j***@example.com.
+func MaskEmail(s string) string {
+	local, domain, ok := strings.Cut(s, "@")
+	if !ok || local == "" {
+		return Mask(s)
+	}
+	return Mask(local) + "@" + domain
+}
+
+// Mask keeps only the first character of s.
+func Mask(s string) string {
+	if s == "" {
+		return ""
+	}
+	r := []rune(s)
+	return string(r[:1]) + "***"
+}
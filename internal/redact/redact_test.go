@@ -0,0 +1,93 @@
+package redact
+
+import (
+	"encoding/json"
+	"testing"
+
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &out))
+	return out
+}
+
+func TestMaskEmail(t *testing.T) {
+	assert.Equal(t, "j***@example.com", MaskEmail("jane@example.com"))
+	assert.Equal(t, "é***@example.com", MaskEmail("éva@example.com"))
+	assert.Equal(t, "n***", MaskEmail("not-an-email"))
+	assert.Equal(t, "", MaskEmail(""))
+}
+
+func TestEmployees(t *testing.T) {
+	jane := &model.Employee{ID: 7, FirstName: "Jane", Email: "jane@example.com"}
+
+	t.Run("EditorUnrestricted", func(t *testing.T) {
+		p := &auth.Principal{Roles: []string{auth.RoleEditor}}
+		assert.Same(t, jane, Employees.Employee(p, jane))
+	})
+
+	t.Run("ViewerMasked", func(t *testing.T) {
+		p := &auth.Principal{Roles: []string{auth.RoleViewer}}
+		out := Employees.Employee(p, jane)
+		assert.Equal(t, "j***@example.com", encode(t, out)["email"])
+		assert.Equal(t, "Jane", encode(t, out)["first_name"])
+	})
+
+	t.Run("HighestRoleWins", func(t *testing.T) {
+		p := &auth.Principal{Roles: []string{auth.RoleViewer, auth.RoleAdmin}}
+		assert.Same(t, jane, Employees.Employee(p, jane))
+	})
+
+	t.Run("OwnRecord", func(t *testing.T) {
+		p := &auth.Principal{Roles: []string{auth.RoleSelf}, EmployeeID: 7}
+		assert.Equal(t, "jane@example.com", encode(t, Employees.Employee(p, jane))["email"])
+		other := &model.Employee{ID: 8, Email: "bob@example.com"}
+		assert.Equal(t, "b***@example.com", encode(t, Employees.Employee(p, other))["email"])
+	})
+
+	t.Run("Shows", func(t *testing.T) {
+		p := &auth.Principal{Roles: []string{auth.RoleSelf}, EmployeeID: 7}
+		assert.True(t, Employees.Shows(p, 7, "email"))
+		assert.False(t, Employees.Shows(p, 8, "email"))
+		assert.True(t, Employees.Shows(p, 8, "first_name"))
+	})
+
+	t.Run("NoPrincipalHidden", func(t *testing.T) {
+		assert.NotContains(t, encode(t, Employees.Employee(nil, jane)), "email")
+	})
+
+	t.Run("KeepsMemberOrder", func(t *testing.T) {
+		p := &auth.Principal{Roles: []string{auth.RoleViewer}}
+		full, err := json.Marshal(&model.Employee{ID: 7, FirstName: "Jane", Email: "j***@example.com"})
+		require.NoError(t, err)
+		masked, err := json.Marshal(Employees.Employee(p, jane))
+		require.NoError(t, err)
+		assert.Equal(t, string(full), string(masked))
+	})
+}
+
+func TestTree(t *testing.T) {
+	p := &auth.Principal{Roles: []string{auth.RoleViewer}}
+	nodes := []*model.OrgNode{{
+		Employee: &model.Employee{ID: 1, Email: "ann@example.com"},
+		Reports:  []*model.OrgNode{{Employee: &model.Employee{ID: 2, Email: "bob@example.com"}}},
+	}}
+	b, err := json.Marshal(Employees.Tree(p, nodes))
+	require.NoError(t, err)
+	var out []map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &out))
+	require.Len(t, out, 1)
+	assert.Equal(t, "a***@example.com", out[0]["email"])
+	reports := out[0]["reports"].([]interface{})
+	require.Len(t, reports, 1)
+	assert.Equal(t, "b***@example.com", reports[0].(map[string]interface{})["email"])
+}
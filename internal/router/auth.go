@@ -0,0 +1,35 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/handler"
+)
+
+// authenticate rejects requests that a does not authenticate and stores the
+// principal in the request context of the rest, which also puts it in their
+// log records. Authentication failures are 401 problems with a
+// WWW-Authenticate challenge; other errors (say, the API key store being
+// unreachable) keep their own status.
+func authenticate(a auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, err := a.Authenticate(r)
+			if errors.Is(err, auth.ErrNoCredentials) {
+				err = apperr.Wrap(apperr.Unauthenticated, "authentication required", err)
+			}
+			if err != nil {
+				if apperr.KindOf(err) == apperr.Unauthenticated {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				}
+				handler.WriteProblem(w, r, err)
+				return
+			}
+			logPrincipal(r.Context(), p)
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), p)))
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/handler"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// IdempotencyKeyHeader names the client's key for a retryable request;
+// IdempotentReplayedHeader marks a response that is a stored one replayed.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKey bounds the length of an Idempotency-Key.
+const maxIdempotencyKey = 255
+
+// A pending key is leased to its request until the request's deadline plus
+// leaseGrace, or for defaultLease if it has none. Once the lease runs out, a
+// retry takes the key over, so a request that died without settling its key
+// blocks retries for no longer than it could have run.
+const (
+	leaseGrace   = 5 * time.Second
+	defaultLease = time.Minute
+)
+
+// replayedHeaders are the response headers stored with a key and sent again
+// on a replay.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyStore keeps keys and the responses to them; dao.IdempotencyDAO
+// implements it.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, k *model.IdempotencyKey) (*model.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, k *model.IdempotencyKey) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// idempotent honours an Idempotency-Key header on POST and PATCH requests.
+// The first request with a key is served and its response stored for ttl; a
+// later one with the same key and the same method, URL, Content-Type and body
+// gets that response again, marked with Idempotent-Replayed, without running.
+// Keys are per caller. A key reused for a different request is a 422, and one
+// whose first request is still being served a 409, until its lease runs out.
+//
+// 5xx responses are not stored, so a request that failed on the server runs
+// again when retried; neither are responses over maxBody bytes, the same
+// bound that applies to request bodies sent with a key (413).
+func idempotent(store IdempotencyStore, ttl time.Duration, maxBody int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				handler.WriteProblem(w, r, apperr.New(apperr.Invalid,
+					fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKey)))
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+			if err != nil {
+				handler.WriteProblem(w, r, apperr.Wrap(apperr.Invalid, "the request body could not be read", err))
+				return
+			}
+			if int64(len(body)) > maxBody {
+				handler.WriteStatusProblem(w, r, http.StatusRequestEntityTooLarge, "payload_too_large",
+					fmt.Sprintf("a request body sent with %s is limited to %d bytes", IdempotencyKeyHeader, maxBody))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := ""
+			if p, ok := auth.FromContext(r.Context()); ok {
+				scope = p.Subject
+			}
+			now := time.Now().UTC()
+			lockedUntil := now.Add(defaultLease)
+			if deadline, ok := r.Context().Deadline(); ok {
+				lockedUntil = deadline.UTC().Add(leaseGrace)
+			}
+			k := &model.IdempotencyKey{
+				Scope:       scope,
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				ExpiresAt:   now.Add(ttl),
+				LockedUntil: &lockedUntil,
+			}
+			held, reserved, err := store.Reserve(r.Context(), k)
+			if err != nil {
+				handler.WriteProblem(w, r, err)
+				return
+			}
+			if !reserved {
+				replay(w, r, k, held)
+				return
+			}
+
+			// the key must be settled even if the request is cancelled
+			ctx := context.WithoutCancel(r.Context())
+			settled := false
+			defer func() {
+				// the handler panicked; let a retry run again
+				if !settled {
+					release(ctx, store, k)
+				}
+			}()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			out := &capped{max: int(maxBody)}
+			ww.Tee(out)
+			next.ServeHTTP(ww, r)
+			settled = true
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= 500 || out.truncated {
+				release(ctx, store, k)
+				return
+			}
+			k.Status = status
+			k.ResponseHeaders = storedHeaders(ww.Header())
+			k.ResponseBody = out.Bytes()
+			if err := store.Complete(ctx, k); err != nil {
+				slog.ErrorContext(ctx, "store idempotent response", "err", err)
+				release(ctx, store, k)
+			}
+		})
+	}
+}
+
+// fingerprint hashes what makes two requests with one key the same request.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, s := range []string{r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type")} {
+		io.WriteString(h, strconv.Itoa(len(s)))
+		io.WriteString(h, ":")
+		io.WriteString(h, s)
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay answers a request whose key is held by held.
+func replay(w http.ResponseWriter, r *http.Request, k, held *model.IdempotencyKey) {
+	switch {
+	case held.Fingerprint != k.Fingerprint:
+		handler.WriteProblem(w, r, apperr.New(apperr.Validation,
+			IdempotencyKeyHeader+" was already used for a different request"))
+	case held.Pending():
+		handler.WriteProblem(w, r, apperr.New(apperr.Conflict,
+			"a request with this "+IdempotencyKeyHeader+" is still being processed"))
+	default:
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(held.ResponseHeaders), &headers); err != nil && held.ResponseHeaders != "" {
+			handler.WriteProblem(w, r, apperr.Wrap(apperr.Internal, "", err))
+			return
+		}
+		for name, v := range headers {
+			w.Header().Set(name, v)
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(held.Status)
+		w.Write(held.ResponseBody)
+	}
+}
+
+// storedHeaders encodes the replayedHeaders h has.
+func storedHeaders(h http.Header) string {
+	kept := map[string]string{}
+	for _, name := range replayedHeaders {
+		if v := h.Get(name); v != "" {
+			kept[name] = v
+		}
+	}
+	b, _ := json.Marshal(kept)
+	return string(b)
+}
+
+// release drops k after a response that is not to be replayed. A key that
+// cannot be dropped stays pending, and retries get 409, until its lease runs
+// out.
+func release(ctx context.Context, store IdempotencyStore, k *model.IdempotencyKey) {
+	if err := store.Release(ctx, k.Scope, k.Key); err != nil {
+		slog.ErrorContext(ctx, "release idempotency key", "err", err)
+	}
+}
@@ -0,0 +1,140 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/handler"
+	"emplopyee-app-go/internal/logging"
+	"emplopyee-app-go/internal/openapi"
+	"emplopyee-app-go/internal/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// routeOf returns the route r matched with its parameter patterns dropped
+// (/api/v1/employees/{id}), or false before routing or when nothing matched.
+// A miss inside a mounted subrouter leaves its mount pattern,
+// /api/v1/employees/*; no route of ours ends in a wildcard.
+func routeOf(r *http.Request) (string, bool) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return "", false
+	}
+	p := rctx.RoutePattern()
+	if p == "" || strings.HasSuffix(p, "/*") {
+		return "", false
+	}
+	return openapi.PathTemplate(p), true
+}
+
+// routeValue logs the route of a request as it is when the record is
+// written, so records from handlers have it although it is added to the
+// context before routing.
+type routeValue struct{ r *http.Request }
+
+func (v routeValue) LogValue() slog.Value {
+	route, ok := routeOf(v.r)
+	if !ok {
+		route = unmatchedRoute
+	}
+	return slog.StringValue(route)
+}
+
+// principalValue logs the subject of the principal authenticate stores in
+// it. Authentication runs inside logRequests, so the request record only
+// sees the principal through this shared value. Until then it is an empty
+// group, which handlers leave out.
+type principalValue struct{ p *auth.Principal }
+
+type principalValueKey struct{}
+
+func (v *principalValue) LogValue() slog.Value {
+	if v.p == nil {
+		return slog.GroupValue()
+	}
+	return slog.StringValue(v.p.Subject)
+}
+
+// logPrincipal makes p the principal of the records of the request ctx
+// belongs to.
+func logPrincipal(ctx context.Context, p *auth.Principal) {
+	if v, ok := ctx.Value(principalValueKey{}).(*principalValue); ok {
+		v.p = p
+	}
+}
+
+// logRequests adds the request ID, route, principal and trace ID to the
+// logging context of each request and writes one "request" record per request once it is
+// served. The trace ID is that of the request span, or of an incoming
+// traceparent header when tracing is off, so logs still join the caller's
+// trace.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := r.Context()
+		attrs := []slog.Attr{slog.String("request_id", middleware.GetReqID(ctx))}
+		if sc := tracing.SpanFromContext(ctx).SpanContext(); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID.String()))
+		} else if sc, ok := tracing.Extract(r.Header); ok {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID.String()))
+		}
+		// the route value reads the request's chi context, which routing
+		// fills in below
+		principal := &principalValue{}
+		attrs = append(attrs, slog.Any("route", routeValue{r}), slog.Any("principal", principal))
+		ctx = context.WithValue(ctx, principalValueKey{}, principal)
+		r = r.WithContext(logging.With(ctx, attrs...))
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if status >= 500 {
+				level = slog.LevelWarn
+			}
+			slog.LogAttrs(r.Context(), level, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// recoverPanics turns a panicking handler into a 500 problem and logs the
+// panic with its stack. http.ErrAbortHandler is passed on, since it is how a
+// handler abandons a response it has already started.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(p)
+			}
+			slog.ErrorContext(r.Context(), "handler panicked",
+				slog.Any("panic", p), slog.String("stack", string(debug.Stack())))
+			handler.WriteStatusProblem(w, r, http.StatusInternalServerError, apperr.Internal.String(),
+				apperr.Message(apperr.New(apperr.Internal, "")))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
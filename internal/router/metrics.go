@@ -0,0 +1,78 @@
+package router
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"emplopyee-app-go/internal/logging"
+	"emplopyee-app-go/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatchedRoute labels requests that matched no route, so that scanners
+// probing random paths cannot create a series per path.
+const unmatchedRoute = "unmatched"
+
+// instrument counts and times every request in http_requests_total and
+// http_request_duration_seconds, labelled by method, status and the chi
+// route pattern with its parameter patterns dropped (/api/v1/employees/{id}),
+// never the raw path.
+func instrument(reg *metrics.Registry) func(http.Handler) http.Handler {
+	requests := reg.Counter("http_requests_total",
+		"HTTP requests served, by route pattern.", "method", "route", "status")
+	duration := reg.Histogram("http_request_duration_seconds",
+		"Time to serve HTTP requests, by route pattern.", metrics.DefaultBuckets, "method", "route", "status")
+	inFlight := reg.Gauge("http_requests_in_flight", "HTTP requests being served.")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			inFlight.Add(1)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				inFlight.Add(-1)
+				route, ok := routeOf(r)
+				if !ok {
+					route = unmatchedRoute
+				}
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				labels := []string{r.Method, route, strconv.Itoa(status)}
+				requests.Inc(labels...)
+				duration.Observe(time.Since(start).Seconds(), labels...)
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// NewAdminRouter serves the operational endpoints that must not be reachable
+// through the public listener:
+//
+//	GET /metrics   - Prometheus metrics from reg
+//	GET /log/level - Current log level, {"level":"INFO"}
+//	PUT /log/level - Change the log level at runtime, {"level":"debug"}
+//	GET /health    - Health check of the admin listener
+//
+// A nil level leaves out /log/level.
+func NewAdminRouter(reg *metrics.Registry, level *slog.LevelVar) http.Handler {
+	r := chi.NewRouter()
+	r.Use(recoverPanics)
+	r.Method(http.MethodGet, "/metrics", reg)
+	if level != nil {
+		h := logging.LevelHandler(level)
+		r.Method(http.MethodGet, "/log/level", h)
+		r.Method(http.MethodPut, "/log/level", h)
+	}
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	return r
+}
@@ -2,64 +2,266 @@ package router
 
 import (
 	"net/http"
+	"time"
 
+	"emplopyee-app-go/internal/auth"
 	"emplopyee-app-go/internal/handler"
+	"emplopyee-app-go/internal/metrics"
+	"emplopyee-app-go/internal/openapi"
 	"emplopyee-app-go/internal/service"
+	"emplopyee-app-go/internal/tracing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// Option configures NewRouter.
+type Option func(*options)
+
+type options struct {
+	handler           []handler.Option
+	validator         *openapi.Validator
+	maxBody           int64
+	validateResponses bool
+	metrics           *metrics.Registry
+	tracer            *tracing.Tracer
+	idempotency       IdempotencyStore
+	idempotencyTTL    time.Duration
+	idempotencyMax    int64
+	bulkTimeout       time.Duration
+}
+
+// requestTimeout bounds every request but the bulk ones; defaultBulkTimeout
+// bounds those unless WithBulkTimeout says otherwise.
+const (
+	requestTimeout     = 30 * time.Second
+	defaultBulkTimeout = 10 * time.Minute
+)
+
+// WithHandlerOptions passes opts through to the employee handler.
+func WithHandlerOptions(opts ...handler.Option) Option {
+	return func(o *options) { o.handler = append(o.handler, opts...) }
+}
+
+// WithRequestValidation checks every /api/v1 request against the operation v
+// describes for it before the handler runs; see validateRequests. JSON bodies
+// over maxBody bytes are rejected with 413. With responses set, responses are
+// checked too and violations logged, which is meant for development.
+func WithRequestValidation(v *openapi.Validator, maxBody int64, responses bool) Option {
+	return func(o *options) {
+		o.validator = v
+		o.maxBody = maxBody
+		o.validateResponses = responses
+	}
+}
+
+// WithMetrics records request counts and latencies in reg; see instrument.
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(o *options) { o.metrics = reg }
+}
+
+// WithTracer starts a span for every request; see traceRequests.
+func WithTracer(t *tracing.Tracer) Option {
+	return func(o *options) { o.tracer = t }
+}
+
+// WithIdempotency honours Idempotency-Key on POST and PATCH requests, keeping
+// responses in store for ttl; see idempotent. Bodies sent with a key are
+// limited to maxBody bytes.
+func WithIdempotency(store IdempotencyStore, ttl time.Duration, maxBody int64) Option {
+	return func(o *options) {
+		o.idempotency = store
+		o.idempotencyTTL = ttl
+		o.idempotencyMax = maxBody
+	}
+}
+
+// WithBulkTimeout gives imports and exports d to finish instead of
+// defaultBulkTimeout; see bulkRequests.
+func WithBulkTimeout(d time.Duration) Option {
+	return func(o *options) { o.bulkTimeout = d }
+}
+
 // Package router provides the application's HTTP routing and middleware configuration.
 //
 // This file defines the router that handles API versioning, route grouping, and
 // middleware setup for the Employee management service.
 //
 // The NewRouter function sets up a chi.Router with logging, recovery, timeout, and
-// request ID middleware, and mounts the Employee resource handlers at /api/v1/employees.
+// request ID middleware, and mounts the Employee and Department resource handlers
+// at /api/v1/employees and /api/v1/departments.
+// opts configure the employee handler (WithHandlerOptions), request
+// validation (WithRequestValidation), metrics (WithMetrics), tracing
+// (WithTracer), Idempotency-Key handling (WithIdempotency) and the deadline
+// of imports and exports (WithBulkTimeout).
+//
+// Every /api/v1 route requires a principal from authn; a nil authn leaves the
+// API open (tests and embedding). /health, /openapi.json and /docs are always
+// public.
 //
 // Routes:
 //
-//	POST   /api/v1/employees/          - Create new employee
-//	GET    /api/v1/employees/          - List all employees
-//	GET    /api/v1/employees/{id}/     - Get employee by ID
-//	PUT    /api/v1/employees/{id}/     - Update employee by ID
-//	DELETE /api/v1/employees/{id}/     - Delete employee by ID
-//	GET    /health                     - Health check endpoint
+//	POST   /api/v1/employees/                - Create new employee
+//	GET    /api/v1/employees/                - List employees (?include_deleted=true adds the trash)
+//	GET    /api/v1/employees/trash           - List soft-deleted employees
+//	GET    /api/v1/employees/search          - Full-text search on names, email and position (?q=ann%20eng)
+//	GET    /api/v1/employees/export          - Download employees matching the list filters (?format=csv|ndjson|xlsx)
+//	POST   /api/v1/employees/import          - Upsert employees from CSV by email (?dry_run=true)
+//	POST   /api/v1/employees:batch           - Create, update and delete in one request (?atomic=true)
+//	GET    /api/v1/employees/{id}/           - Get employee by ID
+//	PUT    /api/v1/employees/{id}/           - Update employee by ID
+//	PATCH  /api/v1/employees/{id}/           - Partially update employee (merge patch or JSON Patch)
+//	DELETE /api/v1/employees/{id}/           - Soft-delete employee by ID
+//	POST   /api/v1/employees/{id}/restore    - Restore a soft-deleted employee
+//	GET    /api/v1/employees/{id}/reports    - Reporting tree below an employee (?depth=n, default 1)
+//	POST   /api/v1/employees/{id}/reports/reassign - Move all direct reports to another manager
+//	GET    /api/v1/employees/{id}/chain      - Management chain, nearest manager first
+//	GET    /api/v1/employees/{id}/history    - Audit entries of an employee, oldest first
+//	GET    /api/v1/orgchart                  - Whole organisation as a nested tree
+//	GET    /api/v1/audit                     - Audit log (?actor=&since=&until=&employee_id=)
+//	DELETE /api/v1/admin/employees/{id}      - Purge a soft-deleted employee (hr_admin role)
+//	POST   /api/v1/departments/              - Create department
+//	GET    /api/v1/departments/              - List departments
+//	GET    /api/v1/departments/{id}/         - Get department by ID
+//	PUT    /api/v1/departments/{id}/         - Update department by ID
+//	DELETE /api/v1/departments/{id}/         - Delete an empty department
+//	GET    /api/v1/departments/{id}/employees - List the department's employees
+//	GET    /health                           - Health check endpoint
+//	GET    /openapi.json                     - OpenAPI 3.1 description of these routes
+//	GET    /docs                             - API documentation page rendering /openapi.json
 //
 // Dependencies:
 //   - github.com/go-chi/chi/v5: Routing framework
-//   - github.com/go-chi/chi/v5/middleware: Middleware for logging, recovery, timeouts, etc.
-func NewRouter(svc service.EmployeeService) http.Handler {
+//   - github.com/go-chi/chi/v5/middleware: Middleware for request IDs and timeouts
+func NewRouter(svc service.EmployeeService, depts service.DepartmentService, audit service.AuditService, authn auth.Authenticator, opts ...Option) http.Handler {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Initialize a new chi.Router instance to handle incoming HTTP requests
 	r := chi.NewRouter()
 
 	// Register common middleware for all routes:
 	//   - RequestID:       Assigns a unique request ID to each HTTP request for tracking/logging.
-	//   - Logger:          Logs the start and end of each request, including path, method, duration, and status.
-	//   - Recoverer:       Recovers from panics within handlers and returns a 500 error instead of crashing the server.
-	//   - Timeout(30s):    Ensures that handlers take no more than 30 seconds to respond, preventing resource exhaustion.
+	//   - logRequests:     Adds request ID, route and trace ID to every log record of the request and logs
+	//                      its method, path, status and duration once it is served.
+	//   - recoverPanics:   Recovers from panics within handlers, logs them and returns a 500 error instead of crashing the server.
+	//
+	// Each route then gets a deadline: requestTimeout, or for the imports and
+	// exports that move whole files, the bulk timeout (bulkRequests).
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * 1e9)) // 30s
-
-	h := handler.NewEmployeeHandler(svc)
-
-	r.Route("/api/v1/employees", func(r chi.Router) {
-		r.Post("/", h.Create)
-		r.Get("/", h.List)
-		r.Route("/{id:[0-9]+}", func(r chi.Router) {
-			r.Get("/", h.Get)
-			r.Put("/", h.Update)
-			r.Delete("/", h.Delete)
+	if o.tracer != nil {
+		r.Use(traceRequests(o.tracer))
+	}
+	if o.metrics != nil {
+		// outside Recoverer, so recovered panics count as the 500s they become
+		r.Use(instrument(o.metrics))
+	}
+	r.Use(logRequests)
+	r.Use(recoverPanics)
+
+	// Unmatched routes and methods get the same problem+json body as handler errors.
+	r.NotFound(handler.NotFound)
+	r.MethodNotAllowed(handler.MethodNotAllowed)
+
+	h := handler.NewEmployeeHandler(svc, o.handler...)
+	dh := handler.NewDepartmentHandler(depts, svc)
+	ah := handler.NewAuditHandler(audit)
+
+	root := r
+	// api adds the middleware every /api/v1 route shares
+	api := func(r chi.Router) {
+		if authn != nil {
+			r.Use(authenticate(authn))
+		}
+		// after authentication, so anonymous callers learn nothing about
+		// the shape of requests
+		if o.validator != nil {
+			r.Use(validateRequests(root, o.validator, o.maxBody, o.validateResponses))
+		}
+		// keys are per principal, and a request the document rejects is not
+		// worth a key; after the deadline, which bounds a key's lease
+		if o.idempotency != nil {
+			r.Use(idempotent(o.idempotency, o.idempotencyTTL, o.idempotencyMax))
+		}
+	}
+
+	bulkTimeout := o.bulkTimeout
+	if bulkTimeout <= 0 {
+		bulkTimeout = defaultBulkTimeout
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(bulkRequests(bulkTimeout))
+		api(r)
+		r.Get("/api/v1/employees/export", h.Export)
+		r.Post("/api/v1/employees/import", h.Import)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(requestTimeout))
+		api(r)
+
+		r.Route("/api/v1/employees", func(r chi.Router) {
+			r.Post("/", h.Create)
+			r.Get("/", h.List)
+			r.Get("/trash", h.Trash)
+			r.Get("/search", h.Search)
+			r.Route("/{id:[0-9]+}", func(r chi.Router) {
+				r.Get("/", h.Get)
+				r.Put("/", h.Update)
+				r.Patch("/", h.Patch)
+				r.Delete("/", h.Delete)
+				r.Post("/restore", h.Restore)
+				r.Get("/reports", h.Reports)
+				r.Post("/reports/reassign", h.ReassignReports)
+				r.Get("/chain", h.Chain)
+				r.Get("/history", ah.History)
+			})
+		})
+		r.Post("/api/v1/employees:batch", h.Batch)
+		r.Delete("/api/v1/admin/employees/{id:[0-9]+}", h.Purge)
+		r.Get("/api/v1/orgchart", h.OrgChart)
+		r.Get("/api/v1/audit", ah.List)
+
+		r.Route("/api/v1/departments", func(r chi.Router) {
+			r.Post("/", dh.Create)
+			r.Get("/", dh.List)
+			r.Route("/{id:[0-9]+}", func(r chi.Router) {
+				r.Get("/", dh.Get)
+				r.Put("/", dh.Update)
+				r.Delete("/", dh.Delete)
+				r.Get("/employees", dh.Employees)
+			})
 		})
 	})
 
-	// health
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+	// health is public so load balancers and probes need no credentials
+	public := r.With(middleware.Timeout(requestTimeout))
+	public.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
+	// every route above must have an operation in the document; see
+	// TestRoutesMatchOpenAPI
+	public.Get("/openapi.json", openapi.ServeDocument)
+	public.Get("/docs", openapi.ServeDocs)
 	return r
 }
+
+// bulkRequests gives a request that uploads or downloads a whole file d to
+// finish, in place of requestTimeout. The server's read and write deadlines,
+// which would cut such a request off much sooner, are moved to match.
+func bulkRequests(d time.Duration) func(http.Handler) http.Handler {
+	timeout := middleware.Timeout(d)
+	return func(next http.Handler) http.Handler {
+		return timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline := time.Now().Add(d)
+			rc := http.NewResponseController(w)
+			// not supported by test recorders, which have no deadlines
+			rc.SetReadDeadline(deadline)
+			rc.SetWriteDeadline(deadline)
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
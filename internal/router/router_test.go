@@ -0,0 +1,318 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/logging"
+	"emplopyee-app-go/internal/metrics"
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/openapi"
+	"emplopyee-app-go/internal/tracing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestRoutesMatchOpenAPI fails when a route has no operation in the OpenAPI
+// document or the document describes a route that does not exist.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	ops, err := openapi.Operations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	documented := make(map[openapi.Operation]bool, len(ops))
+	for _, op := range ops {
+		documented[op] = true
+	}
+
+	routes := map[openapi.Operation]bool{}
+	r := NewRouter(nil, nil, nil, nil).(chi.Routes)
+	err = chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[openapi.Operation{Method: method, Path: openapi.PathTemplate(route)}] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for op := range routes {
+		if !documented[op] {
+			t.Errorf("route %s has no operation in internal/openapi/openapi.json", op)
+		}
+	}
+	for op := range documented {
+		if !routes[op] {
+			t.Errorf("operation %s in internal/openapi/openapi.json has no route", op)
+		}
+	}
+}
+
+// TestRequestValidation checks that requests the document rules out never
+// reach a handler; the nil services would panic if they did.
+func TestRequestValidation(t *testing.T) {
+	v, err := openapi.NewValidator(openapi.Document())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(nil, nil, nil, nil, WithRequestValidation(v, 256, false))
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{"unknown member", "POST", "/api/v1/employees/",
+			`{"first_name":"Ann","last_name":"Lee","email":"ann@example.com","position":"Engineer","salary":1}`,
+			http.StatusBadRequest, []string{"body.salary"}},
+		{"id out of range", "GET", "/api/v1/employees/99999999999999999999/", "",
+			http.StatusBadRequest, []string{"path.id"}},
+		{"limit", "GET", "/api/v1/departments/1/employees?limit=0", "",
+			http.StatusBadRequest, []string{"query.limit"}},
+		{"body too large", "POST", "/api/v1/departments/", `{"name":"` + strings.Repeat("x", 300) + `"}`,
+			http.StatusRequestEntityTooLarge, nil},
+		{"unknown route", "GET", "/api/v1/nothing", "", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var p struct {
+				Errors []struct{ Field string }
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, e := range p.Errors {
+				fields = append(fields, e.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("errors on %q, want %q", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+// TestMetricsRoutePattern checks that requests are labelled by route, not by
+// raw path.
+func TestMetricsRoutePattern(t *testing.T) {
+	reg := metrics.NewRegistry()
+	r := NewRouter(nil, nil, nil, nil, WithMetrics(reg))
+	for _, target := range []string{"/health", "/health", "/openapi.json", "/no/such/path", "/api/v1/employees/abc/"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	NewAdminRouter(reg, nil).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/health",status="200"} 2`,
+		`http_requests_total{method="GET",route="/openapi.json",status="200"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/health",status="200"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %s:\n%s", want, out)
+		}
+	}
+}
+
+type spanRecorder struct{ spans []*tracing.Span }
+
+func (r *spanRecorder) Export(_ context.Context, spans []*tracing.Span) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+// TestTracing checks that a request span continues the caller's trace and
+// carries the route and request ID.
+func TestTracing(t *testing.T) {
+	rec := &spanRecorder{}
+	tracer := tracing.NewTracer("test", rec)
+	r := NewRouter(nil, nil, nil, nil, WithTracer(tracer))
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(rec.spans))
+	}
+	s := rec.spans[0]
+	if s.Name() != "GET /health" || s.SpanContext().TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		s.Parent().String() != "00f067aa0ba902b7" {
+		t.Errorf("span %q in trace %s under %s", s.Name(), s.SpanContext().TraceID, s.Parent())
+	}
+	attrs := map[string]interface{}{}
+	for _, a := range s.Attributes() {
+		attrs[a.Key] = a.Value
+	}
+	if attrs["request.id"] == "" || attrs["http.route"] != "/health" || attrs["http.response.status_code"] != int64(200) {
+		t.Errorf("unexpected attributes %v", attrs)
+	}
+}
+
+// TestRequestLogging checks that records logged while serving a request
+// carry its request ID, route, principal and trace ID, and that handler
+// errors log their cause.
+func TestRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", slog.LevelDebug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	authn := auth.Anonymous("hr_viewer")
+	r := NewRouter(nil, nil, nil, authn)
+	req := httptest.NewRequest("GET", "/api/v1/employees/abc/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	var recs []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("%v in %s", err, line)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("got %d records, want 3:\n%s", len(recs), buf.String())
+	}
+	failed, first, health := recs[0], recs[1], recs[2]
+	if failed["msg"] != "request failed" || failed["status"] != float64(404) || failed["err"] == nil ||
+		failed["principal"] != "anonymous" || failed["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected error record %v", failed)
+	}
+	if first["msg"] != "request" || first["request_id"] == "" || first["route"] != unmatchedRoute ||
+		first["principal"] != "anonymous" || first["status"] != float64(404) {
+		t.Errorf("unexpected request record %v", first)
+	}
+	if health["route"] != "/health" || health["principal"] != nil || health["trace_id"] != nil {
+		t.Errorf("unexpected request record %v", health)
+	}
+}
+
+// memIdempotencyStore is an IdempotencyStore in a map.
+type memIdempotencyStore struct {
+	keys map[string]*model.IdempotencyKey
+}
+
+func (s *memIdempotencyStore) Reserve(_ context.Context, k *model.IdempotencyKey) (*model.IdempotencyKey, bool, error) {
+	if held, ok := s.keys[k.Scope+"/"+k.Key]; ok {
+		return held, false, nil
+	}
+	s.keys[k.Scope+"/"+k.Key] = k
+	return k, true, nil
+}
+
+func (s *memIdempotencyStore) Complete(_ context.Context, k *model.IdempotencyKey) error {
+	s.keys[k.Scope+"/"+k.Key] = k
+	return nil
+}
+
+func (s *memIdempotencyStore) Release(_ context.Context, scope, key string) error {
+	delete(s.keys, scope+"/"+key)
+	return nil
+}
+
+// TestIdempotency checks that a retry with the same key and body replays the
+// first response without running the handler, and that other uses of the key
+// are refused.
+func TestIdempotency(t *testing.T) {
+	store := &memIdempotencyStore{keys: map[string]*model.IdempotencyKey{}}
+	calls := 0
+	h := idempotent(store, time.Hour, 64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == `{"fail":true}` {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("ETag", `"1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+	send := func(method, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/employees/", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("POST", "k1", `{"a":1}`)
+	replayed := send("POST", "k1", `{"a":1}`)
+	if calls != 1 || replayed.Code != http.StatusCreated || replayed.Body.String() != `{"call":1}` ||
+		replayed.Header().Get("ETag") != `"1"` || replayed.Header().Get(IdempotentReplayedHeader) != "true" ||
+		first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("replay ran %d times: %d %s %v", calls, replayed.Code, replayed.Body, replayed.Header())
+	}
+	if rec := send("POST", "k1", `{"a":2}`); rec.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("key reused with another body: %d after %d calls", rec.Code, calls)
+	}
+	if rec := send("PUT", "k1", `{"a":2}`); rec.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("PUT with a key: %d after %d calls", rec.Code, calls)
+	}
+	if send("POST", "", `{"a":1}`); calls != 3 {
+		t.Errorf("POST without a key ran %d times, want 3", calls)
+	}
+
+	// server errors are not kept, so a retry runs again
+	send("POST", "k2", `{"fail":true}`)
+	if send("POST", "k2", `{"fail":true}`); calls != 5 {
+		t.Errorf("a retried 503 ran %d times, want 5", calls)
+	}
+
+	store.keys["/pending"] = &model.IdempotencyKey{Key: "pending", Fingerprint: fingerprint(
+		httptest.NewRequest("POST", "/api/v1/employees/", nil), []byte(`{"a":1}`))}
+	if rec := send("POST", "pending", `{"a":1}`); rec.Code != http.StatusConflict {
+		t.Errorf("key still in flight: %d, want 409", rec.Code)
+	}
+	if rec := send("POST", "k3", strings.Repeat("x", 65)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("body over the limit: %d, want 413", rec.Code)
+	}
+}
+
+// TestBulkRequests checks that imports and exports get the bulk deadline
+// rather than the one of other requests.
+func TestBulkRequests(t *testing.T) {
+	var left time.Duration
+	h := bulkRequests(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if deadline, ok := r.Context().Deadline(); ok {
+			left = time.Until(deadline)
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/employees/import", nil))
+	if left <= requestTimeout || left > time.Hour {
+		t.Errorf("expected a deadline within the hour, got %v", left)
+	}
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"net/http"
+
+	"emplopyee-app-go/internal/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// traceRequests starts a server span for each request, continuing the trace
+// of an incoming W3C traceparent header. The span is named after the method
+// and route once routing is done ("GET /api/v1/employees/{id}") and carries
+// the chi request ID as request.id.
+func traceRequests(t *tracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if sc, ok := tracing.Extract(r.Header); ok {
+				ctx = tracing.ContextWithRemote(ctx, sc)
+			}
+			ctx, span := t.Start(ctx, r.Method, tracing.KindServer,
+				tracing.String("http.request.method", r.Method),
+				tracing.String("url.path", r.URL.Path),
+				tracing.String("request.id", middleware.GetReqID(ctx)),
+			)
+			defer span.End()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if route, ok := routeOf(r); ok {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(tracing.String("http.route", route))
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(tracing.Int("http.response.status_code", int64(status)))
+			if status >= 500 {
+				span.SetStatus(tracing.StatusError, http.StatusText(status))
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"emplopyee-app-go/internal/handler"
+	"emplopyee-app-go/internal/openapi"
+	"emplopyee-app-go/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxValidatedResponse bounds how much of a response body is kept for
+// validation; larger (streamed) responses only have their status and
+// Content-Type checked.
+const maxValidatedResponse = 1 << 20
+
+// validateRequests checks each request against the operation the document
+// describes for its route before the handler runs, and answers 400 with every
+// violation in "errors" (413 for a JSON body over maxBody). With responses
+// set, it also checks what the handler sent and logs each violation; the
+// response itself goes out unchanged.
+//
+// It runs before the route's handler is chosen, so it looks the route up in
+// root itself.
+func validateRequests(root chi.Routes, v *openapi.Validator, maxBody int64, responses bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.RawPath
+			if path == "" {
+				path = r.URL.Path
+			}
+			rctx := chi.NewRouteContext()
+			route := root.Find(rctx, r.Method, path)
+			if route == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			tmpl := openapi.PathTemplate(route)
+			params := make(map[string]string, len(rctx.URLParams.Keys))
+			for i, k := range rctx.URLParams.Keys {
+				params[k] = rctx.URLParams.Values[i]
+			}
+
+			if vs := v.ValidateRequest(r, tmpl, params, maxBody); len(vs) > 0 {
+				for _, x := range vs {
+					if x.Code == "too_large" {
+						handler.WriteStatusProblem(w, r, http.StatusRequestEntityTooLarge, "payload_too_large",
+							fmt.Sprintf("a request body is limited to %d bytes", maxBody))
+						return
+					}
+				}
+				errs := make([]service.FieldError, len(vs))
+				for i, x := range vs {
+					errs[i] = service.FieldError{Field: x.Field, Code: x.Code, Message: x.Message}
+				}
+				handler.WriteInvalidRequest(w, r, "the request does not match the API description", errs)
+				return
+			}
+			if !responses {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			body := &capped{max: maxValidatedResponse}
+			ww.Tee(body)
+			next.ServeHTTP(ww, r)
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			var raw []byte
+			if !body.truncated {
+				raw = body.Bytes()
+			}
+			for _, x := range v.ValidateResponse(r.Method, tmpl, status, ww.Header().Get("Content-Type"), raw) {
+				slog.WarnContext(r.Context(), "response violates the OpenAPI document",
+					"method", r.Method, "path", r.URL.Path, "status", status, "violation", x.String())
+			}
+		})
+	}
+}
+
+// capped keeps the first max bytes written to it.
+type capped struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *capped) Write(p []byte) (int, error) {
+	if room := c.max - c.Len(); len(p) > room {
+		c.truncated = true
+		if room > 0 {
+			c.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return c.Buffer.Write(p)
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/model"
+)
+
+// AuditService reads the employee audit log. Entries are written by the DAO
+// as part of every employee write, never through this service.
+//
+// Methods:
+//   - ListAudit: Returns one page of entries, oldest first. Set
+//     AuditQuery.EmployeeID for the history of a single employee; purged
+//     employees keep their history.
+type AuditService interface {
+	ListAudit(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error)
+}
+
+type auditService struct {
+	dao dao.AuditDAO
+}
+
+func NewAuditService(d dao.AuditDAO) AuditService {
+	return &auditService{dao: d}
+}
+
+func (s *auditService) ListAudit(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Until.After(q.Since) {
+		return nil, fieldError("until", "range", "must be later than since")
+	}
+	return s.dao.List(ctx, q)
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/redact"
+)
+
+// Permission names one kind of operation. Denials carry the permission that
+// was missing so clients can tell callers what to ask for.
+type Permission string
+
+const (
+	PermEmployeeRead        Permission = "employee:read"
+	PermEmployeeReadDeleted Permission = "employee:read_deleted"
+	PermEmployeeCreate      Permission = "employee:create"
+	PermEmployeeUpdate      Permission = "employee:update"
+	PermEmployeeDelete      Permission = "employee:delete"
+	PermEmployeeRestore     Permission = "employee:restore"
+	PermEmployeePurge       Permission = "employee:purge"
+	PermEmployeeReassign    Permission = "employee:reassign"
+	PermDepartmentRead      Permission = "department:read"
+	PermDepartmentWrite     Permission = "department:write"
+	PermAuditRead           Permission = "audit:read"
+)
+
+// rolePermissions maps each role to the permissions it grants on every record.
+// auth.RoleSelf grants none globally; its row-level rules live in
+// authorizedEmployeeService.
+var rolePermissions = map[string][]Permission{
+	auth.RoleViewer: {PermEmployeeRead, PermDepartmentRead},
+	auth.RoleEditor: {
+		PermEmployeeRead, PermEmployeeReadDeleted, PermEmployeeCreate, PermEmployeeUpdate,
+		PermEmployeeDelete, PermEmployeeRestore, PermEmployeeReassign,
+		PermDepartmentRead, PermDepartmentWrite, PermAuditRead,
+	},
+	auth.RoleAdmin: {
+		PermEmployeeRead, PermEmployeeReadDeleted, PermEmployeeCreate, PermEmployeeUpdate,
+		PermEmployeeDelete, PermEmployeeRestore, PermEmployeeReassign, PermEmployeePurge,
+		PermDepartmentRead, PermDepartmentWrite, PermAuditRead,
+	},
+}
+
+// selfEditableFields are the columns a RoleSelf principal may PATCH on their
+// own record.
+var selfEditableFields = map[string]bool{"first_name": true, "last_name": true}
+
+// PermissionError is a 403: the caller is authenticated but lacks Permission.
+type PermissionError struct {
+	Permission Permission
+	Reason     string
+}
+
+func (e *PermissionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("missing permission %s: %s", e.Permission, e.Reason)
+	}
+	return fmt.Sprintf("missing permission %s", e.Permission)
+}
+
+// ClientMessage is safe to show: it only names the permission and the rule.
+func (e *PermissionError) ClientMessage() string { return e.Error() }
+
+// ErrorKind classifies every PermissionError as apperr.Forbidden.
+func (e *PermissionError) ErrorKind() apperr.Kind { return apperr.Forbidden }
+
+// ErrUnauthenticated is returned by the authorizing decorators when the
+// context carries no principal.
+var ErrUnauthenticated = apperr.New(apperr.Unauthenticated, "authentication required")
+
+// can reports whether any of p's roles grants perm.
+func can(p *auth.Principal, perm Permission) bool {
+	for _, r := range p.Roles {
+		for _, granted := range rolePermissions[r] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorize checks a permission that has no row-level exception.
+func authorize(ctx context.Context, perm Permission) (*auth.Principal, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if !can(p, perm) {
+		slog.InfoContext(ctx, "permission denied", "permission", string(perm), "roles", p.Roles)
+		return p, &PermissionError{Permission: perm}
+	}
+	return p, nil
+}
+
+type authorizedEmployeeService struct {
+	next EmployeeService
+}
+
+// NewAuthorizedEmployeeService wraps next so that every call is checked
+// against the auth.Principal in the context before it runs. Transports only
+// need to put the principal there; the rules are the same for all of them.
+//
+// Besides the role permissions, a principal with auth.RoleSelf and an
+// EmployeeID may read their own record and those of everyone below them in
+// the reporting line, and may PATCH the first and last name on their own.
+func NewAuthorizedEmployeeService(next EmployeeService) EmployeeService {
+	return &authorizedEmployeeService{next: next}
+}
+
+// authorizeRead allows reading employee id through employee:read or the
+// row-level self/manager rules.
+func (s *authorizedEmployeeService) authorizeRead(ctx context.Context, id int64) error {
+	p, err := authorize(ctx, PermEmployeeRead)
+	if err == nil || p == nil || !p.HasRole(auth.RoleSelf) || p.EmployeeID == 0 {
+		return err
+	}
+	if id == p.EmployeeID {
+		return nil
+	}
+	chain, cerr := s.next.GetChain(ctx, id)
+	if cerr != nil {
+		if apperr.Is(cerr, apperr.NotFound) {
+			// do not reveal whether a record the caller may not see exists
+			return err
+		}
+		return cerr
+	}
+	for _, m := range chain {
+		if m.ID == p.EmployeeID && m.DeletedAt == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *authorizedEmployeeService) CreateEmployee(ctx context.Context, in *model.Employee) (*model.Employee, error) {
+	if _, err := authorize(ctx, PermEmployeeCreate); err != nil {
+		return nil, err
+	}
+	return s.next.CreateEmployee(ctx, in)
+}
+
+func (s *authorizedEmployeeService) UpdateEmployee(ctx context.Context, in *model.Employee) (*model.Employee, error) {
+	if _, err := authorize(ctx, PermEmployeeUpdate); err != nil {
+		return nil, err
+	}
+	return s.next.UpdateEmployee(ctx, in)
+}
+
+func (s *authorizedEmployeeService) GetEmployee(ctx context.Context, id int64) (*model.Employee, error) {
+	if err := s.authorizeRead(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.next.GetEmployee(ctx, id)
+}
+
+func (s *authorizedEmployeeService) GetEmployeeAsOf(ctx context.Context, id int64, at time.Time) (*model.Employee, error) {
+	if err := s.authorizeRead(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.next.GetEmployeeAsOf(ctx, id, at)
+}
+
+func (s *authorizedEmployeeService) ListEmployees(ctx context.Context, q *model.EmployeeQuery) (*model.EmployeePage, error) {
+	if err := authorizeList(ctx, q); err != nil {
+		return nil, err
+	}
+	return s.next.ListEmployees(ctx, q)
+}
+
+func (s *authorizedEmployeeService) SearchEmployees(ctx context.Context, q *model.SearchQuery) ([]*model.SearchHit, error) {
+	p, err := authorize(ctx, PermEmployeeRead)
+	if err != nil {
+		return nil, err
+	}
+	if q != nil {
+		if q = searchableBy(p, q); len(q.Fields) == 0 {
+			return []*model.SearchHit{}, nil
+		}
+	}
+	return s.next.SearchEmployees(ctx, q)
+}
+
+func (s *authorizedEmployeeService) ExportEmployees(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) error {
+	if err := authorizeList(ctx, q); err != nil {
+		return err
+	}
+	return s.next.ExportEmployees(ctx, q, fn)
+}
+
+// searchableBy narrows q to the fields p sees in full on every record: a hit
+// on a masked email would tell the caller what it is. The result names its
+// fields, none if p may search none of them.
+func searchableBy(p *auth.Principal, q *model.SearchQuery) *model.SearchQuery {
+	fields := q.Fields
+	if len(fields) == 0 {
+		fields = model.SearchFields
+	}
+	out := *q
+	out.Fields = make([]string, 0, len(fields))
+	for _, f := range fields {
+		if redact.Employees.Shows(p, 0, f) {
+			out.Fields = append(out.Fields, f)
+		}
+	}
+	return &out
+}
+
+// authorizeList checks listing or exporting the employees matching q. A
+// caller who sees emails masked may not filter or sort on them either: which
+// rows come back, in what order, and the cursor would give them away.
+func authorizeList(ctx context.Context, q *model.EmployeeQuery) error {
+	p, err := authorize(ctx, listPermission(q))
+	if err != nil || q == nil || redact.Employees.Shows(p, 0, "email") {
+		return err
+	}
+	reason := ""
+	if q.Email != "" {
+		reason = "filtering by email needs emails shown in full"
+	}
+	for _, f := range q.Sort {
+		if f.Field == "email" {
+			reason = "sorting by email needs emails shown in full"
+		}
+	}
+	if reason == "" {
+		return nil
+	}
+	slog.InfoContext(ctx, "permission denied", "permission", string(PermEmployeeRead), "roles", p.Roles, "reason", reason)
+	return &PermissionError{Permission: PermEmployeeRead, Reason: reason}
+}
+
+// listPermission is what listing or exporting the employees matching q
+// needs: seeing the trash takes more than seeing live records.
+func listPermission(q *model.EmployeeQuery) Permission {
+	if q != nil && q.Deleted != model.ExcludeDeleted {
+		return PermEmployeeReadDeleted
+	}
+	return PermEmployeeRead
+}
+
+func (s *authorizedEmployeeService) DeleteEmployee(ctx context.Context, id, version int64) error {
+	if _, err := authorize(ctx, PermEmployeeDelete); err != nil {
+		return err
+	}
+	return s.next.DeleteEmployee(ctx, id, version)
+}
+
+func (s *authorizedEmployeeService) RestoreEmployee(ctx context.Context, id, version int64) (*model.Employee, error) {
+	if _, err := authorize(ctx, PermEmployeeRestore); err != nil {
+		return nil, err
+	}
+	return s.next.RestoreEmployee(ctx, id, version)
+}
+
+func (s *authorizedEmployeeService) PurgeEmployee(ctx context.Context, id int64) error {
+	if _, err := authorize(ctx, PermEmployeePurge); err != nil {
+		return err
+	}
+	return s.next.PurgeEmployee(ctx, id)
+}
+
+// PatchEmployee lets a self principal through for their own record with the
+// set of editable fields attached to the context; the service enforces it once
+// the patch has been applied and the changed columns are known.
+func (s *authorizedEmployeeService) PatchEmployee(ctx context.Context, id, version int64, format PatchFormat, patch []byte) (*model.Employee, error) {
+	p, err := authorize(ctx, PermEmployeeUpdate)
+	if err != nil {
+		if p == nil || !p.HasRole(auth.RoleSelf) || p.EmployeeID != id {
+			return nil, err
+		}
+		ctx = withPatchableFields(ctx, selfEditableFields)
+	}
+	return s.next.PatchEmployee(ctx, id, version, format, patch)
+}
+
+func (s *authorizedEmployeeService) GetChain(ctx context.Context, id int64) ([]*model.Employee, error) {
+	if err := s.authorizeRead(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.next.GetChain(ctx, id)
+}
+
+func (s *authorizedEmployeeService) GetReports(ctx context.Context, id int64, depth int) ([]*model.OrgNode, error) {
+	if err := s.authorizeRead(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.next.GetReports(ctx, id, depth)
+}
+
+func (s *authorizedEmployeeService) GetOrgChart(ctx context.Context) ([]*model.OrgNode, error) {
+	if _, err := authorize(ctx, PermEmployeeRead); err != nil {
+		return nil, err
+	}
+	return s.next.GetOrgChart(ctx)
+}
+
+func (s *authorizedEmployeeService) ReassignReports(ctx context.Context, fromID int64, toID *int64) (int64, error) {
+	if _, err := authorize(ctx, PermEmployeeReassign); err != nil {
+		return 0, err
+	}
+	return s.next.ReassignReports(ctx, fromID, toID)
+}
+
+// Batch checks every operation's permission before anything runs. An atomic
+// batch with a denied operation fails as a whole; otherwise denied operations
+// are reported in their results and the rest run.
+func (s *authorizedEmployeeService) Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	denied := make(map[int]error)
+	allowed := make([]BatchOp, 0, len(ops))
+	index := make([]int, 0, len(ops))
+	for i, op := range ops {
+		if perm, ok := batchPermission(op.Op); ok && !can(p, perm) {
+			if atomic {
+				return nil, &BatchError{Index: i, Err: &PermissionError{Permission: perm}}
+			}
+			denied[i] = &PermissionError{Permission: perm}
+			continue
+		}
+		allowed = append(allowed, op)
+		index = append(index, i)
+	}
+
+	// an atomic batch only gets here with nothing denied, so its error
+	// indexes need no mapping
+	ran, err := s.next.Batch(ctx, allowed, atomic)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(ops))
+	for i, err := range denied {
+		results[i] = BatchResult{Index: i, Err: err}
+	}
+	for j, r := range ran {
+		r.Index = index[j]
+		results[r.Index] = r
+	}
+	return results, nil
+}
+
+// ImportEmployees may create and update any employee, so it needs both
+// permissions up front.
+func (s *authorizedEmployeeService) ImportEmployees(ctx context.Context, src *ImportReader, dryRun bool, emit func(*ImportRow) error) (*ImportSummary, error) {
+	for _, perm := range []Permission{PermEmployeeCreate, PermEmployeeUpdate} {
+		if _, err := authorize(ctx, perm); err != nil {
+			return nil, err
+		}
+	}
+	return s.next.ImportEmployees(ctx, src, dryRun, emit)
+}
+
+type patchableKey struct{}
+
+// withPatchableFields limits PatchEmployee to changing the given columns.
+func withPatchableFields(ctx context.Context, fields map[string]bool) context.Context {
+	return context.WithValue(ctx, patchableKey{}, fields)
+}
+
+// checkPatchable returns a PermissionError naming the first changed column
+// (in name order) that the context does not allow.
+func checkPatchable(ctx context.Context, changes map[string]interface{}) error {
+	allowed, ok := ctx.Value(patchableKey{}).(map[string]bool)
+	if !ok {
+		return nil
+	}
+	cols := make([]string, 0, len(changes))
+	for c := range changes {
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+	for _, c := range cols {
+		if !allowed[c] {
+			return &PermissionError{Permission: PermEmployeeUpdate, Reason: c + " cannot be changed on your own record"}
+		}
+	}
+	return nil
+}
+
+type authorizedDepartmentService struct {
+	next DepartmentService
+}
+
+// NewAuthorizedDepartmentService wraps next with department:read and
+// department:write checks.
+func NewAuthorizedDepartmentService(next DepartmentService) DepartmentService {
+	return &authorizedDepartmentService{next: next}
+}
+
+func (s *authorizedDepartmentService) CreateDepartment(ctx context.Context, in *model.Department) (*model.Department, error) {
+	if _, err := authorize(ctx, PermDepartmentWrite); err != nil {
+		return nil, err
+	}
+	return s.next.CreateDepartment(ctx, in)
+}
+
+func (s *authorizedDepartmentService) UpdateDepartment(ctx context.Context, in *model.Department) (*model.Department, error) {
+	if _, err := authorize(ctx, PermDepartmentWrite); err != nil {
+		return nil, err
+	}
+	return s.next.UpdateDepartment(ctx, in)
+}
+
+func (s *authorizedDepartmentService) GetDepartment(ctx context.Context, id int64) (*model.Department, error) {
+	if _, err := authorize(ctx, PermDepartmentRead); err != nil {
+		return nil, err
+	}
+	return s.next.GetDepartment(ctx, id)
+}
+
+func (s *authorizedDepartmentService) ListDepartments(ctx context.Context) ([]*model.Department, error) {
+	if _, err := authorize(ctx, PermDepartmentRead); err != nil {
+		return nil, err
+	}
+	return s.next.ListDepartments(ctx)
+}
+
+func (s *authorizedDepartmentService) DeleteDepartment(ctx context.Context, id int64) error {
+	if _, err := authorize(ctx, PermDepartmentWrite); err != nil {
+		return err
+	}
+	return s.next.DeleteDepartment(ctx, id)
+}
+
+type authorizedAuditService struct {
+	next AuditService
+}
+
+// NewAuthorizedAuditService wraps next with an audit:read check.
+func NewAuthorizedAuditService(next AuditService) AuditService {
+	return &authorizedAuditService{next: next}
+}
+
+func (s *authorizedAuditService) ListAudit(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	if _, err := authorize(ctx, PermAuditRead); err != nil {
+		return nil, err
+	}
+	return s.next.ListAudit(ctx, q)
+}
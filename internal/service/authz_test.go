@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func as(roles ...string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{Subject: "test", Roles: roles})
+}
+
+func asEmployee(id int64, roles ...string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{Subject: "test", Roles: roles, EmployeeID: id})
+}
+
+func assertDenied(t *testing.T, err error, perm Permission) {
+	t.Helper()
+	var perr *PermissionError
+	if assert.ErrorAs(t, err, &perr) {
+		assert.Equal(t, perm, perr.Permission)
+	}
+	assert.True(t, apperr.Is(err, apperr.Forbidden))
+}
+
+func TestAuthorizedEmployeeService_Roles(t *testing.T) {
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewAuthorizedEmployeeService(NewEmployeeService(mockDAO))
+	mockDAO.On("GetByID", mock.Anything, int64(1)).Return(&model.Employee{ID: 1}, nil)
+	mockDAO.On("Delete", mock.Anything, int64(1), int64(0)).Return(nil)
+	mockDAO.On("Purge", mock.Anything, int64(1)).Return(nil)
+
+	_, err := svc.GetEmployee(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = svc.GetEmployee(as(auth.RoleViewer), 1)
+	assert.NoError(t, err)
+	assertDenied(t, svc.DeleteEmployee(as(auth.RoleViewer), 1, 0), PermEmployeeDelete)
+	_, err = svc.ListEmployees(as(auth.RoleViewer), &model.EmployeeQuery{Deleted: model.OnlyDeleted})
+	assertDenied(t, err, PermEmployeeReadDeleted)
+	err = svc.ExportEmployees(as(auth.RoleViewer), &model.EmployeeQuery{Deleted: model.IncludeDeleted}, nil)
+	assertDenied(t, err, PermEmployeeReadDeleted)
+
+	assert.NoError(t, svc.DeleteEmployee(as(auth.RoleEditor), 1, 0))
+	assertDenied(t, svc.PurgeEmployee(as(auth.RoleEditor), 1), PermEmployeePurge)
+	assert.NoError(t, svc.PurgeEmployee(as(auth.RoleViewer, auth.RoleAdmin), 1))
+}
+
+func TestAuthorizedEmployeeService_EmailFilter(t *testing.T) {
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewAuthorizedEmployeeService(NewEmployeeService(mockDAO))
+	byEmail := &model.EmployeeQuery{Email: "ann@example.com"}
+	bySort := &model.EmployeeQuery{Sort: []model.SortField{{Field: "email"}}}
+	mockDAO.On("GetAll", mock.Anything, byEmail).Return(&model.EmployeePage{}, nil).Once()
+	mockDAO.On("Each", mock.Anything, bySort).Return(nil, nil).Once()
+
+	// a viewer sees emails masked; a hit or the order would reveal them
+	_, err := svc.ListEmployees(as(auth.RoleViewer), byEmail)
+	assertDenied(t, err, PermEmployeeRead)
+	_, err = svc.ListEmployees(asEmployee(2, auth.RoleSelf, auth.RoleViewer), bySort)
+	assertDenied(t, err, PermEmployeeRead)
+	err = svc.ExportEmployees(as(auth.RoleViewer), byEmail, func(*model.Employee) error { return nil })
+	assertDenied(t, err, PermEmployeeRead)
+
+	_, err = svc.ListEmployees(as(auth.RoleEditor), byEmail)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.ExportEmployees(as(auth.RoleAdmin), bySort, func(*model.Employee) error { return nil }))
+	mockDAO.AssertExpectations(t)
+}
+
+func TestAuthorizedEmployeeService_SearchFields(t *testing.T) {
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewAuthorizedEmployeeService(NewEmployeeService(mockDAO))
+	noEmail := []string{"first_name", "last_name", "position"}
+	mockDAO.On("Search", mock.Anything, &model.SearchQuery{Text: "ann", Fields: noEmail}).Return([]*model.SearchHit{}, nil).Once()
+	mockDAO.On("Search", mock.Anything, &model.SearchQuery{Text: "ann", Fields: model.SearchFields}).Return([]*model.SearchHit{}, nil).Once()
+
+	// a viewer sees emails masked, so must not find anyone by theirs
+	_, err := svc.SearchEmployees(as(auth.RoleViewer), &model.SearchQuery{Text: "ann"})
+	assert.NoError(t, err)
+	_, err = svc.SearchEmployees(as(auth.RoleEditor), &model.SearchQuery{Text: "ann"})
+	assert.NoError(t, err)
+
+	hits, err := svc.SearchEmployees(asEmployee(2, auth.RoleSelf, auth.RoleViewer), &model.SearchQuery{Text: "ann", Fields: []string{"email"}})
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+	mockDAO.AssertExpectations(t)
+}
+
+func TestAuthorizedEmployeeService_Self(t *testing.T) {
+	// 1 manages 2, who manages 3
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewAuthorizedEmployeeService(NewEmployeeService(mockDAO))
+	for _, id := range []int64{1, 2, 3} {
+		mockDAO.On("GetByID", mock.Anything, id).Return(&model.Employee{ID: id, FirstName: "F", LastName: "L", Email: "e@example.com", Version: 1}, nil)
+	}
+	mockDAO.On("Chain", mock.Anything, int64(1)).Return([]*model.Employee{}, nil)
+	mockDAO.On("Chain", mock.Anything, int64(3)).Return([]*model.Employee{{ID: 2}, {ID: 1}}, nil)
+	ctx := asEmployee(2, auth.RoleSelf)
+
+	t.Run("ReadOwnAndReports", func(t *testing.T) {
+		_, err := svc.GetEmployee(ctx, 2)
+		assert.NoError(t, err)
+		_, err = svc.GetEmployee(ctx, 3)
+		assert.NoError(t, err)
+		_, err = svc.GetEmployee(ctx, 1)
+		assertDenied(t, err, PermEmployeeRead)
+		_, err = svc.ListEmployees(ctx, nil)
+		assertDenied(t, err, PermEmployeeRead)
+	})
+
+	t.Run("SelfRoleRequired", func(t *testing.T) {
+		_, err := svc.GetEmployee(asEmployee(2), 3)
+		assertDenied(t, err, PermEmployeeRead)
+	})
+
+	t.Run("PatchOwnName", func(t *testing.T) {
+		mockDAO.On("UpdateFields", mock.Anything, int64(2), int64(1), map[string]interface{}{"first_name": "Bea"}).
+			Return(&model.Employee{ID: 2, FirstName: "Bea"}, nil).Once()
+		_, err := svc.PatchEmployee(ctx, 2, 0, MergePatch, []byte(`{"first_name":"Bea"}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("PatchOtherField", func(t *testing.T) {
+		_, err := svc.PatchEmployee(ctx, 2, 0, MergePatch, []byte(`{"first_name":"Bea","position":"CEO"}`))
+		assertDenied(t, err, PermEmployeeUpdate)
+		assert.Contains(t, apperr.Message(err), "position")
+	})
+
+	t.Run("PatchReport", func(t *testing.T) {
+		_, err := svc.PatchEmployee(ctx, 3, 0, MergePatch, []byte(`{"first_name":"Bea"}`))
+		assertDenied(t, err, PermEmployeeUpdate)
+	})
+}
+
+type auditLog []*model.AuditEntry
+
+func (l auditLog) List(ctx context.Context, q *model.AuditQuery) (*model.AuditPage, error) {
+	return &model.AuditPage{Items: l}, nil
+}
+
+func (l auditLog) Verify(ctx context.Context) (*dao.ChainHead, error) { return &dao.ChainHead{}, nil }
+
+func TestAuthorizedAuditService(t *testing.T) {
+	svc := NewAuthorizedAuditService(NewAuditService(auditLog{{ID: 1}}))
+
+	_, err := svc.ListAudit(as(auth.RoleViewer), &model.AuditQuery{})
+	assertDenied(t, err, PermAuditRead)
+	page, err := svc.ListAudit(as(auth.RoleEditor), &model.AuditQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+
+	now := time.Now()
+	_, err = svc.ListAudit(as(auth.RoleAdmin), &model.AuditQuery{Since: now, Until: now})
+	assert.True(t, apperr.Is(err, apperr.Validation))
+}
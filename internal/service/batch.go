@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/model"
+)
+
+// Batch operation names.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOp is one operation of a batch. Create takes Employee; update takes
+// ID and Employee and replaces the record like UpdateEmployee; delete takes
+// ID. Version is the version the client last saw (0 skips the check) and, for
+// updates, wins over Employee.Version.
+type BatchOp struct {
+	Op       string          `json:"op"`
+	ID       int64           `json:"id,omitempty"`
+	Version  int64           `json:"version,omitempty"`
+	Employee *model.Employee `json:"employee,omitempty"`
+}
+
+// BatchResult is the outcome of the operation at Index: the written employee
+// (nil for deletes) or Err.
+type BatchResult struct {
+	Index    int
+	Employee *model.Employee
+	Err      error
+}
+
+// BatchError fails an atomic batch: the operation at Index failed with Err and
+// nothing in the batch was written. Its kind is that of Err.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string { return fmt.Sprintf("operation %d: %v", e.Index, e.Err) }
+
+func (e *BatchError) Unwrap() error { return e.Err }
+
+// Batch runs ops in order through the same methods as single requests. With
+// atomic set they share one transaction and the first failure rolls all of
+// them back and is returned as a *BatchError. Otherwise each operation
+// commits on its own and failures are reported in its result.
+func (s *employeeService) Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	results := make([]BatchResult, len(ops))
+	if !atomic {
+		for i, op := range ops {
+			results[i] = s.runBatchOp(ctx, i, op)
+		}
+		return results, nil
+	}
+	err := s.dao.WithTx(ctx, func(tx dao.EmployeeDAO) error {
+		// the single-request methods join the batch transaction through
+		// a service bound to it
+		txs := *s
+		txs.dao = tx
+		for i, op := range ops {
+			results[i] = txs.runBatchOp(ctx, i, op)
+			if results[i].Err != nil {
+				return &BatchError{Index: i, Err: results[i].Err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *employeeService) runBatchOp(ctx context.Context, i int, op BatchOp) BatchResult {
+	res := BatchResult{Index: i}
+	if err := checkBatchOp(op); err != nil {
+		res.Err = err
+		return res
+	}
+	switch op.Op {
+	case BatchCreate:
+		res.Employee, res.Err = s.CreateEmployee(ctx, op.Employee)
+	case BatchUpdate:
+		in := *op.Employee
+		in.ID = op.ID
+		if op.Version != 0 {
+			in.Version = op.Version
+		}
+		res.Employee, res.Err = s.UpdateEmployee(ctx, &in)
+	case BatchDelete:
+		res.Err = s.DeleteEmployee(ctx, op.ID, op.Version)
+	}
+	return res
+}
+
+// checkBatchOp validates the shape of an operation before it runs.
+func checkBatchOp(op BatchOp) error {
+	switch op.Op {
+	case BatchCreate:
+		if op.Employee == nil {
+			return fieldError("employee", "required", "is required for create")
+		}
+	case BatchUpdate:
+		if op.ID < 1 {
+			return fieldError("id", "required", "is required for update")
+		}
+		if op.Employee == nil {
+			return fieldError("employee", "required", "is required for update")
+		}
+	case BatchDelete:
+		if op.ID < 1 {
+			return fieldError("id", "required", "is required for delete")
+		}
+	default:
+		return fieldError("op", "invalid", "must be one of create, update, delete")
+	}
+	return nil
+}
+
+// batchPermission is the permission an operation needs; unknown operations
+// need none here and fail validation instead.
+func batchPermission(op string) (Permission, bool) {
+	switch op {
+	case BatchCreate:
+		return PermEmployeeCreate, true
+	case BatchUpdate:
+		return PermEmployeeUpdate, true
+	case BatchDelete:
+		return PermEmployeeDelete, true
+	}
+	return "", false
+}
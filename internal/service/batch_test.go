@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func batchOps() []BatchOp {
+	return []BatchOp{
+		{Op: BatchCreate, Employee: &model.Employee{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com"}},
+		{Op: BatchDelete, ID: 7},
+		{Op: BatchUpdate, ID: 1, Version: 3, Employee: &model.Employee{FirstName: "Bob", LastName: "Ray", Email: "bob@example.com"}},
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("BestEffort", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("Create", ctx, mock.Anything).Return(&model.Employee{ID: 9}, nil)
+		mockDAO.On("Delete", ctx, int64(7), int64(0)).Return(apperr.New(apperr.NotFound, "record not found"))
+		mockDAO.On("Update", ctx, mock.MatchedBy(func(e *model.Employee) bool { return e.ID == 1 && e.Version == 3 })).
+			Return(&model.Employee{ID: 1, Version: 4}, nil)
+
+		results, err := svc.Batch(ctx, batchOps(), false)
+		assert.NoError(t, err)
+		if assert.Len(t, results, 3) {
+			assert.Equal(t, int64(9), results[0].Employee.ID)
+			assert.ErrorIs(t, results[1].Err, ErrNotFound)
+			assert.NoError(t, results[2].Err)
+			assert.Equal(t, 2, results[2].Index)
+		}
+		mockDAO.AssertExpectations(t)
+	})
+
+	t.Run("AtomicStopsAtFirstFailure", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("Create", ctx, mock.Anything).Return(&model.Employee{ID: 9}, nil)
+		mockDAO.On("Delete", ctx, int64(7), int64(0)).Return(apperr.New(apperr.NotFound, "record not found"))
+
+		results, err := svc.Batch(ctx, batchOps(), true)
+		assert.Nil(t, results)
+		var berr *BatchError
+		if assert.ErrorAs(t, err, &berr) {
+			assert.Equal(t, 1, berr.Index)
+		}
+		assert.True(t, apperr.Is(err, apperr.NotFound))
+		mockDAO.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("InvalidOperation", func(t *testing.T) {
+		svc := NewEmployeeService(new(MockEmployeeDAO))
+		results, err := svc.Batch(ctx, []BatchOp{{Op: "upsert"}, {Op: BatchUpdate, Employee: &model.Employee{}}}, false)
+		assert.NoError(t, err)
+		assert.True(t, apperr.Is(results[0].Err, apperr.Validation))
+		assert.Contains(t, apperr.Message(results[1].Err), "id")
+	})
+}
+
+func TestAuthorizedBatch(t *testing.T) {
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewAuthorizedEmployeeService(NewEmployeeService(mockDAO))
+	mockDAO.On("Create", mock.Anything, mock.Anything).Return(&model.Employee{ID: 9}, nil)
+	mockDAO.On("Delete", mock.Anything, int64(7), int64(0)).Return(nil)
+	mockDAO.On("Update", mock.Anything, mock.Anything).Return(&model.Employee{ID: 1}, nil)
+
+	_, err := svc.Batch(context.Background(), batchOps(), false)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	results, err := svc.Batch(as(auth.RoleEditor), batchOps(), true)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	// denied operations keep their place among those that ran
+	ops := []BatchOp{batchOps()[0], {Op: "upsert"}, batchOps()[1]}
+	results, err = svc.Batch(as(auth.RoleViewer), ops, false)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assertDenied(t, results[0].Err, PermEmployeeCreate)
+		assert.Equal(t, 1, results[1].Index)
+		assert.True(t, apperr.Is(results[1].Err, apperr.Validation))
+		assertDenied(t, results[2].Err, PermEmployeeDelete)
+		assert.Equal(t, 2, results[2].Index)
+	}
+
+	// atomic: one denial fails the batch before anything runs
+	mockDAO.Calls = nil
+	_, err = svc.Batch(as(auth.RoleViewer), ops, true)
+	var berr *BatchError
+	if assert.ErrorAs(t, err, &berr) {
+		assert.Equal(t, 0, berr.Index)
+	}
+	assertDenied(t, err, PermEmployeeCreate)
+	assert.Empty(t, mockDAO.Calls)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/model"
+)
+
+var ErrDepartmentNotFound = apperr.New(apperr.NotFound, "department not found")
+
+// DepartmentService manages departments. Membership is stored on the employee
+// (Employee.DepartmentID) and listed through EmployeeService.ListEmployees
+// with EmployeeQuery.DepartmentID set.
+//
+// Methods:
+//   - CreateDepartment: Adds a new department; names are unique.
+//   - UpdateDepartment: Renames or re-describes an existing department.
+//   - GetDepartment:    Fetches a department by ID.
+//   - ListDepartments:  Returns every department ordered by name.
+//   - DeleteDepartment: Removes a department; refused with apperr.Conflict
+//     while any employee still belongs to it.
+type DepartmentService interface {
+	CreateDepartment(ctx context.Context, in *model.Department) (*model.Department, error)
+	UpdateDepartment(ctx context.Context, in *model.Department) (*model.Department, error)
+	GetDepartment(ctx context.Context, id int64) (*model.Department, error)
+	ListDepartments(ctx context.Context) ([]*model.Department, error)
+	DeleteDepartment(ctx context.Context, id int64) error
+}
+
+type departmentService struct {
+	dao             dao.DepartmentDAO
+	createValidator Validator[*model.Department]
+	updateValidator Validator[*model.Department]
+}
+
+func NewDepartmentService(d dao.DepartmentDAO) DepartmentService {
+	shared := []Field[*model.Department]{
+		{Name: "name", Value: func(d *model.Department) interface{} { return d.Name }, Rules: []Rule{Required(), MaxLen(100)}},
+		{Name: "description", Value: func(d *model.Department) interface{} { return d.Description }, Rules: []Rule{MaxLen(1000)}},
+	}
+	readOnly := []Field[*model.Department]{
+		{Name: "id", Value: func(d *model.Department) interface{} { return d.ID }, Rules: []Rule{ReadOnly()}},
+		{Name: "created_at", Value: func(d *model.Department) interface{} { return d.CreatedAt }, Rules: []Rule{ReadOnly()}},
+		{Name: "updated_at", Value: func(d *model.Department) interface{} { return d.UpdatedAt }, Rules: []Rule{ReadOnly()}},
+	}
+	return &departmentService{
+		dao:             d,
+		createValidator: Validator[*model.Department]{Fields: append(shared, readOnly...)},
+		updateValidator: Validator[*model.Department]{Fields: shared},
+	}
+}
+
+func (s *departmentService) CreateDepartment(ctx context.Context, in *model.Department) (*model.Department, error) {
+	normalizeDepartment(in)
+	if err := s.createValidator.Validate(in); err != nil {
+		return nil, err
+	}
+	return s.dao.Create(ctx, in)
+}
+
+func (s *departmentService) UpdateDepartment(ctx context.Context, in *model.Department) (*model.Department, error) {
+	normalizeDepartment(in)
+	if err := s.updateValidator.Validate(in); err != nil {
+		return nil, err
+	}
+	out, err := s.dao.Update(ctx, in)
+	if err != nil {
+		return nil, departmentNotFound(err)
+	}
+	return out, nil
+}
+
+func (s *departmentService) GetDepartment(ctx context.Context, id int64) (*model.Department, error) {
+	d, err := s.dao.GetByID(ctx, id)
+	if err != nil {
+		return nil, departmentNotFound(err)
+	}
+	return d, nil
+}
+
+func (s *departmentService) ListDepartments(ctx context.Context) ([]*model.Department, error) {
+	return s.dao.GetAll(ctx)
+}
+
+func (s *departmentService) DeleteDepartment(ctx context.Context, id int64) error {
+	return departmentNotFound(s.dao.Delete(ctx, id))
+}
+
+func departmentNotFound(err error) error {
+	if apperr.Is(err, apperr.NotFound) {
+		return ErrDepartmentNotFound
+	}
+	return err
+}
+
+func normalizeDepartment(d *model.Department) {
+	d.Name = strings.TrimSpace(d.Name)
+	d.Description = strings.TrimSpace(d.Description)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDepartmentDAO is a mock implementation of dao.DepartmentDAO
+type MockDepartmentDAO struct {
+	mock.Mock
+}
+
+func (m *MockDepartmentDAO) Create(ctx context.Context, d *model.Department) (*model.Department, error) {
+	args := m.Called(ctx, d)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentDAO) Update(ctx context.Context, d *model.Department) (*model.Department, error) {
+	args := m.Called(ctx, d)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentDAO) GetByID(ctx context.Context, id int64) (*model.Department, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentDAO) GetAll(ctx context.Context) ([]*model.Department, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Department), args.Error(1)
+}
+
+func (m *MockDepartmentDAO) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDepartmentDAO) Headcounts(ctx context.Context) ([]*model.Headcount, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Headcount), args.Error(1)
+}
+
+func TestCreateDepartment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		mockDAO := new(MockDepartmentDAO)
+		svc := NewDepartmentService(mockDAO)
+		in := &model.Department{Name: "  Finance "}
+		mockDAO.On("Create", ctx, in).Return(&model.Department{ID: 1, Name: "Finance"}, nil)
+
+		out, err := svc.CreateDepartment(ctx, in)
+		assert.NoError(t, err)
+		assert.Equal(t, "Finance", in.Name, "name is trimmed before saving")
+		assert.Equal(t, int64(1), out.ID)
+	})
+
+	t.Run("NameRequired", func(t *testing.T) {
+		mockDAO := new(MockDepartmentDAO)
+		svc := NewDepartmentService(mockDAO)
+
+		_, err := svc.CreateDepartment(ctx, &model.Department{ID: 5})
+		var verr *ValidationError
+		if assert.ErrorAs(t, err, &verr) {
+			assert.ElementsMatch(t, []FieldError{
+				{Field: "name", Code: "required", Message: Required().Message},
+				{Field: "id", Code: "read_only", Message: ReadOnly().Message},
+			}, verr.Errors)
+		}
+		mockDAO.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteDepartment(t *testing.T) {
+	ctx := context.Background()
+	mockDAO := new(MockDepartmentDAO)
+	svc := NewDepartmentService(mockDAO)
+	nonEmpty := apperr.New(apperr.Conflict, "department still has employees")
+	mockDAO.On("Delete", ctx, int64(1)).Return(nonEmpty)
+	mockDAO.On("Delete", ctx, int64(2)).Return(apperr.New(apperr.NotFound, "record not found"))
+
+	assert.Equal(t, nonEmpty, svc.DeleteDepartment(ctx, 1))
+	assert.ErrorIs(t, svc.DeleteDepartment(ctx, 2), ErrDepartmentNotFound)
+}
+
+func TestEmployeeDepartmentCheck(t *testing.T) {
+	ctx := context.Background()
+	missing := int64(9)
+	in := &model.Employee{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", DepartmentID: &missing}
+
+	mockDAO := new(MockEmployeeDAO)
+	depts := new(MockDepartmentDAO)
+	svc := NewEmployeeService(mockDAO, WithDepartments(depts))
+	depts.On("GetByID", ctx, int64(9)).Return(nil, apperr.New(apperr.NotFound, "record not found"))
+
+	_, err := svc.CreateEmployee(ctx, in)
+	var verr *ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, "department_id", verr.Errors[0].Field)
+	}
+	mockDAO.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
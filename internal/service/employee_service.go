@@ -2,20 +2,44 @@ package service
 
 import (
 	"context"
-	"errors"
+	"strings"
+	"time"
 
+	"emplopyee-app-go/internal/apperr"
 	"emplopyee-app-go/internal/dao"
 	"emplopyee-app-go/internal/model"
 )
 
-var ErrNotFound = errors.New("employee not found")
+var ErrNotFound = apperr.New(apperr.NotFound, "employee not found")
+
+// ErrEmailReserved is returned when an email still belongs to a soft-deleted
+// employee and reserved emails are enabled.
+var ErrEmailReserved = apperr.New(apperr.Conflict, "email belongs to a deleted employee")
+
+// ErrInvalidQuery is returned by ListEmployees for bad sort fields or cursors.
+var ErrInvalidQuery = dao.ErrInvalidQuery
+
+// ErrEmptySearch is returned by SearchEmployees for blank search text.
+var ErrEmptySearch = apperr.New(apperr.Invalid, "search text is required")
 
 type EmployeeService interface {
 	CreateEmployee(ctx context.Context, in *model.Employee) (*model.Employee, error)
 	UpdateEmployee(ctx context.Context, in *model.Employee) (*model.Employee, error)
 	GetEmployee(ctx context.Context, id int64) (*model.Employee, error)
-	ListEmployees(ctx context.Context) ([]*model.Employee, error)
-	DeleteEmployee(ctx context.Context, id int64) error
+	GetEmployeeAsOf(ctx context.Context, id int64, at time.Time) (*model.Employee, error)
+	ListEmployees(ctx context.Context, q *model.EmployeeQuery) (*model.EmployeePage, error)
+	SearchEmployees(ctx context.Context, q *model.SearchQuery) ([]*model.SearchHit, error)
+	DeleteEmployee(ctx context.Context, id, version int64) error
+	RestoreEmployee(ctx context.Context, id, version int64) (*model.Employee, error)
+	PurgeEmployee(ctx context.Context, id int64) error
+	PatchEmployee(ctx context.Context, id, version int64, format PatchFormat, patch []byte) (*model.Employee, error)
+	GetChain(ctx context.Context, id int64) ([]*model.Employee, error)
+	GetReports(ctx context.Context, id int64, depth int) ([]*model.OrgNode, error)
+	GetOrgChart(ctx context.Context) ([]*model.OrgNode, error)
+	ReassignReports(ctx context.Context, fromID int64, toID *int64) (int64, error)
+	Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error)
+	ExportEmployees(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) error
+	ImportEmployees(ctx context.Context, src *ImportReader, dryRun bool, emit func(*ImportRow) error) (*ImportSummary, error)
 }
 
 // EmployeeService defines business methods for managing employees.
@@ -27,10 +51,33 @@ type EmployeeService interface {
 //   - CreateEmployee: Adds a new employee to the system.
 //   - UpdateEmployee: Updates an existing employee (must exist).
 //   - GetEmployee:    Fetches an employee by unique ID.
-//   - ListEmployees:  Returns all employees, ordered by ID descending.
-//   - DeleteEmployee: Removes an employee by ID (must exist).
+//   - GetEmployeeAsOf: Fetches an employee as they were at a past instant.
+//   - ListEmployees:  Returns one filtered, sorted page of employees (ID descending by default);
+//     with EmployeeQuery.AsOf set, as they were at that instant.
+//   - SearchEmployees: Ranks live employees by a typo-tolerant prefix match on names, email and position.
+//   - DeleteEmployee: Moves an employee to the trash (soft delete).
+//   - RestoreEmployee: Brings a soft-deleted employee back.
+//   - PurgeEmployee:  Permanently removes an employee that is in the trash.
+//   - PatchEmployee:  Applies a merge patch or JSON Patch to an existing employee.
+//   - GetChain:       Lists an employee's managers up to the top of the org.
+//   - GetReports:     Returns the reporting tree below an employee.
+//   - GetOrgChart:    Returns the whole org as a nested tree.
+//   - ReassignReports: Moves all direct reports of one manager to another.
+//   - Batch:          Runs create/update/delete operations, all-or-nothing or one by one.
+//   - ExportEmployees: Streams every employee matching the list filters, unpaginated.
+//   - ImportEmployees: Upserts employees read from CSV by email, optionally as a dry run.
+//
+// Manager assignments are checked in the write transaction: the manager must
+// be a live employee, and an employee may not manage themselves directly or
+// through a chain of reports.
+//
+// Writes are optimistic: UpdateEmployee uses in.Version, and Delete/Patch take a
+// version argument. A non-zero version must match the stored one or the call
+// fails with apperr.PreconditionFailed. Zero means "whatever is current".
 //
 // Implementation wraps an EmployeeDAO, surfacing application-level errors such as ErrNotFound.
+// Any other DAO error (conflict, unavailable, timeout, ...) is passed through
+// unchanged so callers can classify it with the apperr package.
 
 // ErrNotFound is returned when no employee is found for a given query.
 
@@ -38,43 +85,221 @@ type EmployeeService interface {
 // this enables separation of business logic from data access logic.
 
 type employeeService struct {
-	dao dao.EmployeeDAO
+	dao             dao.EmployeeDAO
+	departments     dao.DepartmentDAO
+	createValidator Validator[*model.Employee]
+	updateValidator Validator[*model.Employee]
+	reserveEmails   bool
+}
+
+// Option configures optional behaviour of the EmployeeService.
+type Option func(*serviceOptions)
+
+type serviceOptions struct {
+	allowedPositions     []string
+	reserveDeletedEmails bool
+	departments          dao.DepartmentDAO
+}
+
+// WithAllowedPositions restricts Employee.Position to the given values. An
+// empty list (the default) accepts any position.
+func WithAllowedPositions(positions []string) Option {
+	return func(o *serviceOptions) { o.allowedPositions = positions }
+}
+
+// WithReservedDeletedEmails keeps the email of a soft-deleted employee
+// reserved, so no other employee can take it while the record is in the trash.
+// By default the email is released on delete.
+func WithReservedDeletedEmails(reserve bool) Option {
+	return func(o *serviceOptions) { o.reserveDeletedEmails = reserve }
+}
+
+// WithDepartments lets the service check that Employee.DepartmentID names an
+// existing department, reporting a field error instead of relying on the
+// foreign key (which only yields a generic conflict).
+func WithDepartments(d dao.DepartmentDAO) Option {
+	return func(o *serviceOptions) { o.departments = d }
 }
 
-func NewEmployeeService(d dao.EmployeeDAO) EmployeeService {
-	return &employeeService{dao: d}
+func NewEmployeeService(d dao.EmployeeDAO, opts ...Option) EmployeeService {
+	var o serviceOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	create, update := newEmployeeValidators(o.allowedPositions)
+	return &employeeService{
+		dao:             d,
+		departments:     o.departments,
+		createValidator: create,
+		updateValidator: update,
+		reserveEmails:   o.reserveDeletedEmails,
+	}
 }
 
 func (s *employeeService) CreateEmployee(ctx context.Context, in *model.Employee) (*model.Employee, error) {
-	return s.dao.Create(ctx, in)
+	normalizeEmployee(in)
+	if err := s.createValidator.Validate(in); err != nil {
+		return nil, err
+	}
+	var out *model.Employee
+	err := s.dao.WithTx(ctx, func(tx dao.EmployeeDAO) error {
+		if err := s.checkReferences(ctx, tx, in, nil); err != nil {
+			return err
+		}
+		var err error
+		out, err = tx.Create(ctx, in)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
 func (s *employeeService) UpdateEmployee(ctx context.Context, in *model.Employee) (*model.Employee, error) {
-	// check exists
-	_, err := s.dao.GetByID(ctx, in.ID)
+	normalizeEmployee(in)
+	if err := s.updateValidator.Validate(in); err != nil {
+		return nil, err
+	}
+	var out *model.Employee
+	err := s.dao.WithTx(ctx, func(tx dao.EmployeeDAO) error {
+		if err := s.checkReferences(ctx, tx, in, nil); err != nil {
+			return err
+		}
+		// existence and version are checked atomically by the DAO's UPDATE
+		var err error
+		out, err = tx.Update(ctx, in)
+		return notFound(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// checkReferences validates the fields of e that point at other records. With
+// a non-nil changed set (from a patch) only the changed columns are checked.
+func (s *employeeService) checkReferences(ctx context.Context, tx dao.EmployeeDAO, e *model.Employee, changed map[string]interface{}) error {
+	check := func(col string) bool {
+		if changed == nil {
+			return true
+		}
+		_, ok := changed[col]
+		return ok
+	}
+	if check("department_id") {
+		if err := s.checkDepartment(ctx, e.DepartmentID); err != nil {
+			return err
+		}
+	}
+	if check("email") && s.reserveEmails {
+		if err := checkEmailReserved(ctx, tx, e.Email); err != nil {
+			return err
+		}
+	}
+	if check("manager_id") {
+		if err := checkManager(ctx, tx, e.ID, e.ManagerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldError wraps a single FieldError as a ValidationError.
+func fieldError(field, code, message string) error {
+	return &ValidationError{Errors: []FieldError{{Field: field, Code: code, Message: message}}}
+}
+
+// checkDepartment reports an unknown department as a field error. It is a
+// no-op without WithDepartments or when no department is set.
+func (s *employeeService) checkDepartment(ctx context.Context, id *int64) error {
+	if s.departments == nil || id == nil {
+		return nil
+	}
+	_, err := s.departments.GetByID(ctx, *id)
+	if apperr.Is(err, apperr.NotFound) {
+		return fieldError("department_id", "unknown", "does not reference an existing department")
+	}
+	return err
+}
+
+// checkEmailReserved fails with ErrEmailReserved when email belongs to a
+// soft-deleted employee. Live duplicates are left to the unique index.
+func checkEmailReserved(ctx context.Context, d dao.EmployeeDAO, email string) error {
+	reserved, err := d.EmailReserved(ctx, email)
 	if err != nil {
-		return nil, ErrNotFound
+		return err
+	}
+	if reserved {
+		return ErrEmailReserved
 	}
-	return s.dao.Update(ctx, in)
+	return nil
 }
 
 func (s *employeeService) GetEmployee(ctx context.Context, id int64) (*model.Employee, error) {
 	e, err := s.dao.GetByID(ctx, id)
 	if err != nil {
-		return nil, ErrNotFound
+		return nil, notFound(err)
+	}
+	return e, nil
+}
+
+func (s *employeeService) GetEmployeeAsOf(ctx context.Context, id int64, at time.Time) (*model.Employee, error) {
+	e, err := s.dao.GetAsOf(ctx, id, at)
+	if err != nil {
+		return nil, notFound(err)
 	}
 	return e, nil
 }
 
-func (s *employeeService) ListEmployees(ctx context.Context) ([]*model.Employee, error) {
-	return s.dao.GetAll(ctx)
+func (s *employeeService) ListEmployees(ctx context.Context, q *model.EmployeeQuery) (*model.EmployeePage, error) {
+	if q == nil {
+		q = &model.EmployeeQuery{}
+	}
+	return s.dao.GetAll(ctx, q)
+}
+
+// SearchEmployees returns up to q.Limit employees matching q.Text, best first;
+// see dao.EmployeeDAO.Search.
+func (s *employeeService) SearchEmployees(ctx context.Context, q *model.SearchQuery) ([]*model.SearchHit, error) {
+	if q == nil || strings.TrimSpace(q.Text) == "" {
+		return nil, ErrEmptySearch
+	}
+	return s.dao.Search(ctx, q)
+}
+
+// ExportEmployees streams every employee matching q's filters to fn; see
+// dao.EmployeeDAO.Each.
+func (s *employeeService) ExportEmployees(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) error {
+	if q == nil {
+		q = &model.EmployeeQuery{}
+	}
+	return s.dao.Each(ctx, q, fn)
 }
 
-func (s *employeeService) DeleteEmployee(ctx context.Context, id int64) error {
-	// verify exists
-	_, err := s.dao.GetByID(ctx, id)
+func (s *employeeService) DeleteEmployee(ctx context.Context, id, version int64) error {
+	return notFound(s.dao.Delete(ctx, id, version))
+}
+
+// RestoreEmployee fails with Conflict when, with released emails, another
+// employee has taken the email in the meantime.
+func (s *employeeService) RestoreEmployee(ctx context.Context, id, version int64) (*model.Employee, error) {
+	out, err := s.dao.Restore(ctx, id, version)
 	if err != nil {
+		return nil, notFound(err)
+	}
+	return out, nil
+}
+
+func (s *employeeService) PurgeEmployee(ctx context.Context, id int64) error {
+	return notFound(s.dao.Purge(ctx, id))
+}
+
+// notFound replaces a DAO not-found error with ErrNotFound and leaves every
+// other error untouched.
+func notFound(err error) error {
+	if apperr.Is(err, apperr.NotFound) {
 		return ErrNotFound
 	}
-	return s.dao.Delete(ctx, id)
+	return err
 }
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/dao"
 	"emplopyee-app-go/internal/model"
 
 	"github.com/stretchr/testify/assert"
@@ -40,19 +44,116 @@ func (m *MockEmployeeDAO) GetByID(ctx context.Context, id int64) (*model.Employe
 	return args.Get(0).(*model.Employee), args.Error(1)
 }
 
-func (m *MockEmployeeDAO) GetAll(ctx context.Context) ([]*model.Employee, error) {
-	args := m.Called(ctx)
+func (m *MockEmployeeDAO) GetByEmail(ctx context.Context, email string) (*model.Employee, error) {
+	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*model.Employee), args.Error(1)
+	return args.Get(0).(*model.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) GetAsOf(ctx context.Context, id int64, at time.Time) (*model.Employee, error) {
+	args := m.Called(ctx, id, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) GetAll(ctx context.Context, q *model.EmployeeQuery) (*model.EmployeePage, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.EmployeePage), args.Error(1)
+}
+
+// Each passes the employees the mock returns for q to fn.
+func (m *MockEmployeeDAO) Each(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) error {
+	args := m.Called(ctx, q)
+	if list, ok := args.Get(0).([]*model.Employee); ok {
+		for _, e := range list {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
 }
 
-func (m *MockEmployeeDAO) Delete(ctx context.Context, id int64) error {
+func (m *MockEmployeeDAO) Search(ctx context.Context, q *model.SearchQuery) ([]*model.SearchHit, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.SearchHit), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) Delete(ctx context.Context, id, version int64) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeDAO) UpdateFields(ctx context.Context, id, version int64, fields map[string]interface{}) (*model.Employee, error) {
+	args := m.Called(ctx, id, version, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) Restore(ctx context.Context, id, version int64) (*model.Employee, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) Purge(ctx context.Context, id int64) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockEmployeeDAO) EmailReserved(ctx context.Context, email string) (bool, error) {
+	args := m.Called(ctx, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) Chain(ctx context.Context, id int64) ([]*model.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) Reports(ctx context.Context, id int64, depth int) ([]*model.OrgNode, error) {
+	args := m.Called(ctx, id, depth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.OrgNode), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) OrgChart(ctx context.Context) ([]*model.OrgNode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.OrgNode), args.Error(1)
+}
+
+func (m *MockEmployeeDAO) ReassignReports(ctx context.Context, fromID int64, toID *int64) (int64, error) {
+	args := m.Called(ctx, fromID, toID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// WithTx runs fn against the mock itself; tests assert on the calls made inside.
+func (m *MockEmployeeDAO) WithTx(ctx context.Context, fn func(tx dao.EmployeeDAO) error) error {
+	return fn(m)
+}
+
 func TestCreateEmployee(t *testing.T) {
 	mockDAO := new(MockEmployeeDAO)
 	svc := NewEmployeeService(mockDAO)
@@ -88,44 +189,66 @@ func TestGetEmployee(t *testing.T) {
 	t.Run("NotFound", func(t *testing.T) {
 		mockDAO := new(MockEmployeeDAO)
 		svc := NewEmployeeService(mockDAO)
-		mockDAO.On("GetByID", ctx, int64(999)).Return(nil, errors.New("db error"))
+		mockDAO.On("GetByID", ctx, int64(999)).Return(nil, apperr.Wrap(apperr.NotFound, "record not found", sql.ErrNoRows))
 
 		result, err := svc.GetEmployee(ctx, 999)
 		assert.Error(t, err)
-		assert.Equal(t, ErrNotFound, err)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, result)
+		mockDAO.AssertExpectations(t)
+	})
+
+	t.Run("OtherErrorsPassThrough", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		dbErr := apperr.Wrap(apperr.Unavailable, "the database is unavailable", errors.New("sql: database is closed"))
+		mockDAO.On("GetByID", ctx, int64(1)).Return(nil, dbErr)
+
+		result, err := svc.GetEmployee(ctx, 1)
 		assert.Nil(t, result)
+		assert.NotErrorIs(t, err, ErrNotFound)
+		assert.True(t, apperr.Is(err, apperr.Unavailable))
 		mockDAO.AssertExpectations(t)
 	})
 }
 
 func TestUpdateEmployee(t *testing.T) {
 	ctx := context.Background()
-	input := &model.Employee{ID: 1, FirstName: "Jane"}
+	input := &model.Employee{ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
 
 	t.Run("Success", func(t *testing.T) {
 		mockDAO := new(MockEmployeeDAO)
 		svc := NewEmployeeService(mockDAO)
-		// First it checks if exists
-		mockDAO.On("GetByID", ctx, int64(1)).Return(&model.Employee{ID: 1}, nil)
-		// Then updates
+		// no read-before-write: the DAO checks existence in the UPDATE itself
 		mockDAO.On("Update", ctx, input).Return(input, nil)
 
 		result, err := svc.UpdateEmployee(ctx, input)
 		assert.NoError(t, err)
 		assert.Equal(t, input, result)
 		mockDAO.AssertExpectations(t)
+		mockDAO.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
 		mockDAO := new(MockEmployeeDAO)
 		svc := NewEmployeeService(mockDAO)
-		mockDAO.On("GetByID", ctx, int64(1)).Return(nil, errors.New("not found"))
+		mockDAO.On("Update", ctx, input).Return(nil, apperr.New(apperr.NotFound, "record not found"))
 
 		result, err := svc.UpdateEmployee(ctx, input)
 		assert.ErrorIs(t, err, ErrNotFound)
 		assert.Nil(t, result)
 		mockDAO.AssertExpectations(t)
 	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		stale := &model.Employee{ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Version: 2}
+		mockDAO.On("Update", ctx, stale).Return(nil, apperr.New(apperr.PreconditionFailed, "employee was modified by another request"))
+
+		_, err := svc.UpdateEmployee(ctx, stale)
+		assert.True(t, apperr.Is(err, apperr.PreconditionFailed), "got %v", err)
+	})
 }
 
 func TestDeleteEmployee(t *testing.T) {
@@ -134,10 +257,9 @@ func TestDeleteEmployee(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockDAO := new(MockEmployeeDAO)
 		svc := NewEmployeeService(mockDAO)
-		mockDAO.On("GetByID", ctx, int64(1)).Return(&model.Employee{ID: 1}, nil)
-		mockDAO.On("Delete", ctx, int64(1)).Return(nil)
+		mockDAO.On("Delete", ctx, int64(1), int64(4)).Return(nil)
 
-		err := svc.DeleteEmployee(ctx, 1)
+		err := svc.DeleteEmployee(ctx, 1, 4)
 		assert.NoError(t, err)
 		mockDAO.AssertExpectations(t)
 	})
@@ -145,9 +267,9 @@ func TestDeleteEmployee(t *testing.T) {
 	t.Run("NotFound", func(t *testing.T) {
 		mockDAO := new(MockEmployeeDAO)
 		svc := NewEmployeeService(mockDAO)
-		mockDAO.On("GetByID", ctx, int64(1)).Return(nil, errors.New("not found"))
+		mockDAO.On("Delete", ctx, int64(1), int64(0)).Return(apperr.New(apperr.NotFound, "record not found"))
 
-		err := svc.DeleteEmployee(ctx, 1)
+		err := svc.DeleteEmployee(ctx, 1, 0)
 		assert.ErrorIs(t, err, ErrNotFound)
 		mockDAO.AssertExpectations(t)
 	})
@@ -158,15 +280,298 @@ func TestListEmployees(t *testing.T) {
 	svc := NewEmployeeService(mockDAO)
 	ctx := context.Background()
 
-	expected := []*model.Employee{
-		{ID: 1, FirstName: "John"},
-		{ID: 2, FirstName: "Jane"},
+	q := &model.EmployeeQuery{Limit: 2, Position: "Engineer"}
+	expected := &model.EmployeePage{
+		Items: []*model.Employee{
+			{ID: 1, FirstName: "John"},
+			{ID: 2, FirstName: "Jane"},
+		},
+		Total:      3,
+		NextCursor: "abc",
 	}
 
-	mockDAO.On("GetAll", ctx).Return(expected, nil)
+	mockDAO.On("GetAll", ctx, q).Return(expected, nil)
 
-	result, err := svc.ListEmployees(ctx)
+	result, err := svc.ListEmployees(ctx, q)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 	mockDAO.AssertExpectations(t)
 }
+
+func TestSearchEmployees(t *testing.T) {
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewEmployeeService(mockDAO)
+	ctx := context.Background()
+
+	expected := []*model.SearchHit{
+		{Employee: &model.Employee{ID: 1, FirstName: "Ann"}, Rank: 2.5, Highlights: map[string]string{"first_name": "\x02Ann\x03"}},
+	}
+	q := &model.SearchQuery{Text: "an", Limit: 10}
+	mockDAO.On("Search", ctx, q).Return(expected, nil)
+
+	result, err := svc.SearchEmployees(ctx, q)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	_, err = svc.SearchEmployees(ctx, &model.SearchQuery{Text: "  ", Limit: 10})
+	assert.Equal(t, ErrEmptySearch, err)
+	mockDAO.AssertExpectations(t)
+}
+
+func TestCreateEmployee_Validation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ReportsEveryField", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO, WithAllowedPositions([]string{"Engineer", "Manager"}))
+
+		input := &model.Employee{ID: 5, FirstName: "  ", Email: "not-an-email", Position: "Wizard", CreatedAt: time.Now()}
+		result, err := svc.CreateEmployee(ctx, input)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrValidation)
+		var verr *ValidationError
+		if assert.ErrorAs(t, err, &verr) {
+			codes := map[string]string{}
+			for _, f := range verr.Errors {
+				codes[f.Field] = f.Code
+			}
+			assert.Equal(t, map[string]string{
+				"id":         "read_only",
+				"first_name": "required",
+				"last_name":  "required",
+				"email":      "invalid_email",
+				"position":   "not_allowed",
+				"created_at": "read_only",
+			}, codes)
+		}
+		mockDAO.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("EmailFormats", func(t *testing.T) {
+		cases := map[string]bool{
+			"john@example.com":          true,
+			"john.o'neil+hr@sub.ex.org": true,
+			"John <john@example.com>":   false,
+			"john@":                     false,
+			"@example.com":              false,
+			"john doe@example.com":      false,
+		}
+		rule := Email()
+		for in, ok := range cases {
+			assert.Equal(t, ok, rule.Check(in), in)
+		}
+	})
+
+	t.Run("TrimsBeforeSaving", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		input := &model.Employee{FirstName: " John ", LastName: "Doe", Email: " john@example.com "}
+		mockDAO.On("Create", ctx, mock.MatchedBy(func(e *model.Employee) bool {
+			return e.FirstName == "John" && e.Email == "john@example.com"
+		})).Return(&model.Employee{ID: 1}, nil)
+
+		_, err := svc.CreateEmployee(ctx, input)
+		assert.NoError(t, err)
+		mockDAO.AssertExpectations(t)
+	})
+}
+
+func TestUpdateEmployee_Validation(t *testing.T) {
+	ctx := context.Background()
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewEmployeeService(mockDAO)
+
+	// a partial body must not blank the required columns
+	result, err := svc.UpdateEmployee(ctx, &model.Employee{ID: 1, Position: "Lead"})
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrValidation)
+	mockDAO.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestPatchEmployee(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stored := func() *model.Employee {
+		return &model.Employee{ID: 1, FirstName: "John", LastName: "Doe", Email: "john@example.com", Position: "Engineer", CreatedAt: created, UpdatedAt: created, Version: 3}
+	}
+
+	t.Run("MergePatchUpdatesOnlyChangedColumns", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		updated := stored()
+		updated.Position = "Lead"
+		mockDAO.On("GetByID", ctx, int64(1)).Return(stored(), nil)
+		mockDAO.On("UpdateFields", ctx, int64(1), int64(3), map[string]interface{}{"position": "Lead"}).Return(updated, nil)
+
+		result, err := svc.PatchEmployee(ctx, 1, 0, MergePatch, []byte(`{"position":"Lead"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, updated, result)
+		mockDAO.AssertExpectations(t)
+	})
+
+	t.Run("JSONPatchWithTest", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(1)).Return(stored(), nil)
+		mockDAO.On("UpdateFields", ctx, int64(1), int64(3), map[string]interface{}{"email": "jd@example.com"}).Return(stored(), nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 0, JSONPatch, []byte(`[
+			{"op":"test","path":"/email","value":"john@example.com"},
+			{"op":"replace","path":"/email","value":"jd@example.com"}
+		]`))
+		assert.NoError(t, err)
+		mockDAO.AssertExpectations(t)
+	})
+
+	t.Run("FailedTestIsConflict", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(1)).Return(stored(), nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 0, JSONPatch, []byte(`[{"op":"test","path":"/position","value":"Manager"}]`))
+		assert.True(t, apperr.Is(err, apperr.Conflict), "got %v", err)
+		mockDAO.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ResultIsValidated", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(1)).Return(stored(), nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 0, MergePatch, []byte(`{"first_name":null,"id":7}`))
+		var verr *ValidationError
+		if assert.ErrorAs(t, err, &verr) {
+			fields := []string{}
+			for _, f := range verr.Errors {
+				fields = append(fields, f.Field)
+			}
+			assert.ElementsMatch(t, []string{"id", "first_name"}, fields)
+		}
+		mockDAO.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UnknownFieldRejected", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(1)).Return(stored(), nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 0, MergePatch, []byte(`{"salary":1}`))
+		assert.True(t, apperr.Is(err, apperr.Invalid), "got %v", err)
+	})
+
+	t.Run("StaleIfMatch", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(1)).Return(stored(), nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 2, MergePatch, []byte(`{"position":"Lead"}`))
+		assert.True(t, apperr.Is(err, apperr.PreconditionFailed), "got %v", err)
+		mockDAO.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("VersionIsReadOnly", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(1)).Return(stored(), nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 0, MergePatch, []byte(`{"version":9}`))
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(9)).Return(nil, apperr.New(apperr.NotFound, "record not found"))
+
+		_, err := svc.PatchEmployee(ctx, 9, 0, MergePatch, []byte(`{"position":"Lead"}`))
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestRestoreEmployee(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		restored := &model.Employee{ID: 1, Version: 3}
+		mockDAO.On("Restore", ctx, int64(1), int64(2)).Return(restored, nil)
+
+		result, err := svc.RestoreEmployee(ctx, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, restored, result)
+	})
+
+	t.Run("NotInTrash", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("Restore", ctx, int64(1), int64(0)).Return(nil, apperr.New(apperr.NotFound, "record not found"))
+
+		_, err := svc.RestoreEmployee(ctx, 1, 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestPurgeEmployee(t *testing.T) {
+	ctx := context.Background()
+	mockDAO := new(MockEmployeeDAO)
+	svc := NewEmployeeService(mockDAO)
+	mockDAO.On("Purge", ctx, int64(1)).Return(nil)
+	mockDAO.On("Purge", ctx, int64(2)).Return(apperr.New(apperr.NotFound, "record not found"))
+
+	assert.NoError(t, svc.PurgeEmployee(ctx, 1))
+	assert.ErrorIs(t, svc.PurgeEmployee(ctx, 2), ErrNotFound)
+}
+
+func TestReservedDeletedEmails(t *testing.T) {
+	ctx := context.Background()
+	in := func() *model.Employee {
+		return &model.Employee{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	}
+
+	t.Run("ReleasedByDefault", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("Create", ctx, mock.Anything).Return(in(), nil)
+
+		_, err := svc.CreateEmployee(ctx, in())
+		assert.NoError(t, err)
+		mockDAO.AssertNotCalled(t, "EmailReserved", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateRejected", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO, WithReservedDeletedEmails(true))
+		mockDAO.On("EmailReserved", ctx, "jane@example.com").Return(true, nil)
+
+		_, err := svc.CreateEmployee(ctx, in())
+		assert.ErrorIs(t, err, ErrEmailReserved)
+		assert.True(t, apperr.Is(err, apperr.Conflict))
+		mockDAO.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateRejected", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO, WithReservedDeletedEmails(true))
+		mockDAO.On("EmailReserved", ctx, "jane@example.com").Return(true, nil)
+
+		e := in()
+		e.ID = 4
+		_, err := svc.UpdateEmployee(ctx, e)
+		assert.ErrorIs(t, err, ErrEmailReserved)
+		mockDAO.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("PatchOnlyChecksChangedEmail", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO, WithReservedDeletedEmails(true))
+		cur := &model.Employee{ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Version: 1}
+		mockDAO.On("GetByID", ctx, int64(1)).Return(cur, nil)
+		mockDAO.On("UpdateFields", ctx, int64(1), int64(1), map[string]interface{}{"position": "Lead"}).Return(cur, nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 0, MergePatch, []byte(`{"position":"Lead"}`))
+		assert.NoError(t, err)
+		mockDAO.AssertNotCalled(t, "EmailReserved", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/model"
+)
+
+// checkManager validates a manager assignment for employee id (0 when the
+// employee is being created). The walk up from the new manager runs in tx so
+// a concurrent reassignment cannot slip a cycle in between check and write.
+func checkManager(ctx context.Context, tx dao.EmployeeDAO, id int64, managerID *int64) error {
+	if managerID == nil {
+		return nil
+	}
+	if *managerID == id {
+		return fieldError("manager_id", "self", "an employee cannot be their own manager")
+	}
+	if _, err := tx.GetByID(ctx, *managerID); err != nil {
+		if apperr.Is(err, apperr.NotFound) {
+			return fieldError("manager_id", "unknown", "does not reference an existing employee")
+		}
+		return err
+	}
+	if id == 0 {
+		return nil
+	}
+	chain, err := tx.Chain(ctx, *managerID)
+	if err != nil {
+		return err
+	}
+	for _, m := range chain {
+		if m.ID == id {
+			return fieldError("manager_id", "cycle", "would make the employee report to themselves")
+		}
+	}
+	return nil
+}
+
+func (s *employeeService) GetChain(ctx context.Context, id int64) ([]*model.Employee, error) {
+	if _, err := s.dao.GetByID(ctx, id); err != nil {
+		return nil, notFound(err)
+	}
+	return s.dao.Chain(ctx, id)
+}
+
+// GetReports returns the reporting tree below id; depth 1 is direct reports
+// only and depth <= 0 means the whole subtree.
+func (s *employeeService) GetReports(ctx context.Context, id int64, depth int) ([]*model.OrgNode, error) {
+	if _, err := s.dao.GetByID(ctx, id); err != nil {
+		return nil, notFound(err)
+	}
+	return s.dao.Reports(ctx, id, depth)
+}
+
+func (s *employeeService) GetOrgChart(ctx context.Context) ([]*model.OrgNode, error) {
+	return s.dao.OrgChart(ctx)
+}
+
+// ReassignReports moves every live direct report of fromID to toID (nil makes
+// them top-level) in one transaction, typically before fromID leaves. toID is
+// validated like any manager assignment of fromID's reports: it must be live
+// and must not sit below fromID.
+func (s *employeeService) ReassignReports(ctx context.Context, fromID int64, toID *int64) (int64, error) {
+	var n int64
+	err := s.dao.WithTx(ctx, func(tx dao.EmployeeDAO) error {
+		if err := checkManager(ctx, tx, fromID, toID); err != nil {
+			return err
+		}
+		var err error
+		n, err = tx.ReassignReports(ctx, fromID, toID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestManagerValidation(t *testing.T) {
+	ctx := context.Background()
+	id := func(n int64) *int64 { return &n }
+	emp := func(managerID *int64) *model.Employee {
+		return &model.Employee{ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", ManagerID: managerID}
+	}
+	managerCode := func(t *testing.T, err error) string {
+		var verr *ValidationError
+		if !assert.ErrorAs(t, err, &verr) {
+			return ""
+		}
+		assert.Equal(t, "manager_id", verr.Errors[0].Field)
+		return verr.Errors[0].Code
+	}
+
+	t.Run("Self", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+
+		_, err := svc.UpdateEmployee(ctx, emp(id(1)))
+		assert.Equal(t, "self", managerCode(t, err))
+		mockDAO.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(9)).Return(nil, apperr.New(apperr.NotFound, "record not found"))
+
+		_, err := svc.UpdateEmployee(ctx, emp(id(9)))
+		assert.Equal(t, "unknown", managerCode(t, err))
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		// 3 reports to 2, which reports to 1: 1 may not report to 3
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(3)).Return(&model.Employee{ID: 3}, nil)
+		mockDAO.On("Chain", ctx, int64(3)).Return([]*model.Employee{{ID: 2}, {ID: 1}}, nil)
+
+		_, err := svc.UpdateEmployee(ctx, emp(id(3)))
+		assert.Equal(t, "cycle", managerCode(t, err))
+		mockDAO.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateSkipsCycleCheck", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		in := emp(id(3))
+		in.ID = 0
+		mockDAO.On("GetByID", ctx, int64(3)).Return(&model.Employee{ID: 3}, nil)
+		mockDAO.On("Create", ctx, in).Return(&model.Employee{ID: 4, ManagerID: id(3)}, nil)
+
+		_, err := svc.CreateEmployee(ctx, in)
+		assert.NoError(t, err)
+		mockDAO.AssertNotCalled(t, "Chain", mock.Anything, mock.Anything)
+	})
+
+	t.Run("PatchChecksChangedManager", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, int64(1)).Return(&model.Employee{ID: 1, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Version: 2}, nil)
+
+		_, err := svc.PatchEmployee(ctx, 1, 0, MergePatch, []byte(`{"manager_id":1}`))
+		assert.Equal(t, "self", managerCode(t, err))
+		mockDAO.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestReassignReports(t *testing.T) {
+	ctx := context.Background()
+	to := int64(5)
+
+	t.Run("Success", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, to).Return(&model.Employee{ID: to}, nil)
+		mockDAO.On("Chain", ctx, to).Return([]*model.Employee{}, nil)
+		mockDAO.On("ReassignReports", ctx, int64(2), &to).Return(int64(3), nil)
+
+		n, err := svc.ReassignReports(ctx, 2, &to)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+	})
+
+	t.Run("TargetBelowManager", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("GetByID", ctx, to).Return(&model.Employee{ID: to}, nil)
+		mockDAO.On("Chain", ctx, to).Return([]*model.Employee{{ID: 2}}, nil)
+
+		_, err := svc.ReassignReports(ctx, 2, &to)
+		assert.ErrorIs(t, err, ErrValidation)
+		mockDAO.AssertNotCalled(t, "ReassignReports", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ToTopLevel", func(t *testing.T) {
+		mockDAO := new(MockEmployeeDAO)
+		svc := NewEmployeeService(mockDAO)
+		mockDAO.On("ReassignReports", ctx, int64(2), (*int64)(nil)).Return(int64(1), nil)
+
+		n, err := svc.ReassignReports(ctx, 2, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/csvsafe"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/model"
+)
+
+// ImportUnchanged is the action of an import row that matched an employee
+// whose fields already had the row's values; nothing is written for it. The
+// other actions are BatchCreate and BatchUpdate.
+const ImportUnchanged = "unchanged"
+
+// importFields are the employee fields a CSV column can map onto.
+var importFields = map[string]bool{
+	"first_name":    true,
+	"last_name":     true,
+	"email":         true,
+	"position":      true,
+	"department_id": true,
+	"manager_id":    true,
+}
+
+// ParseColumnMap parses "column=field" pairs into a map from CSV column name
+// to employee field (its JSON name).
+func ParseColumnMap(pairs []string) (map[string]string, error) {
+	columns := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		col, field, ok := strings.Cut(pair, "=")
+		col, field = strings.TrimSpace(col), strings.TrimSpace(field)
+		if !ok || col == "" {
+			return nil, apperr.New(apperr.Invalid, fmt.Sprintf("invalid column mapping %q: want column=field", pair))
+		}
+		if !importFields[field] {
+			return nil, apperr.New(apperr.Invalid, fmt.Sprintf("invalid column mapping %q: unknown field %q", pair, field))
+		}
+		columns[col] = field
+	}
+	return columns, nil
+}
+
+// ImportReader reads employees from CSV one record at a time. The first record
+// is the header. A column maps onto the field named in the column map or,
+// failing that, the field its header names (case and the separators ' ' and
+// '-' aside, so "First Name" is first_name); other columns are ignored.
+type ImportReader struct {
+	csv    *csv.Reader
+	header []string
+	fields []string // field of each column, "" if ignored
+}
+
+// ImportRecord is one data record of an import.
+type ImportRecord struct {
+	Line   int      // line of the file the record starts on
+	Values []string // the record as read
+	fields map[string]string
+	err    error
+}
+
+// NewImportReader reads the header from r and resolves the column map
+// against it. Every column of the map must be in the header, no field may be
+// fed by two columns, and some column must map onto email, which is how rows
+// are matched to employees.
+func NewImportReader(r io.Reader, columns map[string]string) (*ImportReader, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, apperr.New(apperr.Invalid, "the CSV file is empty")
+	}
+	if err != nil {
+		return nil, apperr.Wrap(apperr.Invalid, "invalid CSV header", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // byte order mark
+	}
+
+	byName := make(map[string]string, len(columns))
+	for col, field := range columns {
+		byName[strings.ToLower(col)] = field
+	}
+	ir := &ImportReader{csv: cr, header: header, fields: make([]string, len(header))}
+	used := make(map[string]string) // field -> column
+	for i, col := range header {
+		name := strings.ToLower(strings.TrimSpace(col))
+		field, ok := byName[name]
+		if ok {
+			delete(byName, name)
+		} else if f := strings.NewReplacer(" ", "_", "-", "_").Replace(name); importFields[f] {
+			field = f
+		}
+		if field == "" {
+			continue
+		}
+		if prev, ok := used[field]; ok {
+			return nil, apperr.New(apperr.Invalid, fmt.Sprintf("columns %q and %q both map to %s", prev, col, field))
+		}
+		used[field] = col
+		ir.fields[i] = field
+	}
+	if len(byName) > 0 {
+		missing := make([]string, 0, len(byName))
+		for col := range byName {
+			missing = append(missing, col)
+		}
+		sort.Strings(missing)
+		return nil, apperr.New(apperr.Invalid, fmt.Sprintf("mapped column %q is not in the header", missing[0]))
+	}
+	if _, ok := used["email"]; !ok {
+		return nil, apperr.New(apperr.Invalid, "no column maps to email")
+	}
+	return ir, nil
+}
+
+// Header returns the header record.
+func (ir *ImportReader) Header() []string { return ir.header }
+
+// Next returns the next record, or io.EOF after the last. A record with the
+// wrong number of fields is returned with an error of its own; a malformed
+// file fails the read.
+func (ir *ImportReader) Next() (*ImportRecord, error) {
+	values, err := ir.csv.Read()
+	var perr *csv.ParseError
+	switch {
+	case err == io.EOF:
+		return nil, io.EOF
+	case errors.As(err, &perr) && errors.Is(perr.Err, csv.ErrFieldCount):
+		return &ImportRecord{
+			Line:   perr.StartLine,
+			Values: values,
+			err:    apperr.New(apperr.Invalid, fmt.Sprintf("has %d fields, the header has %d", len(values), len(ir.header))),
+		}, nil
+	case errors.As(err, &perr):
+		return nil, apperr.Wrap(apperr.Invalid, fmt.Sprintf("invalid CSV on line %d", perr.StartLine), err)
+	case err != nil:
+		return nil, err
+	}
+	line, _ := ir.csv.FieldPos(0)
+	rec := &ImportRecord{Line: line, Values: values, fields: make(map[string]string)}
+	for i, field := range ir.fields {
+		if field != "" {
+			rec.fields[field] = strings.TrimSpace(values[i])
+		}
+	}
+	return rec, nil
+}
+
+// apply sets the mapped fields of e from the record. An empty id clears it.
+func (rec *ImportRecord) apply(e *model.Employee) error {
+	var errs []FieldError
+	for field, v := range rec.fields {
+		switch field {
+		case "first_name":
+			e.FirstName = v
+		case "last_name":
+			e.LastName = v
+		case "email":
+			e.Email = v
+		case "position":
+			e.Position = v
+		case "department_id", "manager_id":
+			var id *int64
+			if v != "" {
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil || n < 1 {
+					errs = append(errs, FieldError{Field: field, Code: "invalid", Message: "must be a positive integer"})
+					continue
+				}
+				id = &n
+			}
+			if field == "department_id" {
+				e.DepartmentID = id
+			} else {
+				e.ManagerID = id
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// ImportRow is the outcome of one record of an import. Action is BatchCreate,
+// BatchUpdate or ImportUnchanged, and empty when the row failed before its
+// employee was looked up. ID is the employee written or matched; it is 0 for
+// creates in a dry run.
+type ImportRow struct {
+	Line   int
+	Values []string
+	Email  string
+	Action string
+	ID     int64
+	Err    error
+}
+
+// ImportSummary counts the rows of an import by outcome.
+type ImportSummary struct {
+	DryRun    bool `json:"dry_run"`
+	Rows      int  `json:"rows"`
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+	Failed    int  `json:"failed"`
+}
+
+// errDryRun rolls back the transaction of a dry-run row.
+var errDryRun = errors.New("dry run")
+
+// ImportEmployees upserts the employees read from src by email: a row whose
+// email belongs to a live employee updates the mapped fields of that employee
+// and leaves the others alone; any other row creates one. Each row runs in a
+// transaction of its own through CreateEmployee or UpdateEmployee and is
+// passed to emit as soon as it is done. With dryRun every row is written and
+// rolled back, so the outcome is exactly what the import would do.
+//
+// Rows that fail are counted and reported, and the import goes on. It stops
+// at a malformed file, an error from emit, or a database failure.
+func (s *employeeService) ImportEmployees(ctx context.Context, src *ImportReader, dryRun bool, emit func(*ImportRow) error) (*ImportSummary, error) {
+	sum := &ImportSummary{DryRun: dryRun}
+	seen := make(map[string]int) // email -> line of the first row with it
+	for {
+		rec, err := src.Next()
+		if err == io.EOF {
+			return sum, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := s.importRecord(ctx, rec, seen, dryRun)
+		if k := apperr.KindOf(row.Err); row.Err != nil && (k == apperr.Internal || k == apperr.Unavailable || k == apperr.Timeout) {
+			return nil, row.Err
+		}
+		sum.Rows++
+		switch {
+		case row.Err != nil:
+			sum.Failed++
+		case row.Action == BatchCreate:
+			sum.Created++
+		case row.Action == BatchUpdate:
+			sum.Updated++
+		default:
+			sum.Unchanged++
+		}
+		if err := emit(row); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *employeeService) importRecord(ctx context.Context, rec *ImportRecord, seen map[string]int, dryRun bool) *ImportRow {
+	row := &ImportRow{Line: rec.Line, Values: rec.Values, Email: rec.fields["email"]}
+	if rec.err != nil {
+		row.Err = rec.err
+		return row
+	}
+	if row.Email == "" {
+		row.Err = fieldError("email", "required", "is required to match the row to an employee")
+		return row
+	}
+	if first, ok := seen[row.Email]; ok {
+		row.Err = fieldError("email", "duplicate", fmt.Sprintf("is also on line %d", first))
+		return row
+	}
+	seen[row.Email] = rec.Line
+
+	err := s.dao.WithTx(ctx, func(tx dao.EmployeeDAO) error {
+		txs := *s
+		txs.dao = tx
+		op := BatchOp{Op: BatchCreate, Employee: &model.Employee{}}
+		cur, err := tx.GetByEmail(ctx, row.Email)
+		switch {
+		case err == nil:
+			row.ID = cur.ID
+			op = BatchOp{Op: BatchUpdate, ID: cur.ID, Version: cur.Version, Employee: cur}
+		case !apperr.Is(err, apperr.NotFound):
+			return err
+		}
+		row.Action = op.Op
+		var before model.Employee
+		if cur != nil {
+			before = *cur
+		}
+		if err := rec.apply(op.Employee); err != nil {
+			return err
+		}
+		normalizeEmployee(op.Employee)
+		if cur != nil && sameFields(&before, op.Employee) {
+			row.Action = ImportUnchanged
+			return nil
+		}
+		res := txs.runBatchOp(ctx, 0, op)
+		if res.Err != nil {
+			return res.Err
+		}
+		if !dryRun {
+			row.ID = res.Employee.ID
+			return nil
+		}
+		return errDryRun
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		row.Err = err
+	}
+	return row
+}
+
+// sameFields reports whether a and b agree on every importable field.
+func sameFields(a, b *model.Employee) bool {
+	sameID := func(x, y *int64) bool { return (x == nil) == (y == nil) && (x == nil || *x == *y) }
+	return a.FirstName == b.FirstName && a.LastName == b.LastName && a.Email == b.Email &&
+		a.Position == b.Position && sameID(a.DepartmentID, b.DepartmentID) && sameID(a.ManagerID, b.ManagerID)
+}
+
+// ImportErrorWriter writes the failed rows of an import as CSV: the header and
+// values of the input followed by "line" and "error" columns. Once fixed, the
+// file can be imported again as it is; the extra columns are ignored. Cells
+// that would read as formulas are escaped with csvsafe.Text, so they must be
+// unescaped when fixing them.
+type ImportErrorWriter struct {
+	csv    *csv.Writer
+	width  int
+	header []string
+}
+
+// NewImportErrorWriter returns a writer for the failed rows of the import
+// whose header is header. Nothing is written until the first row or Close.
+func NewImportErrorWriter(w io.Writer, header []string) *ImportErrorWriter {
+	return &ImportErrorWriter{csv: csv.NewWriter(w), width: len(header), header: header}
+}
+
+// Write adds row if it failed.
+func (ew *ImportErrorWriter) Write(row *ImportRow) error {
+	if row.Err == nil {
+		return nil
+	}
+	return ew.write(strconv.Itoa(row.Line), ImportErrorMessage(row.Err), row.Values)
+}
+
+// Abort records that the import stopped early with err.
+func (ew *ImportErrorWriter) Abort(err error) error {
+	return ew.write("", "import stopped: "+ImportErrorMessage(err), nil)
+}
+
+func (ew *ImportErrorWriter) write(line, msg string, values []string) error {
+	if err := ew.writeHeader(); err != nil {
+		return err
+	}
+	rec := make([]string, ew.width, ew.width+2)
+	copy(rec, values)
+	if err := ew.csv.Write(safeCSV(append(rec, line, msg))); err != nil {
+		return err
+	}
+	ew.csv.Flush()
+	return ew.csv.Error()
+}
+
+// Close writes the header if no row was written and flushes.
+func (ew *ImportErrorWriter) Close() error {
+	if err := ew.writeHeader(); err != nil {
+		return err
+	}
+	ew.csv.Flush()
+	return ew.csv.Error()
+}
+
+func (ew *ImportErrorWriter) writeHeader() error {
+	if ew.header == nil {
+		return nil
+	}
+	err := ew.csv.Write(safeCSV(append(append([]string{}, ew.header...), "line", "error")))
+	ew.header = nil
+	return err
+}
+
+// ImportErrorMessage describes a row error in one line: the client-safe
+// message, or each failing field for validation errors.
+func ImportErrorMessage(err error) string {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		parts := make([]string, len(verr.Errors))
+		for i, f := range verr.Errors {
+			parts[i] = f.Field + ": " + f.Message
+		}
+		return strings.Join(parts, "; ")
+	}
+	return apperr.Message(err)
+}
+
+// safeCSV escapes the cells of rec in place; see csvsafe.Text.
+func safeCSV(rec []string) []string {
+	for i, c := range rec {
+		rec[i] = csvsafe.Text(c)
+	}
+	return rec
+}
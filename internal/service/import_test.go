@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewImportReader(t *testing.T) {
+	t.Run("Mapping", func(t *testing.T) {
+		columns, err := ParseColumnMap([]string{"Given Name=first_name", " Mail = email"})
+		assert.NoError(t, err)
+		ir, err := NewImportReader(strings.NewReader("\ufeffGiven Name,Last-Name,MAIL,Notes\nAnn,Lee,ann@example.com,x\n"), columns)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"first_name", "last_name", "email", ""}, ir.fields)
+			rec, err := ir.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, 2, rec.Line)
+			assert.Equal(t, map[string]string{"first_name": "Ann", "last_name": "Lee", "email": "ann@example.com"}, rec.fields)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		csv     string
+		columns []string
+		msg     string
+	}{
+		"Empty":         {"", nil, "the CSV file is empty"},
+		"NoEmail":       {"first_name,last_name\n", nil, "no column maps to email"},
+		"UnknownColumn": {"email\n", []string{"Mail=email"}, `mapped column "mail" is not in the header`},
+		"TwoColumns":    {"email,Mail\n", []string{"Mail=email"}, `columns "email" and "Mail" both map to email`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			columns, err := ParseColumnMap(tc.columns)
+			assert.NoError(t, err)
+			_, err = NewImportReader(strings.NewReader(tc.csv), columns)
+			assert.True(t, apperr.Is(err, apperr.Invalid))
+			assert.Equal(t, tc.msg, apperr.Message(err))
+		})
+	}
+
+	_, err := ParseColumnMap([]string{"Salary=salary"})
+	assert.True(t, apperr.Is(err, apperr.Invalid))
+}
+
+func TestImportEmployees(t *testing.T) {
+	ctx := context.Background()
+	const file = "email,first_name,last_name,position,department_id\n" +
+		"ann@example.com,Ann,Lee,CTO,\n" + // new
+		"bob@example.com,Bob,Ray,Engineer,\n" + // exists, position changes
+		"cy@example.com,Cy,Fox,Lead,3\n" + // exists, unchanged
+		"ann@example.com,Ann,Lee,CTO,\n" + // repeated
+		"dee@example.com,Dee,Ng,,x\n" + // bad id
+		"eve@example.com\n" // short
+	dept, mgr := int64(3), int64(7)
+	setup := func() *MockEmployeeDAO {
+		m := new(MockEmployeeDAO)
+		m.On("GetByEmail", ctx, "ann@example.com").Return(nil, apperr.New(apperr.NotFound, "record not found"))
+		m.On("GetByEmail", ctx, "bob@example.com").
+			Return(&model.Employee{ID: 2, FirstName: "Bob", LastName: "Ray", Email: "bob@example.com", Position: "Intern", DepartmentID: &dept, ManagerID: &mgr, Version: 5}, nil)
+		m.On("GetByEmail", ctx, "cy@example.com").
+			Return(&model.Employee{ID: 3, FirstName: "Cy", LastName: "Fox", Email: "cy@example.com", Position: "Lead", DepartmentID: &dept, Version: 1}, nil)
+		m.On("GetByID", ctx, mgr).Return(&model.Employee{ID: mgr}, nil)
+		m.On("Chain", ctx, mgr).Return([]*model.Employee{}, nil)
+		m.On("GetByEmail", ctx, "dee@example.com").Return(nil, apperr.New(apperr.NotFound, "record not found"))
+		m.On("Create", ctx, mock.MatchedBy(func(e *model.Employee) bool { return e.Email == "ann@example.com" })).
+			Return(&model.Employee{ID: 10}, nil).Once()
+		// an empty id clears the department, the unmapped manager stays and
+		// the version read guards the update
+		m.On("Update", ctx, mock.MatchedBy(func(e *model.Employee) bool {
+			return e.ID == 2 && e.Position == "Engineer" && e.DepartmentID == nil && e.ManagerID == &mgr && e.Version == 5
+		})).Return(&model.Employee{ID: 2}, nil).Once()
+		return m
+	}
+	run := func(t *testing.T, m *MockEmployeeDAO, dryRun bool) ([]*ImportRow, *ImportSummary) {
+		ir, err := NewImportReader(strings.NewReader(file), nil)
+		if err != nil {
+			t.Fatalf("NewImportReader: %v", err)
+		}
+		var rows []*ImportRow
+		sum, err := NewEmployeeService(m).ImportEmployees(ctx, ir, dryRun, func(row *ImportRow) error {
+			rows = append(rows, row)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ImportEmployees: %v", err)
+		}
+		return rows, sum
+	}
+
+	t.Run("Upsert", func(t *testing.T) {
+		m := setup()
+		rows, sum := run(t, m, false)
+		assert.Equal(t, &ImportSummary{Rows: 6, Created: 1, Updated: 1, Unchanged: 1, Failed: 3}, sum)
+		if assert.Len(t, rows, 6) {
+			assert.Equal(t, []interface{}{BatchCreate, int64(10)}, []interface{}{rows[0].Action, rows[0].ID})
+			assert.Equal(t, []interface{}{BatchUpdate, int64(2)}, []interface{}{rows[1].Action, rows[1].ID})
+			assert.Equal(t, []interface{}{ImportUnchanged, int64(3)}, []interface{}{rows[2].Action, rows[2].ID})
+			assert.Equal(t, "email: is also on line 2", ImportErrorMessage(rows[3].Err))
+			assert.Equal(t, "department_id: must be a positive integer", ImportErrorMessage(rows[4].Err))
+			assert.Equal(t, 7, rows[5].Line)
+			assert.True(t, apperr.Is(rows[5].Err, apperr.Invalid))
+		}
+		m.AssertExpectations(t)
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		m := setup()
+		rows, sum := run(t, m, true)
+		assert.Equal(t, &ImportSummary{DryRun: true, Rows: 6, Created: 1, Updated: 1, Unchanged: 1, Failed: 3}, sum)
+		// the writes ran and were rolled back; a created employee has no id
+		assert.Equal(t, int64(0), rows[0].ID)
+		assert.Equal(t, int64(2), rows[1].ID)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("ErrorReport", func(t *testing.T) {
+		rows, _ := run(t, setup(), true)
+		var buf bytes.Buffer
+		ew := NewImportErrorWriter(&buf, strings.Split("email,first_name,last_name,position,department_id", ","))
+		for _, row := range rows {
+			assert.NoError(t, ew.Write(row))
+		}
+		assert.NoError(t, ew.Close())
+		assert.Equal(t, "email,first_name,last_name,position,department_id,line,error\n"+
+			"ann@example.com,Ann,Lee,CTO,,5,email: is also on line 2\n"+
+			"dee@example.com,Dee,Ng,,x,6,department_id: must be a positive integer\n"+
+			`eve@example.com,,,,,7,"has 1 fields, the header has 5"`+"\n", buf.String())
+	})
+
+	t.Run("ErrorReportEscapesFormulas", func(t *testing.T) {
+		var buf bytes.Buffer
+		ew := NewImportErrorWriter(&buf, []string{"email", "=cmd"})
+		assert.NoError(t, ew.Write(&ImportRow{Line: 2, Values: []string{"=1+1", "@SUM(A1)"}, Err: apperr.New(apperr.Invalid, "-bad")}))
+		assert.NoError(t, ew.Close())
+		assert.Equal(t, "email,'=cmd,line,error\n'=1+1,'@SUM(A1),2,'-bad\n", buf.String())
+	})
+
+	t.Run("Authorization", func(t *testing.T) {
+		svc := NewAuthorizedEmployeeService(NewEmployeeService(new(MockEmployeeDAO)))
+		ir, _ := NewImportReader(strings.NewReader(file), nil)
+		_, err := svc.ImportEmployees(as(auth.RoleViewer), ir, true, func(*ImportRow) error { return nil })
+		assertDenied(t, err, PermEmployeeCreate)
+	})
+}
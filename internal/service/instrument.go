@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/metrics"
+	"emplopyee-app-go/internal/model"
+)
+
+type instrumentedEmployeeService struct {
+	next     EmployeeService
+	duration *metrics.HistogramVec
+	errors   *metrics.CounterVec
+}
+
+// NewInstrumentedEmployeeService wraps next so that every call is timed in
+// service_duration_seconds{method} and every failure counted in
+// service_errors_total{method,kind}, kind being the apperr kind. Wrapped
+// around the authorizing decorator, denials count as "forbidden" and
+// "unauthenticated" errors.
+func NewInstrumentedEmployeeService(next EmployeeService, reg *metrics.Registry) EmployeeService {
+	return &instrumentedEmployeeService{
+		next: next,
+		duration: reg.Histogram("service_duration_seconds",
+			"Time spent in EmployeeService methods.", metrics.DefaultBuckets, "method"),
+		errors: reg.Counter("service_errors_total",
+			"EmployeeService calls that failed, by error kind.", "method", "kind"),
+	}
+}
+
+// observe starts timing a call of method; the returned func, deferred with
+// the address of the call's error, records it.
+func (s *instrumentedEmployeeService) observe(method string) func(*error) {
+	start := time.Now()
+	return func(err *error) {
+		s.duration.Observe(time.Since(start).Seconds(), method)
+		if *err != nil {
+			s.errors.Inc(method, apperr.KindOf(*err).String())
+		}
+	}
+}
+
+func (s *instrumentedEmployeeService) CreateEmployee(ctx context.Context, in *model.Employee) (e *model.Employee, err error) {
+	defer s.observe("CreateEmployee")(&err)
+	return s.next.CreateEmployee(ctx, in)
+}
+
+func (s *instrumentedEmployeeService) UpdateEmployee(ctx context.Context, in *model.Employee) (e *model.Employee, err error) {
+	defer s.observe("UpdateEmployee")(&err)
+	return s.next.UpdateEmployee(ctx, in)
+}
+
+func (s *instrumentedEmployeeService) GetEmployee(ctx context.Context, id int64) (e *model.Employee, err error) {
+	defer s.observe("GetEmployee")(&err)
+	return s.next.GetEmployee(ctx, id)
+}
+
+func (s *instrumentedEmployeeService) GetEmployeeAsOf(ctx context.Context, id int64, at time.Time) (e *model.Employee, err error) {
+	defer s.observe("GetEmployeeAsOf")(&err)
+	return s.next.GetEmployeeAsOf(ctx, id, at)
+}
+
+func (s *instrumentedEmployeeService) ListEmployees(ctx context.Context, q *model.EmployeeQuery) (page *model.EmployeePage, err error) {
+	defer s.observe("ListEmployees")(&err)
+	return s.next.ListEmployees(ctx, q)
+}
+
+func (s *instrumentedEmployeeService) SearchEmployees(ctx context.Context, q *model.SearchQuery) (hits []*model.SearchHit, err error) {
+	defer s.observe("SearchEmployees")(&err)
+	return s.next.SearchEmployees(ctx, q)
+}
+
+func (s *instrumentedEmployeeService) ExportEmployees(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) (err error) {
+	defer s.observe("ExportEmployees")(&err)
+	return s.next.ExportEmployees(ctx, q, fn)
+}
+
+func (s *instrumentedEmployeeService) DeleteEmployee(ctx context.Context, id, version int64) (err error) {
+	defer s.observe("DeleteEmployee")(&err)
+	return s.next.DeleteEmployee(ctx, id, version)
+}
+
+func (s *instrumentedEmployeeService) RestoreEmployee(ctx context.Context, id, version int64) (e *model.Employee, err error) {
+	defer s.observe("RestoreEmployee")(&err)
+	return s.next.RestoreEmployee(ctx, id, version)
+}
+
+func (s *instrumentedEmployeeService) PurgeEmployee(ctx context.Context, id int64) (err error) {
+	defer s.observe("PurgeEmployee")(&err)
+	return s.next.PurgeEmployee(ctx, id)
+}
+
+func (s *instrumentedEmployeeService) PatchEmployee(ctx context.Context, id, version int64, format PatchFormat, patch []byte) (e *model.Employee, err error) {
+	defer s.observe("PatchEmployee")(&err)
+	return s.next.PatchEmployee(ctx, id, version, format, patch)
+}
+
+func (s *instrumentedEmployeeService) GetChain(ctx context.Context, id int64) (chain []*model.Employee, err error) {
+	defer s.observe("GetChain")(&err)
+	return s.next.GetChain(ctx, id)
+}
+
+func (s *instrumentedEmployeeService) GetReports(ctx context.Context, id int64, depth int) (nodes []*model.OrgNode, err error) {
+	defer s.observe("GetReports")(&err)
+	return s.next.GetReports(ctx, id, depth)
+}
+
+func (s *instrumentedEmployeeService) GetOrgChart(ctx context.Context) (nodes []*model.OrgNode, err error) {
+	defer s.observe("GetOrgChart")(&err)
+	return s.next.GetOrgChart(ctx)
+}
+
+func (s *instrumentedEmployeeService) ReassignReports(ctx context.Context, fromID int64, toID *int64) (n int64, err error) {
+	defer s.observe("ReassignReports")(&err)
+	return s.next.ReassignReports(ctx, fromID, toID)
+}
+
+func (s *instrumentedEmployeeService) Batch(ctx context.Context, ops []BatchOp, atomic bool) (results []BatchResult, err error) {
+	defer s.observe("Batch")(&err)
+	return s.next.Batch(ctx, ops, atomic)
+}
+
+func (s *instrumentedEmployeeService) ImportEmployees(ctx context.Context, src *ImportReader, dryRun bool, emit func(*ImportRow) error) (sum *ImportSummary, err error) {
+	defer s.observe("ImportEmployees")(&err)
+	return s.next.ImportEmployees(ctx, src, dryRun, emit)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/metrics"
+	"emplopyee-app-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInstrumentedEmployeeService(t *testing.T) {
+	mockDAO := new(MockEmployeeDAO)
+	reg := metrics.NewRegistry()
+	svc := NewInstrumentedEmployeeService(NewAuthorizedEmployeeService(NewEmployeeService(mockDAO)), reg)
+	mockDAO.On("GetByID", mock.Anything, int64(1)).Return(&model.Employee{ID: 1}, nil)
+	mockDAO.On("GetByID", mock.Anything, int64(2)).Return(nil, apperr.New(apperr.NotFound, "record not found"))
+
+	_, err := svc.GetEmployee(as(auth.RoleViewer), 1)
+	assert.NoError(t, err)
+	_, err = svc.GetEmployee(as(auth.RoleViewer), 2)
+	assert.Error(t, err)
+	_, err = svc.GetEmployee(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Error(t, svc.DeleteEmployee(as(auth.RoleViewer), 1, 0))
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	assert.Contains(t, out, `service_duration_seconds_count{method="GetEmployee"} 3`)
+	assert.Contains(t, out, `service_duration_seconds_count{method="DeleteEmployee"} 1`)
+	assert.Contains(t, out, `service_errors_total{method="GetEmployee",kind="not_found"} 1`)
+	assert.Contains(t, out, `service_errors_total{method="GetEmployee",kind="unauthenticated"} 1`)
+	assert.Contains(t, out, `service_errors_total{method="DeleteEmployee",kind="forbidden"} 1`)
+}
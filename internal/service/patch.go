@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/dao"
+	"emplopyee-app-go/internal/jsonpatch"
+	"emplopyee-app-go/internal/model"
+)
+
+var errVersionMismatch = apperr.New(apperr.PreconditionFailed, "employee was modified by another request")
+
+// PatchFormat selects how PatchEmployee interprets the patch document.
+type PatchFormat int
+
+const (
+	MergePatch PatchFormat = iota + 1 // RFC 7396, application/merge-patch+json
+	JSONPatch                         // RFC 6902, application/json-patch+json
+)
+
+// PatchEmployee applies patch to the stored employee inside a transaction,
+// validates the result and writes back only the columns that changed.
+func (s *employeeService) PatchEmployee(ctx context.Context, id, version int64, format PatchFormat, patch []byte) (*model.Employee, error) {
+	var out *model.Employee
+	err := s.dao.WithTx(ctx, func(tx dao.EmployeeDAO) error {
+		cur, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return notFound(err)
+		}
+		if version != 0 && cur.Version != version {
+			return errVersionMismatch
+		}
+		next, errs, err := applyPatch(cur, format, patch)
+		if err != nil {
+			return err
+		}
+		normalizeEmployee(next)
+		if err := s.updateValidator.Validate(next); err != nil {
+			var verr *ValidationError
+			errors.As(err, &verr)
+			errs = append(errs, verr.Errors...)
+		}
+		if len(errs) > 0 {
+			return &ValidationError{Errors: errs}
+		}
+
+		changes := changedColumns(cur, next)
+		if len(changes) == 0 {
+			out = cur
+			return nil
+		}
+		if err := checkPatchable(ctx, changes); err != nil {
+			return err
+		}
+		if err := s.checkReferences(ctx, tx, next, changes); err != nil {
+			return err
+		}
+		out, err = tx.UpdateFields(ctx, id, cur.Version, changes)
+		return notFound(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// applyPatch runs the patch over the JSON form of cur. Server-managed fields
+// may be tested but not changed; attempts are returned as read_only field
+// errors. Unknown members are rejected outright.
+func applyPatch(cur *model.Employee, format PatchFormat, patch []byte) (*model.Employee, []FieldError, error) {
+	doc, err := json.Marshal(cur)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var patched []byte
+	switch format {
+	case MergePatch:
+		patched, err = jsonpatch.MergePatch(doc, patch)
+	case JSONPatch:
+		patched, err = jsonpatch.Apply(doc, patch)
+	default:
+		return nil, nil, fmt.Errorf("unknown patch format %d", format)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, jsonpatch.ErrTestFailed):
+			return nil, nil, apperr.Wrap(apperr.Conflict, "patch test operation failed", err)
+		case errors.Is(err, jsonpatch.ErrPathNotFound), errors.Is(err, jsonpatch.ErrInvalidPatch):
+			return nil, nil, apperr.Wrap(apperr.Invalid, err.Error(), err)
+		}
+		return nil, nil, apperr.Wrap(apperr.Invalid, "patch document is not valid JSON", err)
+	}
+
+	var next model.Employee
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return nil, nil, apperr.Wrap(apperr.Invalid, "patched document does not describe an employee: "+err.Error(), err)
+	}
+
+	var errs []FieldError
+	if next.ID != cur.ID {
+		errs = append(errs, FieldError{Field: "id", Code: "read_only", Message: ReadOnly().Message})
+	}
+	if !next.CreatedAt.Equal(cur.CreatedAt) {
+		errs = append(errs, FieldError{Field: "created_at", Code: "read_only", Message: ReadOnly().Message})
+	}
+	if !next.UpdatedAt.Equal(cur.UpdatedAt) {
+		errs = append(errs, FieldError{Field: "updated_at", Code: "read_only", Message: ReadOnly().Message})
+	}
+	if next.Version != cur.Version {
+		errs = append(errs, FieldError{Field: "version", Code: "read_only", Message: ReadOnly().Message})
+	}
+	if next.DeletedAt != nil {
+		errs = append(errs, FieldError{Field: "deleted_at", Code: "read_only", Message: ReadOnly().Message})
+	}
+	return &next, errs, nil
+}
+
+// changedColumns lists the updatable columns whose value differs.
+func changedColumns(cur, next *model.Employee) map[string]interface{} {
+	changes := map[string]interface{}{}
+	if cur.FirstName != next.FirstName {
+		changes["first_name"] = next.FirstName
+	}
+	if cur.LastName != next.LastName {
+		changes["last_name"] = next.LastName
+	}
+	if cur.Email != next.Email {
+		changes["email"] = next.Email
+	}
+	if cur.Position != next.Position {
+		changes["position"] = next.Position
+	}
+	if !equalID(cur.DepartmentID, next.DepartmentID) {
+		changes["department_id"] = next.DepartmentID
+	}
+	if !equalID(cur.ManagerID, next.ManagerID) {
+		changes["manager_id"] = next.ManagerID
+	}
+	return changes
+}
+
+func equalID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
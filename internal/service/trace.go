@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/tracing"
+)
+
+type tracedEmployeeService struct {
+	next   EmployeeService
+	tracer *tracing.Tracer
+}
+
+// NewTracedEmployeeService wraps next so that every call runs in a child span
+// of the request's, named "EmployeeService.<method>". A failed call marks its
+// span as an error and records the apperr kind as error.kind.
+func NewTracedEmployeeService(next EmployeeService, t *tracing.Tracer) EmployeeService {
+	return &tracedEmployeeService{next: next, tracer: t}
+}
+
+// start begins the span of a call of method; the returned func, deferred
+// with the address of the call's error, ends it.
+func (s *tracedEmployeeService) start(ctx context.Context, method string) (context.Context, func(*error)) {
+	ctx, span := s.tracer.Start(ctx, "EmployeeService."+method, tracing.KindInternal)
+	return ctx, func(err *error) {
+		if *err != nil {
+			span.SetAttributes(tracing.String("error.kind", apperr.KindOf(*err).String()))
+			span.RecordError(*err)
+		}
+		span.End()
+	}
+}
+
+func (s *tracedEmployeeService) CreateEmployee(ctx context.Context, in *model.Employee) (e *model.Employee, err error) {
+	ctx, end := s.start(ctx, "CreateEmployee")
+	defer end(&err)
+	return s.next.CreateEmployee(ctx, in)
+}
+
+func (s *tracedEmployeeService) UpdateEmployee(ctx context.Context, in *model.Employee) (e *model.Employee, err error) {
+	ctx, end := s.start(ctx, "UpdateEmployee")
+	defer end(&err)
+	return s.next.UpdateEmployee(ctx, in)
+}
+
+func (s *tracedEmployeeService) GetEmployee(ctx context.Context, id int64) (e *model.Employee, err error) {
+	ctx, end := s.start(ctx, "GetEmployee")
+	defer end(&err)
+	return s.next.GetEmployee(ctx, id)
+}
+
+func (s *tracedEmployeeService) GetEmployeeAsOf(ctx context.Context, id int64, at time.Time) (e *model.Employee, err error) {
+	ctx, end := s.start(ctx, "GetEmployeeAsOf")
+	defer end(&err)
+	return s.next.GetEmployeeAsOf(ctx, id, at)
+}
+
+func (s *tracedEmployeeService) ListEmployees(ctx context.Context, q *model.EmployeeQuery) (page *model.EmployeePage, err error) {
+	ctx, end := s.start(ctx, "ListEmployees")
+	defer end(&err)
+	return s.next.ListEmployees(ctx, q)
+}
+
+func (s *tracedEmployeeService) SearchEmployees(ctx context.Context, q *model.SearchQuery) (hits []*model.SearchHit, err error) {
+	ctx, end := s.start(ctx, "SearchEmployees")
+	defer end(&err)
+	return s.next.SearchEmployees(ctx, q)
+}
+
+func (s *tracedEmployeeService) ExportEmployees(ctx context.Context, q *model.EmployeeQuery, fn func(*model.Employee) error) (err error) {
+	ctx, end := s.start(ctx, "ExportEmployees")
+	defer end(&err)
+	return s.next.ExportEmployees(ctx, q, fn)
+}
+
+func (s *tracedEmployeeService) DeleteEmployee(ctx context.Context, id, version int64) (err error) {
+	ctx, end := s.start(ctx, "DeleteEmployee")
+	defer end(&err)
+	return s.next.DeleteEmployee(ctx, id, version)
+}
+
+func (s *tracedEmployeeService) RestoreEmployee(ctx context.Context, id, version int64) (e *model.Employee, err error) {
+	ctx, end := s.start(ctx, "RestoreEmployee")
+	defer end(&err)
+	return s.next.RestoreEmployee(ctx, id, version)
+}
+
+func (s *tracedEmployeeService) PurgeEmployee(ctx context.Context, id int64) (err error) {
+	ctx, end := s.start(ctx, "PurgeEmployee")
+	defer end(&err)
+	return s.next.PurgeEmployee(ctx, id)
+}
+
+func (s *tracedEmployeeService) PatchEmployee(ctx context.Context, id, version int64, format PatchFormat, patch []byte) (e *model.Employee, err error) {
+	ctx, end := s.start(ctx, "PatchEmployee")
+	defer end(&err)
+	return s.next.PatchEmployee(ctx, id, version, format, patch)
+}
+
+func (s *tracedEmployeeService) GetChain(ctx context.Context, id int64) (chain []*model.Employee, err error) {
+	ctx, end := s.start(ctx, "GetChain")
+	defer end(&err)
+	return s.next.GetChain(ctx, id)
+}
+
+func (s *tracedEmployeeService) GetReports(ctx context.Context, id int64, depth int) (nodes []*model.OrgNode, err error) {
+	ctx, end := s.start(ctx, "GetReports")
+	defer end(&err)
+	return s.next.GetReports(ctx, id, depth)
+}
+
+func (s *tracedEmployeeService) GetOrgChart(ctx context.Context) (nodes []*model.OrgNode, err error) {
+	ctx, end := s.start(ctx, "GetOrgChart")
+	defer end(&err)
+	return s.next.GetOrgChart(ctx)
+}
+
+func (s *tracedEmployeeService) ReassignReports(ctx context.Context, fromID int64, toID *int64) (n int64, err error) {
+	ctx, end := s.start(ctx, "ReassignReports")
+	defer end(&err)
+	return s.next.ReassignReports(ctx, fromID, toID)
+}
+
+func (s *tracedEmployeeService) Batch(ctx context.Context, ops []BatchOp, atomic bool) (results []BatchResult, err error) {
+	ctx, end := s.start(ctx, "Batch")
+	defer end(&err)
+	return s.next.Batch(ctx, ops, atomic)
+}
+
+func (s *tracedEmployeeService) ImportEmployees(ctx context.Context, src *ImportReader, dryRun bool, emit func(*ImportRow) error) (sum *ImportSummary, err error) {
+	ctx, end := s.start(ctx, "ImportEmployees")
+	defer end(&err)
+	return s.next.ImportEmployees(ctx, src, dryRun, emit)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/auth"
+	"emplopyee-app-go/internal/model"
+	"emplopyee-app-go/internal/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (r *spanRecorder) Export(_ context.Context, spans []*tracing.Span) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+func TestTracedEmployeeService(t *testing.T) {
+	mockDAO := new(MockEmployeeDAO)
+	rec := &spanRecorder{}
+	tracer := tracing.NewTracer("test", rec)
+	svc := NewTracedEmployeeService(NewAuthorizedEmployeeService(NewEmployeeService(mockDAO)), tracer)
+	mockDAO.On("GetByID", mock.Anything, int64(1)).Return(&model.Employee{ID: 1}, nil)
+	mockDAO.On("GetByID", mock.Anything, int64(2)).Return(nil, apperr.New(apperr.NotFound, "record not found"))
+
+	ctx, root := tracer.Start(as(auth.RoleViewer), "request", tracing.KindServer)
+	_, err := svc.GetEmployee(ctx, 1)
+	assert.NoError(t, err)
+	_, err = svc.GetEmployee(ctx, 2)
+	assert.Error(t, err)
+	root.End()
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	if assert.Len(t, rec.spans, 3) {
+		ok, failed := rec.spans[0], rec.spans[1]
+		assert.Equal(t, "EmployeeService.GetEmployee", ok.Name())
+		assert.Equal(t, root.SpanContext().SpanID, ok.Parent())
+		code, _ := ok.Status()
+		assert.Equal(t, tracing.StatusUnset, code)
+
+		code, _ = failed.Status()
+		assert.Equal(t, tracing.StatusError, code)
+		assert.Contains(t, failed.Attributes(), tracing.String("error.kind", "not_found"))
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"emplopyee-app-go/internal/apperr"
+	"emplopyee-app-go/internal/model"
+)
+
+// ErrValidation matches any *ValidationError via errors.Is.
+var ErrValidation = errors.New("validation failed")
+
+// FieldError describes one failing rule on one field. Field uses the JSON
+// name so clients can map it straight back onto their payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every FieldError found on an input.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, f := range e.Errors {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// ErrorKind classifies every ValidationError as apperr.Validation.
+func (e *ValidationError) ErrorKind() apperr.Kind { return apperr.Validation }
+
+// Rule is a single declarative check. Check returns true when v is valid.
+type Rule struct {
+	Code    string
+	Message string
+	Check   func(v interface{}) bool
+}
+
+// Field binds a set of rules to one attribute of the validated value.
+type Field[T any] struct {
+	Name  string
+	Value func(T) interface{}
+	Rules []Rule
+}
+
+// Validator runs field rules in declaration order and reports every failure,
+// not just the first.
+type Validator[T any] struct {
+	Fields []Field[T]
+}
+
+// Validate returns a *ValidationError, or nil if every rule passes.
+func (v Validator[T]) Validate(in T) error {
+	var errs []FieldError
+	for _, f := range v.Fields {
+		val := f.Value(in)
+		for _, r := range f.Rules {
+			if !r.Check(val) {
+				errs = append(errs, FieldError{Field: f.Name, Code: r.Code, Message: r.Message})
+				// one failure per field is enough; later rules usually
+				// depend on earlier ones (e.g. format after required)
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// Required rejects zero strings (after trimming).
+func Required() Rule {
+	return Rule{Code: "required", Message: "is required", Check: func(v interface{}) bool {
+		s, _ := v.(string)
+		return strings.TrimSpace(s) != ""
+	}}
+}
+
+// MaxLen limits a string to n characters.
+func MaxLen(n int) Rule {
+	return Rule{Code: "too_long", Message: fmt.Sprintf("must be at most %d characters", n), Check: func(v interface{}) bool {
+		s, _ := v.(string)
+		return utf8.RuneCountInString(s) <= n
+	}}
+}
+
+// Email accepts a bare RFC 5322 addr-spec (no display name or angle brackets).
+// Empty values pass so the rule can be combined with Required or left optional.
+func Email() Rule {
+	return Rule{Code: "invalid_email", Message: "must be a valid email address", Check: func(v interface{}) bool {
+		s, _ := v.(string)
+		if s == "" {
+			return true
+		}
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	}}
+}
+
+// OneOf restricts a non-empty string to the allowed values. An empty allowed
+// list disables the rule.
+func OneOf(allowed []string) Rule {
+	set := make(map[string]bool, len(allowed))
+	for _, a := range allowed {
+		set[a] = true
+	}
+	return Rule{Code: "not_allowed", Message: "must be one of: " + strings.Join(allowed, ", "), Check: func(v interface{}) bool {
+		s, _ := v.(string)
+		return s == "" || len(set) == 0 || set[s]
+	}}
+}
+
+// ReadOnly rejects client-supplied values for server-managed fields.
+func ReadOnly() Rule {
+	return Rule{Code: "read_only", Message: "is set by the server and must not be supplied", Check: func(v interface{}) bool {
+		switch x := v.(type) {
+		case int64:
+			return x == 0
+		case time.Time:
+			return x.IsZero()
+		case *time.Time:
+			return x == nil
+		}
+		return true
+	}}
+}
+
+// employeeFields declares the rules shared by create and update.
+func employeeFields(allowedPositions []string) []Field[*model.Employee] {
+	return []Field[*model.Employee]{
+		{Name: "first_name", Value: func(e *model.Employee) interface{} { return e.FirstName }, Rules: []Rule{Required(), MaxLen(100)}},
+		{Name: "last_name", Value: func(e *model.Employee) interface{} { return e.LastName }, Rules: []Rule{Required(), MaxLen(100)}},
+		{Name: "email", Value: func(e *model.Employee) interface{} { return e.Email }, Rules: []Rule{Required(), MaxLen(254), Email()}},
+		{Name: "position", Value: func(e *model.Employee) interface{} { return e.Position }, Rules: []Rule{MaxLen(100), OneOf(allowedPositions)}},
+	}
+}
+
+// newEmployeeValidators builds the create and update validators. Create also
+// rejects server-managed fields; on update the id comes from the URL, the
+// version is the optimistic-lock precondition and the timestamps are ignored,
+// so a GET-modify-PUT round trip is accepted.
+func newEmployeeValidators(allowedPositions []string) (create, update Validator[*model.Employee]) {
+	shared := employeeFields(allowedPositions)
+	readOnly := []Field[*model.Employee]{
+		{Name: "id", Value: func(e *model.Employee) interface{} { return e.ID }, Rules: []Rule{ReadOnly()}},
+		{Name: "created_at", Value: func(e *model.Employee) interface{} { return e.CreatedAt }, Rules: []Rule{ReadOnly()}},
+		{Name: "updated_at", Value: func(e *model.Employee) interface{} { return e.UpdatedAt }, Rules: []Rule{ReadOnly()}},
+		{Name: "version", Value: func(e *model.Employee) interface{} { return e.Version }, Rules: []Rule{ReadOnly()}},
+		{Name: "deleted_at", Value: func(e *model.Employee) interface{} { return e.DeletedAt }, Rules: []Rule{ReadOnly()}},
+	}
+	create = Validator[*model.Employee]{Fields: append(shared, readOnly...)}
+	update = Validator[*model.Employee]{Fields: shared}
+	return create, update
+}
+
+// normalizeEmployee trims surrounding whitespace from user-entered text.
+func normalizeEmployee(e *model.Employee) {
+	e.FirstName = strings.TrimSpace(e.FirstName)
+	e.LastName = strings.TrimSpace(e.LastName)
+	e.Email = strings.TrimSpace(e.Email)
+	e.Position = strings.TrimSpace(e.Position)
+}
@@ -0,0 +1,351 @@
+// Package tracing records OpenTelemetry-compatible spans without the
+// OpenTelemetry SDK: W3C Trace Context propagation, a tracer that batches
+// finished spans, and exporters that write them as OTLP/JSON, either to an
+// OTLP/HTTP collector or to a file or stdout.
+//
+// A nil *Tracer is valid and records nothing, and so is the nil *Span it
+// returns, so instrumented code does not need to check whether tracing is on.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID and SpanID identify a trace and a span within it.
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// SpanContext is what propagates between processes: the trace, the parent
+// span and whether the trace is sampled.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+}
+
+// IsValid reports whether sc has non-zero IDs.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Extract reads the W3C traceparent and tracestate headers of h. It returns
+// false when there is no valid traceparent.
+func Extract(h http.Header) (SpanContext, bool) {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	parts := strings.Split(strings.TrimSpace(h.Get("traceparent")), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() || strings.ToLower(parts[1]) != parts[1] || strings.ToLower(parts[2]) != parts[2] {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	sc.TraceState = h.Get("tracestate")
+	return sc, true
+}
+
+// Inject writes sc to the traceparent and tracestate headers of h.
+func Inject(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	h.Set("traceparent", "00-"+sc.TraceID.String()+"-"+sc.SpanID.String()+"-"+flags)
+	if sc.TraceState != "" {
+		h.Set("tracestate", sc.TraceState)
+	}
+}
+
+// SpanKind says what side of a call a span is on.
+type SpanKind int
+
+// The values are OTLP's.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Attribute is a key and a string, int64, float64 or bool value.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String, Int and Bool build attributes.
+func String(k, v string) Attribute    { return Attribute{k, v} }
+func Int(k string, v int64) Attribute { return Attribute{k, v} }
+func Bool(k string, v bool) Attribute { return Attribute{k, v} }
+
+// StatusCode is the outcome of a span; the values are OTLP's.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Span is one timed operation. Its methods may be called on a nil *Span and
+// are safe for use by one goroutine at a time, like the code it measures.
+type Span struct {
+	tracer  *Tracer
+	sc      SpanContext
+	parent  SpanID
+	name    string
+	kind    SpanKind
+	start   time.Time
+	end     time.Time
+	attrs   []Attribute
+	status  StatusCode
+	message string
+	ended   bool
+}
+
+// SpanContext returns the context to propagate for s.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// Name, Kind, Parent, Attributes, Status, StartTime and EndTime describe a
+// span to exporters; call them after End.
+func (s *Span) Name() string                 { return s.name }
+func (s *Span) Kind() SpanKind               { return s.kind }
+func (s *Span) Parent() SpanID               { return s.parent }
+func (s *Span) Attributes() []Attribute      { return s.attrs }
+func (s *Span) Status() (StatusCode, string) { return s.status, s.message }
+func (s *Span) StartTime() time.Time         { return s.start }
+func (s *Span) EndTime() time.Time           { return s.end }
+
+// SetName renames s, for names only known once the work is done, such as the
+// route of a request.
+func (s *Span) SetName(name string) {
+	if s != nil {
+		s.name = name
+	}
+}
+
+// SetAttributes adds attributes to s.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s != nil {
+		s.attrs = append(s.attrs, attrs...)
+	}
+}
+
+// SetStatus sets the outcome of s.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s != nil {
+		s.status, s.message = code, message
+	}
+}
+
+// RecordError marks s as failed with err, if err is not nil.
+func (s *Span) RecordError(err error) {
+	if s != nil && err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End finishes s and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	if s.sc.Sampled {
+		s.tracer.enqueue(s)
+	}
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns ctx carrying s as the parent of spans started from
+// it.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext returns the current span of ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+type remoteKey struct{}
+
+// ContextWithRemote returns ctx carrying sc, extracted from an incoming
+// request, as the parent of the next span started from it.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Exporter sends finished spans somewhere.
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer starts spans and exports them in batches from a background
+// goroutine.
+type Tracer struct {
+	service  string
+	exporter Exporter
+
+	mu      sync.Mutex
+	queue   []*Span
+	dropped int
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+const (
+	batchSize     = 512
+	maxQueue      = 4096
+	flushInterval = 5 * time.Second
+)
+
+// NewTracer returns a tracer that exports the spans of service through exp.
+// Call Shutdown to export what is still queued.
+func NewTracer(service string, exp Exporter) *Tracer {
+	t := &Tracer{
+		service:  service,
+		exporter: exp,
+		flush:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Start begins a span named name as a child of the span in ctx, or of the
+// remote parent put there by ContextWithRemote, or as the root of a new
+// trace. It returns ctx carrying the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{tracer: t, name: name, kind: kind, start: time.Now(), attrs: attrs}
+	if p := SpanFromContext(ctx); p != nil {
+		s.sc = p.sc
+		s.parent = p.sc.SpanID
+	} else if rc, ok := ctx.Value(remoteKey{}).(SpanContext); ok && rc.IsValid() {
+		s.sc = rc
+		s.parent = rc.SpanID
+	} else {
+		rand.Read(s.sc.TraceID[:])
+		s.sc.Sampled = true
+	}
+	rand.Read(s.sc.SpanID[:])
+	return ContextWithSpan(ctx, s), s
+}
+
+func (t *Tracer) enqueue(s *Span) {
+	t.mu.Lock()
+	if len(t.queue) >= maxQueue {
+		t.dropped++
+		t.mu.Unlock()
+		return
+	}
+	t.queue = append(t.queue, s)
+	full := len(t.queue) >= batchSize
+	t.mu.Unlock()
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	tick := time.NewTicker(flushInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-tick.C:
+		case <-t.flush:
+		}
+		t.export(context.Background())
+	}
+}
+
+// export sends everything queued, in batches.
+func (t *Tracer) export(ctx context.Context) error {
+	t.mu.Lock()
+	spans, dropped := t.queue, t.dropped
+	t.queue, t.dropped = nil, 0
+	t.mu.Unlock()
+	if dropped > 0 {
+		slog.Warn("tracing: queue full, dropped spans", "dropped", dropped)
+	}
+	for len(spans) > 0 {
+		n := min(len(spans), batchSize)
+		ectx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := t.exporter.Export(ectx, spans[:n])
+		cancel()
+		if err != nil {
+			slog.Error("tracing: export failed", "spans", n, "err", err)
+			if ctx.Err() != nil {
+				return err
+			}
+		}
+		spans = spans[n:]
+	}
+	return nil
+}
+
+// Shutdown stops the background export, exports the spans still queued and
+// shuts the exporter down.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	close(t.done)
+	<-t.stopped
+	if err := t.export(ctx); err != nil {
+		return err
+	}
+	if err := t.exporter.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shut down exporter: %w", err)
+	}
+	return nil
+}
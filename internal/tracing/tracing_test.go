@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *recorder) Export(_ context.Context, spans []*Span) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *recorder) Shutdown(context.Context) error { return nil }
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		header  string
+		ok      bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		// a later version may append fields
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", false, false},
+		{"garbage", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("traceparent", tt.header)
+		sc, ok := Extract(h)
+		if ok != tt.ok || sc.Sampled != tt.sampled {
+			t.Errorf("Extract(%q) = %+v, %v; want ok %v, sampled %v", tt.header, sc, ok, tt.ok, tt.sampled)
+		}
+	}
+
+	h := http.Header{}
+	h.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Set("tracestate", "vendor=x")
+	sc, _ := Extract(h)
+	out := http.Header{}
+	Inject(sc, out)
+	if out.Get("traceparent") != h.Get("traceparent") || out.Get("tracestate") != "vendor=x" {
+		t.Errorf("Inject wrote %v, want the extracted headers back", out)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	rec := &recorder{}
+	tr := NewTracer("test", rec)
+
+	h := http.Header{}
+	h.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	remote, _ := Extract(h)
+	ctx, root := tr.Start(ContextWithRemote(context.Background(), remote), "root", KindServer, String("a", "b"))
+	_, child := tr.Start(ctx, "child", KindInternal)
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End() // a second End is ignored
+
+	// an unsampled parent records nothing
+	h.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	unsampled, _ := Extract(h)
+	_, s := tr.Start(ContextWithRemote(context.Background(), unsampled), "dropped", KindServer)
+	s.End()
+
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(rec.spans))
+	}
+	c, r := rec.spans[0], rec.spans[1]
+	if c.Name() != "child" || r.Name() != "root" {
+		t.Fatalf("exported %q, %q", c.Name(), r.Name())
+	}
+	if r.SpanContext().TraceID != remote.TraceID || r.Parent() != remote.SpanID {
+		t.Errorf("root did not continue the remote trace: %+v", r.SpanContext())
+	}
+	if c.SpanContext().TraceID != remote.TraceID || c.Parent() != r.SpanContext().SpanID {
+		t.Errorf("child is not a child of root")
+	}
+	if code, msg := c.Status(); code != StatusError || msg != "boom" {
+		t.Errorf("child status = %v %q", code, msg)
+	}
+
+	// nil tracers and spans do nothing
+	var none *Tracer
+	ctx, s = none.Start(context.Background(), "x", KindInternal)
+	s.SetAttributes(String("k", "v"))
+	s.End()
+	if SpanFromContext(ctx) != nil {
+		t.Error("a nil tracer put a span in the context")
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tr := NewTracer("svc", NewWriterExporter("svc", &buf))
+	_, s := tr.Start(context.Background(), "op", KindClient, String("s", "x"), Int("n", 3), Bool("b", true))
+	s.End()
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got otlpRequest
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("not OTLP/JSON: %v\n%s", err, buf.String())
+	}
+	rs := got.ResourceSpans[0]
+	if *rs.Resource.Attributes[0].Value.StringValue != "svc" {
+		t.Errorf("service.name = %v", rs.Resource.Attributes)
+	}
+	span := rs.ScopeSpans[0].Spans[0]
+	if span.Name != "op" || span.Kind != KindClient || len(span.TraceID) != 32 || len(span.SpanID) != 16 || span.ParentSpanID != "" {
+		t.Errorf("unexpected span %+v", span)
+	}
+	if *span.Attributes[1].Value.IntValue != "3" || !*span.Attributes[2].Value.BoolValue {
+		t.Errorf("unexpected attributes %+v", span.Attributes)
+	}
+}
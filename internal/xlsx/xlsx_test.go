@@ -0,0 +1,80 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, want, column(i))
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Staff & Co")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	assert.NoError(t, w.WriteRow("id", "name", "note"))
+	assert.NoError(t, w.WriteRow(int64(1), "Ann <Lee>", nil, 2.5))
+	assert.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	parts := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = b
+		// every part must be well-formed XML
+		dec := xml.NewDecoder(bytes.NewReader(b))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", f.Name, err)
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		assert.Contains(t, parts, name)
+	}
+	assert.Contains(t, string(parts["xl/workbook.xml"]), `name="Staff &amp; Co"`)
+
+	var sheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R string `xml:"r,attr"`
+				T string `xml:"t,attr"`
+				V string `xml:"v"`
+				S string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("sheet: %v", err)
+	}
+	if assert.Len(t, sheet.Rows, 2) {
+		assert.Equal(t, 2, sheet.Rows[1].R)
+		cells := sheet.Rows[1].Cells
+		if assert.Len(t, cells, 3) {
+			assert.Equal(t, []string{"A2", "", "1"}, []string{cells[0].R, cells[0].T, cells[0].V})
+			assert.Equal(t, []string{"B2", "inlineStr", "Ann <Lee>"}, []string{cells[1].R, cells[1].T, cells[1].S})
+			assert.Equal(t, []string{"D2", "2.5"}, []string{cells[2].R, cells[2].V})
+		}
+	}
+}